@@ -7,9 +7,15 @@ import (
 	"belajar-go/internal/repository"
 	// myws "belajar-go/internal/websocket"
 	"belajar-go/internal/config"
-	"belajar-go/pkg/database"
-	"belajar-go/pkg/logger"
 
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -20,46 +26,68 @@ import (
 )
 
 func main() {
-	// Inisialisasi logger
-	logger.InitLoggers()
-	defer logger.SyncLoggers()
-	logger.SystemLogger.Info("Starting application", zap.String("time", time.Now().Format(time.RFC3339)))
-
-	// Load config
-	cfg := configs.LoadConfig()
-	// Inisialisasi database
-	config.DB = database.ConnectDB(cfg)
-	defer config.DB.Close()
+	// Load config dari default, file konfigurasi, .env, environment, dan flag.
+	// Aplikasi berhenti jika secret wajib belum diisi atau masih memakai default.
+	cfg, err := configs.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
 
-	logger.SystemLogger.Info("Database Connected")
+	// Inisialisasi logger, database, dan Redis
+	deps, err := config.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize application: %v", err)
+	}
+	defer deps.Close()
+	deps.Log.SystemLogger.Info("Starting application", zap.String("time", time.Now().Format(time.RFC3339)))
+	deps.Log.SystemLogger.Info("Database Connected")
 
 	// ----- Inisialisasi repository ----- //
-	// Buat tabel jika belum ada:
-	repository.CreateTableIfNotExists(config.DB)
+	// Jalankan migrasi jika DB_AUTO_MIGRATE=true,
+	// selain itu gunakan perintah: go run ./cmd/migrate up
+	if cfg.AutoMigrate {
+		applied, err := repository.RunMigrations(context.Background(), deps.DB)
+		if err != nil {
+			deps.Log.ErrorLogger.Error("Database migration failed", zap.Error(err))
+			log.Fatalf("Database migration failed: %v", err)
+		}
+		for _, m := range applied {
+			deps.Log.SystemLogger.Info("Migration applied", zap.Int64("version", m.Version), zap.String("name", m.Name))
+		}
+	}
 	// Jika ingin membuat admin user:
-	// repository.CreateAdminUser(config.DB)
+	// repository.CreateAdminUser(deps.DB, deps.Passwords)
 	// Jika ingin menghapus tabel:
-	// repository.DeleteAllTable(config.DB)
-
-	// Inisialisasi Redis (Anda bisa pindahkan ke pkg/database juga jika diperlukan)
-	config.RedisClient = database.ConnectRedis(cfg)
-	defer config.RedisClient.Close()
+	// repository.DeleteAllTable(deps.DB)
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		// beri ruang untuk header multipart di atas ukuran file maksimal
+		BodyLimit: int(cfg.UploadMaxSize) + 1<<20,
+	})
 
 	// Middleware
-	app.Use(middleware.ErrorHandler())
+	app.Use(middleware.ErrorHandler(deps))
+
+	// Liveness dan readiness probe, didaftarkan sebelum rate limiter
+	v1.RegisterHealthRoutes(app, deps)
+
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
+		AllowOrigins: cfg.CORSAllowOrigins,
 		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
 	}))
 	app.Use(limiter.New(limiter.Config{
-		Max:        100,
-		Expiration: 1 * time.Minute,
+		Max:        cfg.RateLimitMax,
+		Expiration: cfg.RateLimitWindow,
 	}))
 
+	// Public key untuk verifikasi access token
+	v1.RegisterWellKnownRoutes(app, deps)
+
 	// Daftarkan route API v1
-	v1.RegisterRoutes(app)
+	v1.RegisterRoutes(app.Group("/api/v1"), deps)
 
 	// // WebSocket Routes (sesuai kebutuhan)
 	// hub := myws.NewHub()
@@ -88,8 +116,36 @@ func main() {
 	// 	}
 	// }))
 
-	logger.SystemLogger.Info("Application ready, listening on port 3004")
-	if err := app.Listen(":3004"); err != nil {
-		logger.ErrorLogger.Error("Application failed to start", zap.Error(err))
+	// Jalankan server di goroutine agar main bisa menunggu sinyal berhenti
+	listenErr := make(chan error, 1)
+	go func() {
+		deps.Log.SystemLogger.Info("Application ready", zap.Int("port", cfg.Port))
+		listenErr <- app.Listen(fmt.Sprintf(":%d", cfg.Port))
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			deps.Log.ErrorLogger.Error("Application failed to start", zap.Error(err))
+		}
+		return
+	case <-ctx.Done():
+	}
+	// sinyal kedua langsung menghentikan proses tanpa menunggu
+	stop()
+
+	// Tandai not-ready lebih dulu agar load balancer berhenti mengirim
+	// request baru, lalu tunggu request yang sedang berjalan selesai
+	deps.Health.SetDraining()
+	deps.Log.SystemLogger.Info("Shutdown signal received, draining",
+		zap.Duration("delay", cfg.ShutdownDelay), zap.Duration("timeout", cfg.ShutdownTimeout))
+	time.Sleep(cfg.ShutdownDelay)
+
+	if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		deps.Log.ErrorLogger.Error("Graceful shutdown failed", zap.Error(err))
 	}
+	deps.Log.SystemLogger.Info("Application stopped")
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"belajar-go/configs"
+	"belajar-go/internal/repository"
+	"belajar-go/pkg/database"
+	"belajar-go/pkg/migrate"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const usage = `Usage: go run ./cmd/migrate <command> [args]
+
+Commands:
+  up          Jalankan semua migrasi yang belum diterapkan
+  down [n]    Batalkan n migrasi terakhir (default 1, "all" untuk semua)
+  status      Tampilkan status setiap migrasi
+  redo        Batalkan lalu jalankan ulang migrasi terakhir
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := configs.LoadConfig()
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := repository.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations("Applied", applied)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	case "down":
+		steps := 1
+		if arg := flag.Arg(1); arg == "all" {
+			steps = 0
+		} else if arg != "" {
+			steps, err = strconv.Atoi(arg)
+			if err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps: %q", arg)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		printMigrations("Reverted", reverted)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	case "redo":
+		applied, err := migrator.Redo(ctx)
+		printMigrations("Redone", applied)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Error reading migration status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Modified {
+				state += " (MODIFIED: checksum mismatch)"
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func printMigrations(action string, migrations []migrate.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("No migrations to run.")
+		return
+	}
+	for _, m := range migrations {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
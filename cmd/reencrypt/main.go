@@ -0,0 +1,65 @@
+package main
+
+import (
+	"belajar-go/configs"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"belajar-go/pkg/database"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `Usage: go run ./cmd/reencrypt [-batch-size n] [-after id]
+
+Mengenkripsi ulang tasks.security_code dengan key terbaru (ENCRYPTION_KEY_ID,
+atau key pertama di ENCRYPTION_KEYS). Task yang sudah memakai key terbaru
+dilewati, sehingga perintah ini aman dijalankan berulang kali. Jika berhenti
+di tengah jalan, lanjutkan dengan -after <last id> yang dicetak terakhir.
+
+Flags:
+`
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "number of tasks per transaction")
+	afterID := flag.Int("after", 0, "only process tasks with an ID greater than this (resume point)")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *batchSize < 1 || *afterID < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := configs.LoadConfig()
+	keyring, err := cfg.Keyring()
+	if err != nil {
+		log.Fatalf("Invalid encryption keys: %v", err)
+	}
+
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	// berhenti setelah batch yang sedang berjalan jika menerima sinyal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Re-encrypting task security codes with key %q\n", keyring.PrimaryKeyID())
+	tasks := repository.NewPostgresTaskRepository(db)
+	result, err := service.ReencryptTasks(ctx, tasks, keyring, *afterID, *batchSize, func(b repository.ReencryptBatch) {
+		fmt.Printf("last_id=%d scanned=%d updated=%d\n", b.LastID, b.Scanned, b.Updated)
+	})
+	fmt.Printf("Done: scanned=%d updated=%d last_id=%d\n", result.Scanned, result.Updated, result.LastID)
+	if err != nil {
+		log.Fatalf("Re-encryption stopped: %v (resume with -after %d)", err, result.LastID)
+	}
+}
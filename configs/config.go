@@ -1,51 +1,503 @@
 package configs
 
 import (
+	"belajar-go/pkg/crypto"
+	"belajar-go/pkg/jwk"
+	"belajar-go/pkg/password"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// Config berisi semua pengaturan aplikasi.
+//
+// Setiap field bisa diisi dari (urutan prioritas dari rendah ke tinggi):
+// nilai default, file konfigurasi YAML/TOML, file .env, environment variable,
+// dan flag command-line. Tag env adalah nama environment variable; key di file
+// konfigurasi adalah nama yang sama dalam huruf kecil (misalnya db_host),
+// dan flag-nya memakai tanda minus (misalnya -db-host).
 type Config struct {
-	DBHost     string
-	DBPort     int
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBNameTest string
-	RedisHost  string
-	RedisPort  int
+	DBHost        string `env:"DB_HOST" usage:"Postgres host"`
+	DBPort        int    `env:"DB_PORT" usage:"Postgres port"`
+	DBUser        string `env:"DB_USER" usage:"Postgres user"`
+	DBPassword    string `env:"DB_PASSWORD" usage:"Postgres password"`
+	DBName        string `env:"DB_NAME" usage:"Postgres database name"`
+	DBNameTest    string `env:"DB_NAME_TEST" usage:"Postgres database name used by tests"`
+	RedisHost     string `env:"REDIS_HOST" usage:"Redis host"`
+	RedisPort     int    `env:"REDIS_PORT" usage:"Redis port"`
+	RedisPassword string `env:"REDIS_PASSWORD" usage:"Redis password"`
+	AutoMigrate   bool   `env:"DB_AUTO_MIGRATE" usage:"run database migrations on startup"`
+
+	Port             int           `env:"PORT" usage:"HTTP listen port"`
+	CORSAllowOrigins string        `env:"CORS_ALLOW_ORIGINS" usage:"comma separated list of allowed CORS origins"`
+	RateLimitMax     int           `env:"RATE_LIMIT_MAX" usage:"maximum requests per client per window"`
+	RateLimitWindow  time.Duration `env:"RATE_LIMIT_WINDOW" usage:"rate limiter window"`
+	LogDir           string        `env:"LOG_DIR" usage:"directory for log files"`
+	ShutdownTimeout  time.Duration `env:"SHUTDOWN_TIMEOUT" usage:"maximum time to wait for in-flight requests on shutdown"`
+	ShutdownDelay    time.Duration `env:"SHUTDOWN_DELAY" usage:"time to report not-ready before the server stops accepting requests"`
+	ReadinessTimeout time.Duration `env:"READINESS_TIMEOUT" usage:"timeout for each dependency check in /readyz"`
+
+	EmailLinkSecret string        `env:"EMAIL_LINK_SECRET" usage:"secret used to sign email verification links"`
+	JWTKeyFiles     string        `env:"JWT_KEY_FILES" usage:"comma separated kid:path list of PEM keys (RSA or Ed25519) for access tokens"`
+	JWTKeyID        string        `env:"JWT_KEY_ID" usage:"kid of the private key that signs new access tokens (default: first private key in JWT_KEY_FILES)"`
+	JWTIssuer       string        `env:"JWT_ISSUER" usage:"iss claim of access tokens"`
+	JWTAudience     string        `env:"JWT_AUDIENCE" usage:"aud claim of access tokens"`
+	JWTLeeway       time.Duration `env:"JWT_LEEWAY" usage:"allowed clock skew when checking exp, nbf and iat of access tokens"`
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL" usage:"access token lifetime"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL" usage:"refresh token lifetime"`
+
+	LoginMaxFailures     int           `env:"LOGIN_MAX_FAILURES" usage:"failed logins per username before the account is locked"`
+	LoginIPMaxFailures   int           `env:"LOGIN_IP_MAX_FAILURES" usage:"failed logins per IP address before the address is blocked"`
+	LoginFailureWindow   time.Duration `env:"LOGIN_FAILURE_WINDOW" usage:"period in which failed logins are counted"`
+	LoginLockoutDuration time.Duration `env:"LOGIN_LOCKOUT_DURATION" usage:"how long a locked account or blocked IP address stays locked"`
+	LoginBackoffBase     time.Duration `env:"LOGIN_BACKOFF_BASE" usage:"delay after the second failed login, doubled on every further failure (0 disables backoff)"`
+	LoginBackoffMax      time.Duration `env:"LOGIN_BACKOFF_MAX" usage:"maximum delay between failed logins"`
+
+	Argon2Memory      int `env:"ARGON2_MEMORY" usage:"Argon2id memory cost in KiB"`
+	Argon2Iterations  int `env:"ARGON2_ITERATIONS" usage:"Argon2id number of iterations"`
+	Argon2Parallelism int `env:"ARGON2_PARALLELISM" usage:"Argon2id degree of parallelism"`
+
+	EncryptionKeys      string `env:"ENCRYPTION_KEYS" usage:"comma separated id:base64 list of 32-byte AES keys for task security codes"`
+	EncryptionKeyID     string `env:"ENCRYPTION_KEY_ID" usage:"ID of the key used to encrypt new data (default: first key in ENCRYPTION_KEYS)"`
+	LegacyEncryptionKey string `env:"LEGACY_ENCRYPTION_KEY" usage:"old ENCRYPTION_KEY passphrase, only used to decrypt data written before ENCRYPTION_KEYS"`
+
+	AppBaseURL       string        `env:"APP_BASE_URL" usage:"public base URL used in links sent by email"`
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" usage:"password reset token lifetime"`
+
+	RequireEmailVerification bool          `env:"REQUIRE_EMAIL_VERIFICATION" usage:"reject members with an unverified email on authenticated routes"`
+	EmailVerificationTTL     time.Duration `env:"EMAIL_VERIFICATION_TTL" usage:"email verification link lifetime"`
+	VerificationResendWindow time.Duration `env:"VERIFICATION_RESEND_WINDOW" usage:"minimum time between verification emails to the same address"`
+
+	MFAIssuer       string        `env:"MFA_ISSUER" usage:"issuer name shown in authenticator apps for TOTP"`
+	MFAChallengeTTL time.Duration `env:"MFA_CHALLENGE_TTL" usage:"how long a login waits for the TOTP code after the password was accepted"`
+
+	OIDCProvidersFile string        `env:"OIDC_PROVIDERS_FILE" usage:"YAML or TOML file with the OpenID Connect providers for social login (empty disables it)"`
+	OIDCStateTTL      time.Duration `env:"OIDC_STATE_TTL" usage:"how long a login may stay at the identity provider before the callback"`
+
+	TaskWorkflowFile string `env:"TASK_WORKFLOW_FILE" usage:"YAML or TOML file with the task statuses, allowed transitions and the roles for each (empty uses the default workflow)"`
+
+	MailDriver   string `env:"MAIL_DRIVER" usage:"how emails are delivered: smtp, file or log"`
+	MailFrom     string `env:"MAIL_FROM" usage:"sender address for emails"`
+	MailDir      string `env:"MAIL_DIR" usage:"directory for emails when MAIL_DRIVER is file"`
+	SMTPHost     string `env:"SMTP_HOST" usage:"SMTP server host"`
+	SMTPPort     int    `env:"SMTP_PORT" usage:"SMTP server port"`
+	SMTPUsername string `env:"SMTP_USERNAME" usage:"SMTP username (empty disables authentication)"`
+	SMTPPassword string `env:"SMTP_PASSWORD" usage:"SMTP password"`
+
+	UploadDir     string `env:"UPLOAD_DIR" usage:"directory for uploaded files"`
+	UploadMaxSize int64  `env:"UPLOAD_MAX_SIZE" usage:"maximum upload size in bytes"`
+}
+
+// Default mengembalikan Config dengan nilai default. Secret sengaja
+// dikosongkan agar harus diisi secara eksplisit.
+func Default() Config {
+	return Config{
+		DBPort:    10501,
+		RedisPort: 6379,
+
+		Port:             3004,
+		CORSAllowOrigins: "*",
+		RateLimitMax:     100,
+		RateLimitWindow:  time.Minute,
+		LogDir:           "logs",
+		ShutdownTimeout:  15 * time.Second,
+		ShutdownDelay:    5 * time.Second,
+		ReadinessTimeout: 2 * time.Second,
+
+		JWTIssuer:       "belajar-go",
+		JWTAudience:     "belajar-go-api",
+		JWTLeeway:       30 * time.Second,
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+
+		LoginMaxFailures:     5,
+		LoginIPMaxFailures:   50,
+		LoginFailureWindow:   15 * time.Minute,
+		LoginLockoutDuration: 15 * time.Minute,
+		LoginBackoffBase:     time.Second,
+		LoginBackoffMax:      time.Minute,
+
+		Argon2Memory:      int(password.DefaultParams().Memory),
+		Argon2Iterations:  int(password.DefaultParams().Iterations),
+		Argon2Parallelism: int(password.DefaultParams().Parallelism),
+
+		AppBaseURL:       "http://localhost:3004",
+		PasswordResetTTL: time.Hour,
+
+		EmailVerificationTTL:     48 * time.Hour,
+		VerificationResendWindow: time.Minute,
+
+		MFAIssuer:       "belajar-go",
+		MFAChallengeTTL: 5 * time.Minute,
+
+		OIDCStateTTL: 10 * time.Minute,
+
+		MailDriver: "log",
+		MailFrom:   "no-reply@localhost",
+		MailDir:    "mail",
+		SMTPPort:   587,
+
+		UploadDir:     "uploads",
+		UploadMaxSize: 5 << 20,
+	}
+}
+
+// nilai secret yang pernah di-hardcode atau umum dipakai sebagai contoh
+var insecureSecrets = map[string]bool{
+	"secret":            true,
+	"changeme":          true,
+	"JWT_SECRET":        true,
+	"EMAIL_LINK_SECRET": true,
 }
 
+// minimal panjang secret
+const minSecretLength = 32
+
+// ValidationError berisi semua masalah konfigurasi yang ditemukan.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate mengecek secret wajib dan nilai yang tidak masuk akal.
+func (c Config) Validate() error {
+	var problems []string
+	checkSecret := func(name, value string, minLength int) {
+		switch {
+		case value == "":
+			problems = append(problems, name+" is required")
+		case insecureSecrets[value]:
+			problems = append(problems, name+" is set to an insecure default value")
+		case len(value) < minLength:
+			problems = append(problems, fmt.Sprintf("%s must be at least %d characters", name, minLength))
+		}
+	}
+	checkSecret("EMAIL_LINK_SECRET", c.EmailLinkSecret, minSecretLength)
+	if c.JWTKeyFiles == "" {
+		problems = append(problems, "JWT_KEY_FILES is required")
+	} else if _, err := c.JWTKeys(); err != nil {
+		problems = append(problems, "JWT_KEY_FILES: "+err.Error())
+	}
+	if c.EncryptionKeys == "" {
+		problems = append(problems, "ENCRYPTION_KEYS is required")
+	} else if _, err := c.Keyring(); err != nil {
+		problems = append(problems, "ENCRYPTION_KEYS: "+err.Error())
+	}
+
+	if c.Port <= 0 || c.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT %d is out of range", c.Port))
+	}
+	if c.JWTIssuer == "" || c.JWTAudience == "" {
+		problems = append(problems, "JWT_ISSUER and JWT_AUDIENCE are required")
+	}
+	if c.AccessTokenTTL <= 0 {
+		problems = append(problems, "ACCESS_TOKEN_TTL must be positive")
+	}
+	if c.JWTLeeway < 0 || c.JWTLeeway > 5*time.Minute {
+		problems = append(problems, "JWT_LEEWAY must be between 0 and 5m")
+	}
+	if c.RefreshTokenTTL <= c.AccessTokenTTL {
+		problems = append(problems, "REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL")
+	}
+	if c.RateLimitMax <= 0 || c.RateLimitWindow <= 0 {
+		problems = append(problems, "RATE_LIMIT_MAX and RATE_LIMIT_WINDOW must be positive")
+	}
+	if c.ShutdownTimeout <= 0 || c.ReadinessTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT and READINESS_TIMEOUT must be positive")
+	}
+	if c.ShutdownDelay < 0 {
+		problems = append(problems, "SHUTDOWN_DELAY must not be negative")
+	}
+	if c.LoginMaxFailures < 1 || c.LoginIPMaxFailures < 1 {
+		problems = append(problems, "LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES must be at least 1")
+	}
+	if c.LoginFailureWindow <= 0 || c.LoginLockoutDuration <= 0 {
+		problems = append(problems, "LOGIN_FAILURE_WINDOW and LOGIN_LOCKOUT_DURATION must be positive")
+	}
+	if c.LoginBackoffBase < 0 || c.LoginBackoffMax < c.LoginBackoffBase {
+		problems = append(problems, "LOGIN_BACKOFF_BASE must not be negative or greater than LOGIN_BACKOFF_MAX")
+	}
+	if c.Argon2Parallelism < 1 || c.Argon2Parallelism > 255 {
+		problems = append(problems, "ARGON2_PARALLELISM must be between 1 and 255")
+	}
+	if c.Argon2Iterations < 1 {
+		problems = append(problems, "ARGON2_ITERATIONS must be at least 1")
+	}
+	if c.Argon2Memory < 8*c.Argon2Parallelism {
+		problems = append(problems, "ARGON2_MEMORY must be at least 8 KiB per ARGON2_PARALLELISM")
+	}
+	if c.PasswordResetTTL <= 0 {
+		problems = append(problems, "PASSWORD_RESET_TTL must be positive")
+	}
+	if c.EmailVerificationTTL <= 0 || c.VerificationResendWindow <= 0 {
+		problems = append(problems, "EMAIL_VERIFICATION_TTL and VERIFICATION_RESEND_WINDOW must be positive")
+	}
+	if c.MFAIssuer == "" || strings.Contains(c.MFAIssuer, ":") {
+		problems = append(problems, "MFA_ISSUER is required and must not contain ':'")
+	}
+	if c.MFAChallengeTTL <= 0 {
+		problems = append(problems, "MFA_CHALLENGE_TTL must be positive")
+	}
+	if _, err := c.OIDCProviders(); err != nil {
+		problems = append(problems, "OIDC_PROVIDERS_FILE: "+err.Error())
+	}
+	if c.OIDCStateTTL <= 0 {
+		problems = append(problems, "OIDC_STATE_TTL must be positive")
+	}
+	if _, err := c.TaskWorkflow(); err != nil {
+		problems = append(problems, "TASK_WORKFLOW_FILE: "+err.Error())
+	}
+	switch c.MailDriver {
+	case "log":
+	case "file":
+		if c.MailDir == "" {
+			problems = append(problems, "MAIL_DIR is required when MAIL_DRIVER is file")
+		}
+	case "smtp":
+		if c.SMTPHost == "" {
+			problems = append(problems, "SMTP_HOST is required when MAIL_DRIVER is smtp")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("MAIL_DRIVER %q is not one of smtp, file, log", c.MailDriver))
+	}
+	if c.UploadDir == "" {
+		problems = append(problems, "UPLOAD_DIR is required")
+	}
+	if c.UploadMaxSize <= 0 {
+		problems = append(problems, "UPLOAD_MAX_SIZE must be positive")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Keyring membuat keyring enkripsi dari ENCRYPTION_KEYS, ENCRYPTION_KEY_ID,
+// dan LEGACY_ENCRYPTION_KEY.
+func (c Config) Keyring() (*crypto.Keyring, error) {
+	return crypto.ParseKeyring(c.EncryptionKeys, c.EncryptionKeyID, c.LegacyEncryptionKey)
+}
+
+// JWTKeys membaca key penandatangan access token dari JWT_KEY_FILES dan
+// JWT_KEY_ID.
+func (c Config) JWTKeys() (*jwk.KeySet, error) {
+	return jwk.ParseKeySet(c.JWTKeyFiles, c.JWTKeyID)
+}
+
+// PasswordParams mengembalikan parameter Argon2id dari konfigurasi.
+func (c Config) PasswordParams() password.Params {
+	p := password.DefaultParams()
+	p.Memory = uint32(c.Argon2Memory)
+	p.Iterations = uint32(c.Argon2Iterations)
+	p.Parallelism = uint8(c.Argon2Parallelism)
+	return p
+}
+
+// Load membaca konfigurasi lengkap dari semua sumber, termasuk flag di args,
+// lalu memvalidasinya. Flag -config atau env CONFIG_FILE menunjuk ke file
+// konfigurasi YAML (.yaml/.yml) atau TOML (.toml) yang bersifat opsional.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flagValues := registerFlags(fs, &cfg)
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	loadDotEnv()
+
+	if *configFile != "" {
+		values, err := readConfigFile(*configFile)
+		if err != nil {
+			return cfg, err
+		}
+		if err := apply(&cfg, values); err != nil {
+			return cfg, fmt.Errorf("%s: %w", *configFile, err)
+		}
+	}
+	if err := apply(&cfg, envValues(&cfg)); err != nil {
+		return cfg, err
+	}
+
+	// flag hanya menimpa nilai jika benar-benar diberikan
+	set := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		if env, ok := flagValues[f.Name]; ok {
+			set[env] = f.Value.String()
+		}
+	})
+	if err := apply(&cfg, set); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// LoadConfig membaca konfigurasi dari file (CONFIG_FILE), .env, dan
+// environment variable tanpa validasi secret. Dipakai oleh perintah
+// yang tidak menerbitkan token, misalnya cmd/migrate.
 func LoadConfig() Config {
-	// Muat file .env
+	cfg := Default()
+	loadDotEnv()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			log.Printf("Error reading config file: %v", err)
+		} else if err := apply(&cfg, values); err != nil {
+			log.Printf("Error in config file %s: %v", path, err)
+		}
+	}
+	if err := apply(&cfg, envValues(&cfg)); err != nil {
+		log.Printf("Error in environment: %v", err)
+	}
+	return cfg
+}
+
+func loadDotEnv() {
+	// Muat file .env, nilai yang sudah ada di environment tidak ditimpa
 	if err := godotenv.Load(); err != nil {
 		// Hanya log jika tidak dalam mode test
 		if os.Getenv("GO_ENV") != "test" {
 			log.Println("No .env file found, using default values")
 		}
 	}
+	// JWT_SECRET sudah diganti namanya karena hanya dipakai untuk link email
+	if os.Getenv("JWT_SECRET") != "" && os.Getenv("EMAIL_LINK_SECRET") == "" {
+		log.Println("JWT_SECRET is no longer read, set EMAIL_LINK_SECRET instead")
+	}
+}
 
-	dbPort, err := strconv.Atoi(os.Getenv("DB_PORT"))
-	if err != nil {
-		dbPort = 10501
+// fields mengembalikan semua field Config yang memiliki tag env
+func fields(cfg *Config) map[string]reflect.Value {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	out := map[string]reflect.Value{}
+	for i := 0; i < t.NumField(); i++ {
+		if env := t.Field(i).Tag.Get("env"); env != "" {
+			out[env] = v.Field(i)
+		}
+	}
+	return out
+}
+
+func registerFlags(fs *flag.FlagSet, cfg *Config) map[string]string {
+	t := reflect.TypeOf(*cfg)
+	names := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		env := t.Field(i).Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		name := strings.ReplaceAll(strings.ToLower(env), "_", "-")
+		fs.String(name, "", t.Field(i).Tag.Get("usage")+" (env "+env+")")
+		names[name] = env
 	}
+	return names
+}
+
+func envValues(cfg *Config) map[string]string {
+	values := map[string]string{}
+	for env := range fields(cfg) {
+		if v, ok := os.LookupEnv(env); ok && v != "" {
+			values[env] = v
+		}
+	}
+	return values
+}
 
-	redisPort, err := strconv.Atoi(os.Getenv("REDIS_PORT"))
+// decodeFile membaca file YAML atau TOML (dipilih dari ekstensinya) ke v
+func decodeFile(path string, v interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, v)
+	case ".toml":
+		err = toml.Unmarshal(content, v)
+	default:
+		return fmt.Errorf("unsupported file type %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
 	if err != nil {
-		redisPort = 6379
+		return fmt.Errorf("parse %s: %w", path, err)
 	}
+	return nil
+}
 
-	return Config{
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     dbPort,
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-		DBNameTest: os.Getenv("DB_NAME_TEST"),
-		RedisHost:  os.Getenv("REDIS_HOST"),
-		RedisPort:  redisPort,
+// readConfigFile membaca file YAML/TOML dengan key datar (misalnya db_host)
+func readConfigFile(path string) (map[string]string, error) {
+	raw := map[string]interface{}{}
+	if err := decodeFile(path, &raw); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for key, value := range raw {
+		values[strings.ToUpper(key)] = fmt.Sprint(value)
+	}
+	return values, nil
+}
+
+// apply mengisi field Config dari pasangan nama env dan nilai string
+func apply(cfg *Config, values map[string]string) error {
+	fieldByEnv := fields(cfg)
+	var errs []error
+	for env, raw := range values {
+		field, ok := fieldByEnv[env]
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(raw)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case int, int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	default:
+		return fmt.Errorf("unsupported config type %s", field.Type())
 	}
+	return nil
 }
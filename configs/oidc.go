@@ -0,0 +1,97 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// OIDCProvider adalah satu identity provider OpenID Connect di
+// OIDC_PROVIDERS_FILE.
+type OIDCProvider struct {
+	// Name dipakai di URL, misalnya /api/v1/oidc/<name>/login
+	Name         string `yaml:"name" toml:"name"`
+	Issuer       string `yaml:"issuer" toml:"issuer"`
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+	// RedirectURL kosong berarti APP_BASE_URL + /api/v1/oidc/<name>/callback
+	RedirectURL string `yaml:"redirect_url" toml:"redirect_url"`
+	// Scopes kosong berarti openid, email, dan profile
+	Scopes []string `yaml:"scopes" toml:"scopes"`
+}
+
+var oidcProviderNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// OIDCProviders membaca daftar identity provider dari OIDC_PROVIDERS_FILE
+// (YAML atau TOML dengan list "providers"). Daftar kosong jika file tidak
+// diisi, artinya login OpenID Connect tidak aktif.
+func (c Config) OIDCProviders() ([]OIDCProvider, error) {
+	if c.OIDCProvidersFile == "" {
+		return nil, nil
+	}
+	var file struct {
+		Providers []OIDCProvider `yaml:"providers" toml:"providers"`
+	}
+	if err := decodeFile(c.OIDCProvidersFile, &file); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	seen := map[string]bool{}
+	for i := range file.Providers {
+		p := &file.Providers[i]
+		if !oidcProviderNamePattern.MatchString(p.Name) {
+			errs = append(errs, fmt.Errorf("provider %q: name must be 1-50 lower-case letters, digits or '-'", p.Name))
+			continue
+		}
+		if seen[p.Name] {
+			errs = append(errs, fmt.Errorf("provider %q is defined twice", p.Name))
+		}
+		seen[p.Name] = true
+		if err := checkIssuer(p.Issuer); err != nil {
+			errs = append(errs, fmt.Errorf("provider %q: %w", p.Name, err))
+		}
+		if p.ClientID == "" {
+			errs = append(errs, fmt.Errorf("provider %q: client_id is required", p.Name))
+		}
+		if p.RedirectURL == "" {
+			p.RedirectURL = strings.TrimRight(c.AppBaseURL, "/") + "/api/v1/oidc/" + p.Name + "/callback"
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"openid", "email", "profile"}
+		} else if !contains(p.Scopes, "openid") {
+			p.Scopes = append([]string{"openid"}, p.Scopes...)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return file.Providers, nil
+}
+
+// checkIssuer memastikan issuer memakai https, kecuali untuk localhost
+// (misalnya provider tiruan saat development)
+func checkIssuer(issuer string) error {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("issuer %q is not a valid URL", issuer)
+	}
+	switch host := u.Hostname(); {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && (host == "localhost" || host == "127.0.0.1" || host == "::1"):
+	default:
+		return fmt.Errorf("issuer %q must use https", issuer)
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// TaskWorkflow adalah alur status task di TASK_WORKFLOW_FILE.
+type TaskWorkflow struct {
+	// Statuses adalah semua status yang dikenal, harus berisi completed
+	// (lihat taskStatusCompleted)
+	Statuses []string `yaml:"statuses" toml:"statuses"`
+	// Initial adalah status yang boleh dipakai saat membuat task,
+	// kosong berarti hanya status pertama di Statuses
+	Initial     []string             `yaml:"initial" toml:"initial"`
+	Transitions []TaskTransitionRule `yaml:"transitions" toml:"transitions"`
+}
+
+// TaskTransitionRule mengizinkan perubahan status From ke To.
+type TaskTransitionRule struct {
+	From string `yaml:"from" toml:"from"`
+	To   string `yaml:"to" toml:"to"`
+	// Roles kosong berarti semua role boleh melakukan perubahan ini
+	Roles []string `yaml:"roles" toml:"roles"`
+}
+
+// taskStatusCompleted adalah status akhir task. Status ini wajib ada di
+// setiap workflow dan namanya tidak bisa diganti lewat konfigurasi, karena
+// tertulis langsung di SQL: completed_at di TaskRepository Create/Update,
+// filter /tasks/overdue, dan partial index tasks_overdue_idx di migrasi
+// 0012. Nilainya harus sama dengan models.TaskStatusCompleted.
+const taskStatusCompleted = "completed"
+
+var taskStatusPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// DefaultTaskWorkflow adalah workflow jika TASK_WORKFLOW_FILE tidak diisi:
+// task boleh dibuat dengan status apa saja dan dipindah ke status mana saja,
+// kecuali task completed yang hanya bisa dibuka kembali ke in_progress.
+func DefaultTaskWorkflow() TaskWorkflow {
+	return TaskWorkflow{
+		Statuses: []string{"pending", "in_progress", "completed"},
+		Initial:  []string{"pending", "in_progress", "completed"},
+		Transitions: []TaskTransitionRule{
+			{From: "pending", To: "in_progress"},
+			{From: "pending", To: "completed"},
+			{From: "in_progress", To: "pending"},
+			{From: "in_progress", To: "completed"},
+			{From: "completed", To: "in_progress"},
+		},
+	}
+}
+
+// TaskWorkflow membaca workflow status task dari TASK_WORKFLOW_FILE (YAML
+// atau TOML), atau DefaultTaskWorkflow jika file tidak diisi.
+func (c Config) TaskWorkflow() (TaskWorkflow, error) {
+	if c.TaskWorkflowFile == "" {
+		return DefaultTaskWorkflow(), nil
+	}
+	var workflow TaskWorkflow
+	if err := decodeFile(c.TaskWorkflowFile, &workflow); err != nil {
+		return TaskWorkflow{}, err
+	}
+
+	var errs []error
+	known := map[string]bool{}
+	for _, status := range workflow.Statuses {
+		if !taskStatusPattern.MatchString(status) {
+			errs = append(errs, fmt.Errorf("status %q must be 1-50 lower-case letters, digits or '_'", status))
+		}
+		if known[status] {
+			errs = append(errs, fmt.Errorf("status %q is defined twice", status))
+		}
+		known[status] = true
+	}
+	// status akhir tidak bisa dikonfigurasi, SQL dan index bergantung padanya
+	if !known[taskStatusCompleted] {
+		errs = append(errs, fmt.Errorf("statuses must include %q", taskStatusCompleted))
+	}
+
+	if len(workflow.Initial) == 0 && len(workflow.Statuses) > 0 {
+		workflow.Initial = workflow.Statuses[:1]
+	}
+	for _, status := range workflow.Initial {
+		if !known[status] {
+			errs = append(errs, fmt.Errorf("initial status %q is not in statuses", status))
+		}
+	}
+
+	seen := map[[2]string]bool{}
+	for _, t := range workflow.Transitions {
+		switch {
+		case !known[t.From] || !known[t.To]:
+			errs = append(errs, fmt.Errorf("transition %q -> %q uses a status that is not in statuses", t.From, t.To))
+		case t.From == t.To:
+			errs = append(errs, fmt.Errorf("transition %q -> %q does not change the status", t.From, t.To))
+		case seen[[2]string{t.From, t.To}]:
+			errs = append(errs, fmt.Errorf("transition %q -> %q is defined twice", t.From, t.To))
+		}
+		seen[[2]string{t.From, t.To}] = true
+	}
+	if err := errors.Join(errs...); err != nil {
+		return TaskWorkflow{}, err
+	}
+	return workflow, nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Personal access token handlers
+
+// ListAccessTokens mengembalikan personal access token milik user yang
+// sedang login. Token aslinya tidak ikut dikembalikan.
+func (h *Handler) ListAccessTokens(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	tokens, err := h.AccessTokens.List(c.Context(), userID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching access tokens", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching access tokens",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Access tokens fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    tokens,
+	})
+}
+
+// CreateAccessToken membuat personal access token dengan nama, scope, dan
+// waktu kadaluarsa opsional. Token hanya ditampilkan sekali di respons ini.
+func (h *Handler) CreateAccessToken(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	type CreateAccessTokenRequest struct {
+		Name      string     `json:"name" validate:"required,max=100"`
+		Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	var req CreateAccessTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in create access token", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+	for _, scope := range req.Scopes {
+		if !service.ValidScope(scope) {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("Unknown scope %q, valid scopes are: %s", scope, strings.Join(service.Scopes, ", ")),
+				"success": false,
+				"status":  400,
+			})
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "expires_at must be in the future",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	token, record, err := h.AccessTokens.Create(c.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error creating access token", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error creating access token",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.SecurityLogger.Info("Personal access token created", zap.Int("user_id", userID), zap.Int("token_id", record.ID), zap.Strings("scopes", record.Scopes))
+	h.Log.AuditLogger.Info("Personal access token created", zap.Int("user_id", userID), zap.Int("token_id", record.ID))
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Access token created, copy it now because it will not be shown again",
+		"success": true,
+		"status":  201,
+		"data": fiber.Map{
+			"id":           record.ID,
+			"name":         record.Name,
+			"scopes":       record.Scopes,
+			"expires_at":   record.ExpiresAt,
+			"created_at":   record.CreatedAt,
+			"last_used_at": record.LastUsedAt,
+			"token":        token,
+		},
+	})
+}
+
+// RevokeAccessToken menghapus personal access token milik user yang sedang
+// login. Token langsung tidak bisa dipakai lagi.
+func (h *Handler) RevokeAccessToken(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	tokenID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid token ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	if err := h.AccessTokens.Revoke(c.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Access token not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error revoking access token", zap.Int("user_id", userID), zap.Int("token_id", tokenID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error revoking access token",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.SecurityLogger.Info("Personal access token revoked", zap.Int("user_id", userID), zap.Int("token_id", tokenID))
+	h.Log.AuditLogger.Info("Personal access token revoked", zap.Int("user_id", userID), zap.Int("token_id", tokenID))
+	return c.JSON(fiber.Map{
+		"message": "Access token revoked",
+		"success": true,
+		"status":  200,
+	})
+}
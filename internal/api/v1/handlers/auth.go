@@ -1,20 +1,24 @@
 package handlers
 
 import (
-	"belajar-go/internal/config"
-	"belajar-go/pkg/logger"
+	"belajar-go/internal/auth"
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt"
-	"github.com/lib/pq"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Auth handlers
-func Register(c *fiber.Ctx) error {
+func (h *Handler) Register(c *fiber.Ctx) error {
 	// struct RegisterRequest menerima inputan dari user
 	type RegisterRequest struct {
 		Username string `json:"username" validate:"required,excludesall=@?"`
@@ -25,7 +29,7 @@ func Register(c *fiber.Ctx) error {
 	// variabel req digunakan untuk menerima inputan dari user
 	var req RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
-		logger.ErrorLogger.Error("Bad request in register", zap.Error(err))
+		h.Log.ErrorLogger.Error("Bad request in register", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Bad request",
 			"success": false,
@@ -34,8 +38,8 @@ func Register(c *fiber.Ctx) error {
 	}
 
 	// Validasi dengan validator
-	if err := config.Validate.Struct(req); err != nil {
-		logger.AuditLogger.Warn("Validation error during register", zap.Error(err))
+	if err := h.Validate.Struct(req); err != nil {
+		h.Log.AuditLogger.Warn("Validation error during register", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Validation error",
 			"errors":  err.Error(),
@@ -53,11 +57,11 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Hash the password using bcrypt with default cost
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// Hash password dengan algoritma dan parameter dari konfigurasi (Argon2id)
+	hashedPassword, err := h.Passwords.Hash(req.Password)
 	if err != nil {
 		// Return error response if password hashing fails
-		logger.ErrorLogger.Error("Error hashing password", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error hashing password", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error hashing password",
 			"success": false,
@@ -65,36 +69,41 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Insert data user ke dalam database
+	// Simpan data user ke dalam database
 	// Jika gagal, maka akan dikembalikan response error 500
 	// Jika username sudah ada, maka akan dikembalikan response error 409
-	var userID int
-	err = config.DB.QueryRow(
-		"INSERT INTO users (username, email, password, role) VALUES ($1, $2, $3, 'member') RETURNING id",
-		req.Username, req.Email, string(hashedPassword)).Scan(&userID) // Scan the generated ID into the userID variable
-	if err != nil {
-		// Jika error adalah unique violation error,
-		// maka kita ingin mengembalikan status code 409 dengan message
-		// yang mengindikasikan bahwa username sudah ada
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23505" {
-				logger.SecurityLogger.Warn("Duplicate username", zap.String("username", req.Username))
-				return c.Status(409).JSON(fiber.Map{
-					"message": "Username already exists",
-					"success": false,
-					"status":  409,
-				})
-			}
+	user := models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: hashedPassword,
+		Role:     "member",
+	}
+	if err := h.Users.Create(c.Context(), &user); err != nil {
+		// Jika error adalah duplicate, maka kita ingin mengembalikan
+		// status code 409 dengan message yang mengindikasikan
+		// bahwa username sudah ada
+		if errors.Is(err, repository.ErrDuplicate) {
+			h.Log.SecurityLogger.Warn("Duplicate username", zap.String("username", req.Username))
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Username already exists",
+				"success": false,
+				"status":  409,
+			})
 		}
-		logger.ErrorLogger.Error("Error creating user", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error creating user", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error creating user",
 			"success": false,
 			"status":  500,
 		})
 	}
+	userID := user.ID
+
+	// kegagalan kirim email tidak menggagalkan registrasi, user bisa
+	// meminta email verifikasi lagi
+	h.sendVerificationEmail(c, &user)
 
-	logger.AuditLogger.Info("User registered successfully", zap.Int("userID", userID))
+	h.Log.AuditLogger.Info("User registered successfully", zap.Int("userID", userID))
 	return c.JSON(fiber.Map{
 		"message": "User created successfully",
 		"status":  201,
@@ -104,19 +113,108 @@ func Register(c *fiber.Ctx) error {
 	})
 }
 
+// generateAccessToken membuat access token untuk user di sesi sessionID
+// (family refresh token). gen adalah generasi token user saat ini, untuk
+// mencabut semua token user sekaligus.
+func (h *Handler) generateAccessToken(ctx context.Context, user *models.User, sessionID string) (string, error) {
+	gen, err := h.Denylist.UserGeneration(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	token, _, err := h.Tokens.Issue(auth.Claims{
+		UserID:        user.ID,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		SessionID:     sessionID,
+		Generation:    gen,
+	})
+	return token, err
+}
+
+// rehashPassword menyimpan hash baru untuk user. Kegagalan hanya dicatat
+// karena login tetap valid dengan hash lama.
+func (h *Handler) rehashPassword(c *fiber.Ctx, userID int, plain string) {
+	hashed, err := h.Passwords.Hash(plain)
+	if err == nil {
+		_, err = h.Users.Update(c.Context(), userID, repository.UserUpdate{Password: &hashed})
+	}
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error upgrading password hash", zap.Int("user_id", userID), zap.Error(err))
+		return
+	}
+	h.Log.AuditLogger.Info("Password hash upgraded", zap.Int("user_id", userID))
+}
+
+// recordLoginFailure mencatat login yang gagal dan mengunci akun user jika
+// batas kegagalan tercapai. user bernilai nil jika username tidak ada.
+// Kegagalan mencatat hanya di-log agar respons tetap 401.
+func (h *Handler) recordLoginFailure(c *fiber.Ctx, user *models.User, username, ip string) {
+	failure, err := h.LoginGuard.RecordFailure(c.Context(), username, ip)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error recording failed login", zap.Error(err))
+		return
+	}
+	if failure.IPBlocked {
+		h.Log.SecurityLogger.Warn("IP address blocked after too many failed logins",
+			zap.String("ip", ip), zap.Int64("failures", failure.IPFailures), zap.Duration("duration", h.Config.LoginLockoutDuration))
+	}
+	if !failure.LockUser || user == nil {
+		return
+	}
+
+	lockedUntil := time.Now().Add(h.Config.LoginLockoutDuration).UTC()
+	if err := h.Users.SetLockedUntil(c.Context(), user.ID, &lockedUntil); err != nil {
+		h.Log.ErrorLogger.Error("Error locking account", zap.Int("user_id", user.ID), zap.Error(err))
+		return
+	}
+	h.Redis.Del(c.Context(), fmt.Sprintf("user:%d", user.ID))
+	h.Log.SecurityLogger.Warn("Account locked after too many failed logins",
+		zap.Int("user_id", user.ID), zap.String("username", user.Username), zap.String("ip", ip),
+		zap.Int64("failures", failure.UserFailures), zap.Time("locked_until", lockedUntil))
+}
+
+// unlockUser menghapus lockout yang sudah berakhir dari database
+func (h *Handler) unlockUser(c *fiber.Ctx, userID int) {
+	if err := h.Users.SetLockedUntil(c.Context(), userID, nil); err != nil {
+		h.Log.ErrorLogger.Error("Error clearing account lock", zap.Int("user_id", userID), zap.Error(err))
+		return
+	}
+	h.Redis.Del(c.Context(), fmt.Sprintf("user:%d", userID))
+}
+
+// retryAfterSeconds membulatkan durasi ke atas dalam detik untuk header Retry-After
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int((d + time.Second - 1) / time.Second))
+}
+
+// tooManyAttempts mengembalikan 429 dengan header Retry-After
+func tooManyAttempts(c *fiber.Ctx, wait time.Duration) error {
+	c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(wait))
+	return c.Status(429).JSON(fiber.Map{
+		"message":     "Too many failed login attempts, try again later",
+		"retry_after": int((wait + time.Second - 1) / time.Second),
+		"success":     false,
+		"status":      429,
+	})
+}
+
 // fungsi login dengan menggunakan JSON Web Token (JWT)
-func Login(c *fiber.Ctx) error {
+func (h *Handler) Login(c *fiber.Ctx) error {
 	// struct LoginRequest menerima inputan dari user
+	// device_id bersifat opsional, jika kosong maka server akan membuatkan
+	// device_name hanya ditampilkan di daftar sesi, misalnya "Laptop kantor"
 	type LoginRequest struct {
-		Username string `json:"username" validate:"required"`
-		Password string `json:"password" validate:"required"`
+		Username   string `json:"username" validate:"required"`
+		Password   string `json:"password" validate:"required"`
+		DeviceID   string `json:"device_id" validate:"max=255"`
+		DeviceName string `json:"device_name" validate:"max=100"`
 	}
 
 	// variabel req digunakan untuk menerima inputan dari user
 	var req LoginRequest
 	if err := c.BodyParser(&req); err != nil {
 		// jika inputan tidak valid, maka akan dikembalikan response error 400
-		logger.ErrorLogger.Error("Bad request in login", zap.Error(err))
+		h.Log.ErrorLogger.Error("Bad request in login", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Bad request",
 			"success": false,
@@ -124,9 +222,9 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := config.Validate.Struct(req); err != nil {
+	if err := h.Validate.Struct(req); err != nil {
 		// jika inputan tidak valid, maka akan dikembalikan response error 400
-		logger.AuditLogger.Warn("Validation error during login", zap.Error(err))
+		h.Log.AuditLogger.Warn("Validation error during login", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Validation error",
 			"errors":  err.Error(),
@@ -135,23 +233,39 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// variabel user digunakan untuk menerima data user dari database
-	var user struct {
-		ID       int
-		Username string
-		Email    string
-		Password string
-		Role     string
+	// tolak percobaan yang masih dalam masa jeda (backoff) atau IP diblokir
+	ip := c.IP()
+	wait, err := h.LoginGuard.RetryAfter(c.Context(), req.Username, ip)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error checking login attempts", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error checking login attempts",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if wait > 0 {
+		h.Log.SecurityLogger.Warn("Login attempt during backoff", zap.String("username", req.Username), zap.String("ip", ip), zap.Duration("retry_after", wait))
+		return tooManyAttempts(c, wait)
 	}
 
-	// query select digunakan untuk mengambil data user dari database
+	// ambil data user dari database
 	// berdasarkan username yang dikirimkan oleh user
-	err := config.DB.QueryRow(
-		"SELECT id, username, email, password, role FROM users WHERE username = $1",
-		req.Username).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role)
+	user, err := h.Users.GetByUsername(c.Context(), req.Username)
 	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error fetching user",
+				"success": false,
+				"status":  500,
+			})
+		}
+		// username yang tidak ada tetap dihitung agar tidak bisa dipakai
+		// untuk menebak username mana yang terdaftar
+		h.Log.SecurityLogger.Warn("User not found", zap.String("username", req.Username), zap.String("ip", ip))
+		h.recordLoginFailure(c, nil, req.Username, ip)
 		// error 401, jika data user tidak ditemukan
-		logger.SecurityLogger.Warn("User not found", zap.String("username", req.Username))
 		return c.Status(401).JSON(fiber.Map{
 			"message": "Invalid credentials",
 			"success": false,
@@ -159,11 +273,28 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
+	// akun yang sedang dikunci tidak bisa login sampai lockout berakhir
+	// atau dibuka oleh admin
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		h.Log.SecurityLogger.Warn("Login attempt on locked account", zap.Int("user_id", user.ID), zap.String("ip", ip), zap.Time("locked_until", *user.LockedUntil))
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(time.Until(*user.LockedUntil)))
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"message": "Account is locked due to too many failed login attempts",
+			"success": false,
+			"status":  fiber.StatusLocked,
+		})
+	}
+
 	// invalid password
 	// user.Password -> password yang ada di database
 	// req.Password -> password yang dikirimkan oleh user
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		logger.SecurityLogger.Warn("Invalid password", zap.String("password", req.Password))
+	ok, err := h.Passwords.Verify(req.Password, user.Password)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error verifying password", zap.Int("user_id", user.ID), zap.Error(err))
+	}
+	if !ok {
+		h.Log.SecurityLogger.Warn("Invalid password", zap.String("username", req.Username), zap.String("ip", ip))
+		h.recordLoginFailure(c, user, req.Username, ip)
 		return c.Status(401).JSON(fiber.Map{
 			"message": "Invalid credentials",
 			"success": false,
@@ -171,19 +302,90 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// membuat token JWT dengan menggunakan secret key
-	// token JWT ini akan berisi user_id, role, dan exp (expired time)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * 1).Unix(),
-	})
+	if user.LockedUntil != nil {
+		h.unlockUser(c, user.ID)
+	}
+
+	// hash lama (bcrypt atau parameter Argon2id lama) dibuat ulang dengan
+	// algoritma saat ini, selagi password asli tersedia
+	if h.Passwords.NeedsRehash(user.Password) {
+		h.rehashPassword(c, user.ID, req.Password)
+	}
+
+	return h.continueLogin(c, user, service.Device{ID: req.DeviceID, Name: req.DeviceName})
+}
 
-	// token JWT di encode menjadi string
-	tokenString, err := token.SignedString(config.SecretKey)
+// continueLogin melanjutkan login user yang identitasnya sudah terbukti
+// (lewat password atau identity provider): user dengan TOTP aktif mendapat
+// challenge token, user lain langsung mendapat token.
+func (h *Handler) continueLogin(c *fiber.Ctx, user *models.User, device service.Device) error {
+	// user dengan TOTP aktif mendapat challenge token, access token baru
+	// diberikan setelah kode TOTP ditukar di POST /login/mfa
+	mfaEnabled, err := h.MFA.Enabled(c.Context(), user.ID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error checking two-factor status", zap.Int("user_id", user.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error checking two-factor status",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if mfaEnabled {
+		mfaToken, err := h.MFAChallenges.Issue(c.Context(), user.ID, device)
+		if err != nil {
+			h.Log.ErrorLogger.Error("Error creating mfa challenge", zap.Int("user_id", user.ID), zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error creating mfa challenge",
+				"success": false,
+				"status":  500,
+			})
+		}
+		h.Log.AuditLogger.Info("First factor accepted, waiting for two-factor code", zap.Int("user_id", user.ID))
+		return c.JSON(fiber.Map{
+			"message": "Two-factor authentication required",
+			"success": true,
+			"status":  200,
+			"data": fiber.Map{
+				"mfa_required": true,
+				"mfa_token":    mfaToken,
+				"expires_in":   int(h.MFAChallenges.TTL().Seconds()),
+			},
+		})
+	}
+
+	return h.completeLogin(c, user, device, nil)
+}
+
+// completeLogin membuat sesi baru dan menerbitkan access token dan refresh
+// token setelah user lolos semua tahap autentikasi. extra ditambahkan ke
+// data respons.
+func (h *Handler) completeLogin(c *fiber.Ctx, user *models.User, device service.Device, extra fiber.Map) error {
+	// login berhasil, hitungan gagal untuk username ini dimulai dari nol
+	if err := h.LoginGuard.RecordSuccess(c.Context(), user.Username); err != nil {
+		h.Log.ErrorLogger.Error("Error resetting login attempts", zap.Error(err))
+	}
+
+	// refresh token terikat ke device, sehingga setiap login
+	// di device berbeda memiliki family token sendiri
+	if device.ID == "" {
+		device.ID = uuid.NewString()
+	}
+	refreshToken, session, err := h.RefreshTokens.Issue(c.Context(), user.ID, device, c.IP(), c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error generating refresh token", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error generating refresh token",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	// access token membawa ID family refresh token sebagai ID sesi
+	tokenString, err := h.generateAccessToken(c.Context(), user, session.FamilyID)
 	if err != nil {
 		// error 500, jika terjadi error saat mengencode token
-		logger.ErrorLogger.Error("Error generating token", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error generating token", zap.Error(err))
+		_ = h.RefreshTokens.RevokeFamily(c.Context(), user.ID, session.FamilyID)
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error generating token",
 			"success": false,
@@ -191,16 +393,25 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
+	data := fiber.Map{
+		"user_id":       user.ID,
+		"role":          user.Role,
+		"token":         tokenString,
+		"expires_in":    int(h.Tokens.TTL().Seconds()),
+		"refresh_token": refreshToken,
+		"device_id":     device.ID,
+		"session_id":    session.FamilyID,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
 	// kembalikan response success
-	logger.AuditLogger.Info("Login success", zap.Int("user_id", user.ID), zap.String("role", user.Role))
+	h.Log.AuditLogger.Info("Login success", zap.Int("user_id", user.ID), zap.String("role", user.Role), zap.String("device_id", device.ID), zap.String("session_id", session.FamilyID))
 	return c.JSON(fiber.Map{
 		"message": "Login success",
 		"success": true,
 		"status":  200,
-		"data": fiber.Map{
-			"user_id": user.ID,
-			"role":    user.Role,
-			"token":   tokenString,
-		},
+		"data":    data,
 	})
 }
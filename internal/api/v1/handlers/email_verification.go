@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"belajar-go/pkg/mail"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Email verification handlers
+
+// sendVerificationEmail mengirim link verifikasi ke email user. Kegagalan
+// hanya dicatat, user bisa meminta email baru lewat ResendVerificationEmail.
+func (h *Handler) sendVerificationEmail(c *fiber.Ctx, user *models.User) {
+	endpoint := strings.TrimRight(h.Config.AppBaseURL, "/") + "/api/v1/email/verify"
+	link, expiresAt := h.EmailVerifier.Link(endpoint, user.ID, user.Email)
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Please confirm that this is your email address by opening the link below:
+
+%s
+
+The link expires at %s. If you did not create an account, you can ignore
+this email.
+`, user.Username, link, expiresAt.UTC().Format(time.RFC1123)),
+	}
+	if err := h.Mailer.Send(c.Context(), msg); err != nil {
+		h.Log.ErrorLogger.Error("Error sending verification email", zap.Int("user_id", user.ID), zap.Error(err))
+		return
+	}
+	h.Log.AuditLogger.Info("Verification email sent", zap.Int("user_id", user.ID))
+}
+
+// VerifyEmail memeriksa link verifikasi dari email (parameter uid, exp, dan
+// sig) lalu menandai email user sebagai terverifikasi.
+func (h *Handler) VerifyEmail(c *fiber.Ctx) error {
+	invalid := func() error {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid verification link",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	userID, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		return invalid()
+	}
+	expires, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil {
+		return invalid()
+	}
+
+	user, err := h.Users.GetByID(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return invalid()
+		}
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching user",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	if err := h.EmailVerifier.Verify(user.ID, user.Email, expires, c.Query("sig")); err != nil {
+		if errors.Is(err, service.ErrVerificationExpired) {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Verification link has expired, request a new one",
+				"success": false,
+				"status":  400,
+			})
+		}
+		h.Log.SecurityLogger.Warn("Invalid email verification link", zap.Int("user_id", userID), zap.String("ip", c.IP()))
+		return invalid()
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return c.JSON(fiber.Map{
+			"message": "Email already verified",
+			"success": true,
+			"status":  200,
+		})
+	}
+
+	if err := h.Users.MarkEmailVerified(c.Context(), user.ID, user.Email, time.Now()); err != nil {
+		// ErrNotFound berarti email berubah di antara GetByID dan update
+		if errors.Is(err, repository.ErrNotFound) {
+			return invalid()
+		}
+		h.Log.ErrorLogger.Error("Error verifying email", zap.Int("user_id", user.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error verifying email",
+			"success": false,
+			"status":  500,
+		})
+	}
+	h.Redis.Del(c.Context(), fmt.Sprintf("user:%d", user.ID))
+
+	h.Log.AuditLogger.Info("Email verified", zap.Int("user_id", user.ID))
+	return c.JSON(fiber.Map{
+		"message": "Email verified successfully",
+		"success": true,
+		"status":  200,
+	})
+}
+
+// ResendVerificationEmail mengirim ulang link verifikasi. Batas pengiriman
+// berlaku per alamat email, termasuk alamat yang tidak terdaftar, dan
+// respons untuk email terdaftar maupun tidak selalu sama.
+func (h *Handler) ResendVerificationEmail(c *fiber.Ctx) error {
+	type ResendRequest struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	var req ResendRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in resend verification", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	allowed, wait, err := h.VerificationResends.Allow(c.Context(), strings.ToLower(req.Email))
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error checking resend rate limit", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error sending verification email",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if !allowed {
+		h.Log.SecurityLogger.Warn("Verification email resend rate limited", zap.String("ip", c.IP()))
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(wait))
+		return c.Status(429).JSON(fiber.Map{
+			"message": "Too many verification emails requested, try again later",
+			"success": false,
+			"status":  429,
+		})
+	}
+
+	accepted := fiber.Map{
+		"message": "If the email is registered and not yet verified, a verification link has been sent",
+		"success": true,
+		"status":  200,
+	}
+
+	user, err := h.Users.GetByEmail(c.Context(), req.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.JSON(accepted)
+		}
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching user",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if user.EmailVerifiedAt == nil {
+		h.sendVerificationEmail(c, user)
+	}
+	return c.JSON(accepted)
+}
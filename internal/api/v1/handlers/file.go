@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"belajar-go/internal/config"
-	"belajar-go/pkg/logger"
 	"fmt"
 	"mime/multipart"
 	"os"
@@ -17,10 +15,10 @@ import (
 
 // File Handling
 // Fungsi untuk validasi file
-func validateFile(file *multipart.FileHeader) error {
-	// Validasi ukuran file maksimal 5MB
-	if file.Size > 5<<20 {
-		return fiber.NewError(fiber.StatusBadRequest, "File size exceeds the limit of 5MB")
+func validateFile(file *multipart.FileHeader, maxSize int64) error {
+	// Validasi ukuran file maksimal sesuai UPLOAD_MAX_SIZE
+	if file.Size > maxSize {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("File size exceeds the limit of %s", formatSize(maxSize)))
 	}
 
 	// Validasi ekstensi file
@@ -39,22 +37,30 @@ func validateFile(file *multipart.FileHeader) error {
 	return nil
 }
 
+// formatSize menampilkan ukuran dalam MB jika habis dibagi, selain itu dalam byte
+func formatSize(size int64) string {
+	if size%(1<<20) == 0 {
+		return fmt.Sprintf("%dMB", size>>20)
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
+
 // Fungsi untuk mendapatkan file
-func GetFile(c *fiber.Ctx) error {
+func (h *Handler) GetFile(c *fiber.Ctx) error {
 	filename := c.Params("filename")
-	filePath := path.Join("uploads", filename)
+	filePath := path.Join(h.Config.UploadDir, filename)
 	return c.SendFile(filePath)
 }
 
 // Fungsi untuk mengunggah file
-func UploadFile(c *fiber.Ctx) error {
+func (h *Handler) UploadFile(c *fiber.Ctx) error {
 	// Pastikan folder uploads sudah ada
-	uploadDir := "uploads"
+	uploadDir := h.Config.UploadDir
 	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
 		// Buat folder jika belum ada
-		if err := os.Mkdir(uploadDir, os.ModePerm); err != nil {
+		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
 			// kembalikan error 500 jika terjadi kesalahan saat membuat folder
-			logger.ErrorLogger.Error("Error creating upload directory", zap.Error(err))
+			h.Log.ErrorLogger.Error("Error creating upload directory", zap.Error(err))
 			return c.Status(500).JSON(fiber.Map{
 				"message": "Error creating upload directory",
 				"success": false,
@@ -67,7 +73,7 @@ func UploadFile(c *fiber.Ctx) error {
 	file, err := c.FormFile("file")
 	if err != nil {
 		// kembalikan error 400 jika terjadi kesalahan saat mengunggah file
-		logger.ErrorLogger.Error("Error uploading file", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error uploading file", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Error uploading file",
 			"success": false,
@@ -76,9 +82,9 @@ func UploadFile(c *fiber.Ctx) error {
 	}
 
 	// Validasi file
-	if err := validateFile(file); err != nil {
+	if err := validateFile(file, h.Config.UploadMaxSize); err != nil {
 		// kembalikan error 400 jika terjadi kesalahan saat validasi file
-		logger.ErrorLogger.Error("Error validating file", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error validating file", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": err.Error(),
 			"success": false,
@@ -93,7 +99,7 @@ func UploadFile(c *fiber.Ctx) error {
 	filePath := path.Join(uploadDir, newFilename)
 	if err := c.SaveFile(file, filePath); err != nil {
 		// kembalikan error 500 jika terjadi kesalahan saat menyimpan file
-		logger.ErrorLogger.Error("Error saving file", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error saving file", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error saving file",
 			"success": false,
@@ -102,7 +108,7 @@ func UploadFile(c *fiber.Ctx) error {
 	}
 
 	// kembalikan respons sukses
-	logger.AuditLogger.Info("File uploaded", zap.String("filename", newFilename))
+	h.Log.AuditLogger.Info("File uploaded", zap.String("filename", newFilename))
 	return c.JSON(fiber.Map{
 		"message": "File uploaded successfully",
 		"success": true,
@@ -115,13 +121,13 @@ func UploadFile(c *fiber.Ctx) error {
 }
 
 // Profile Picture Handling
-func UploadProfilePicture(c *fiber.Ctx) error {
+func (h *Handler) UploadProfilePicture(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(int)
 
-	uploadDir := "uploads"
+	uploadDir := h.Config.UploadDir
 	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		if err := os.Mkdir(uploadDir, os.ModePerm); err != nil {
-			logger.ErrorLogger.Error("Error creating upload directory", zap.Error(err))
+		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+			h.Log.ErrorLogger.Error("Error creating upload directory", zap.Error(err))
 			return c.Status(500).JSON(fiber.Map{
 				"message": "Error creating upload directory",
 				"success": false,
@@ -132,7 +138,7 @@ func UploadProfilePicture(c *fiber.Ctx) error {
 
 	file, err := c.FormFile("profile_picture")
 	if err != nil {
-		logger.ErrorLogger.Error("Error uploading file", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error uploading file", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Error uploading file",
 			"success": false,
@@ -140,8 +146,8 @@ func UploadProfilePicture(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := validateFile(file); err != nil {
-		logger.ErrorLogger.Error("Error validating file", zap.Error(err))
+	if err := validateFile(file, h.Config.UploadMaxSize); err != nil {
+		h.Log.ErrorLogger.Error("Error validating file", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": err.Error(),
 			"success": false,
@@ -153,7 +159,7 @@ func UploadProfilePicture(c *fiber.Ctx) error {
 	filePath := path.Join(uploadDir, newFilename)
 
 	if err := c.SaveFile(file, filePath); err != nil {
-		logger.ErrorLogger.Error("Error saving file", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error saving file", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error saving file",
 			"success": false,
@@ -163,9 +169,9 @@ func UploadProfilePicture(c *fiber.Ctx) error {
 
 	fileURL := fmt.Sprintf("/uploads/%s", newFilename)
 
-	_, err = config.DB.Exec("UPDATE users SET profile_picture = $1 WHERE id = $2", fileURL, userID)
+	err = h.Users.UpdateProfilePicture(c.Context(), userID, fileURL)
 	if err != nil {
-		logger.ErrorLogger.Error("Error updating profile picture", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error updating profile picture", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error updating profile picture",
 			"success": false,
@@ -173,7 +179,7 @@ func UploadProfilePicture(c *fiber.Ctx) error {
 		})
 	}
 
-	logger.AuditLogger.Info("Profile picture uploaded", zap.String("filename", newFilename))
+	h.Log.AuditLogger.Info("Profile picture uploaded", zap.String("filename", newFilename))
 	return c.JSON(fiber.Map{
 		"message": "Profile picture uploaded successfully",
 		"success": true,
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"belajar-go/internal/config"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler menyimpan dependency yang dipakai semua handler API v1.
+// Semua handler adalah method dari Handler, sehingga tidak ada
+// state global yang dipakai bersama antar instance aplikasi.
+type Handler struct {
+	*config.App
+}
+
+// New membuat Handler dari App.
+func New(a *config.App) *Handler {
+	return &Handler{App: a}
+}
+
+// errorResponse mengirim error dari helper handler sebagai respons JSON.
+// Helper mengembalikan *fiber.Error dengan status dan pesan untuk client,
+// error lain dikirim sebagai 500.
+func errorResponse(c *fiber.Ctx, err error) error {
+	fiberErr := fiber.ErrInternalServerError
+	errors.As(err, &fiberErr)
+	return c.Status(fiberErr.Code).JSON(fiber.Map{
+		"message": fiberErr.Message,
+		"success": false,
+		"status":  fiberErr.Code,
+	})
+}
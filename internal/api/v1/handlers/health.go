@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Health handlers
+
+// Healthz adalah liveness probe: selama proses bisa melayani request
+// maka dianggap hidup, tanpa mengecek dependency.
+func (h *Handler) Healthz(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": "ok",
+	})
+}
+
+// Readyz adalah readiness probe: mengecek Postgres dan Redis, dan
+// mengembalikan 503 jika salah satu gagal atau server sedang berhenti.
+func (h *Handler) Readyz(c *fiber.Ctx) error {
+	report := h.Health.Check(c.Context())
+
+	for name, dep := range report.Dependencies {
+		if dep.Err() != nil {
+			h.Log.ErrorLogger.Error("Readiness check failed", zap.String("dependency", name), zap.Error(dep.Err()))
+		}
+	}
+
+	status, code := "ready", fiber.StatusOK
+	switch {
+	case report.Draining:
+		status, code = "draining", fiber.StatusServiceUnavailable
+	case !report.Ready:
+		status, code = "not_ready", fiber.StatusServiceUnavailable
+	}
+	return c.Status(code).JSON(fiber.Map{
+		"status":       status,
+		"dependencies": report.Dependencies,
+	})
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKS handlers
+
+// JWKS mengembalikan public key untuk memverifikasi access token dalam
+// format JSON Web Key Set. Key lama yang masih ada di JWT_KEY_FILES ikut
+// ditampilkan agar token yang terbit sebelum rotasi tetap bisa diverifikasi.
+func (h *Handler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.Tokens.JWKS())
+}
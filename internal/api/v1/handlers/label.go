@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"belajar-go/internal/middleware"
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Label handlers
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+const (
+	// defaultLabelColor dipakai jika color tidak diisi saat membuat label
+	defaultLabelColor  = "#6b7280"
+	maxLabelNameLength = 50
+	// maxLabelFilter membatasi jumlah label di filter label= ListTasks
+	maxLabelFilter = 20
+)
+
+// labelRequest adalah isi request create dan update label. Field nil tidak
+// diubah saat update.
+type labelRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+	// Shared membuat label bersama, hanya dipakai saat create
+	Shared bool `json:"shared"`
+}
+
+// normalize merapikan name dan color lalu memvalidasinya
+func (r *labelRequest) normalize() error {
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxLabelNameLength {
+			return fmt.Errorf("name must be 1-%d characters", maxLabelNameLength)
+		}
+		r.Name = &name
+	}
+	if r.Color != nil {
+		if !labelColorPattern.MatchString(*r.Color) {
+			return errors.New("color must be a hex color like #1f77b4")
+		}
+		color := strings.ToLower(*r.Color)
+		r.Color = &color
+	}
+	return nil
+}
+
+// duplicateLabel mengirim respons untuk nama label yang sudah dipakai
+func duplicateLabel(c *fiber.Ctx) error {
+	return c.Status(409).JSON(fiber.Map{
+		"message": "A label with this name already exists",
+		"success": false,
+		"status":  409,
+	})
+}
+
+// editableLabel mengambil label dari parameter :id yang boleh diubah user:
+// label miliknya sendiri, atau label bersama jika user punya labels:manage.
+// Label pribadi user lain dianggap tidak ada.
+func (h *Handler) editableLabel(c *fiber.Ctx) (*models.Label, error) {
+	userID := c.Locals("userID").(int)
+
+	labelID, err := c.ParamsInt("id")
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid label ID")
+	}
+	label, err := h.Labels.GetByID(c.Context(), labelID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		h.Log.ErrorLogger.Error("Error fetching label", zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Error fetching label")
+	}
+	if err != nil || !label.UsableOn(userID) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Label not found")
+	}
+	if label.Shared() && !middleware.HasPermission(c, models.PermLabelsManage) {
+		h.Log.SecurityLogger.Warn("You don't have permission to manage shared labels", zap.Int("user_id", userID), zap.Int("label_id", labelID))
+		return nil, fiber.NewError(fiber.StatusForbidden, "You don't have permission to manage shared labels")
+	}
+	return label, nil
+}
+
+// fillTaskLabels mengisi Labels setiap task. Task tanpa label mendapat
+// slice kosong agar respons selalu berisi array.
+func (h *Handler) fillTaskLabels(ctx context.Context, tasks []models.Task) error {
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	labels, err := h.Labels.ForTasks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].Labels = labels[tasks[i].ID]
+		if tasks[i].Labels == nil {
+			tasks[i].Labels = []models.Label{}
+		}
+	}
+	return nil
+}
+
+// ListLabels menampilkan label milik user dan semua label bersama
+func (h *Handler) ListLabels(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	labels, err := h.Labels.ListForUser(c.Context(), userID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching labels", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching labels",
+			"success": false,
+			"status":  500,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "Labels fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    labels,
+	})
+}
+
+// CreateLabel membuat label pribadi, atau label bersama ("shared": true)
+// untuk user dengan labels:manage
+func (h *Handler) CreateLabel(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	var req labelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := req.normalize(); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+	if req.Name == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  "name is required",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	label := models.Label{Name: *req.Name, Color: defaultLabelColor}
+	if req.Color != nil {
+		label.Color = *req.Color
+	}
+	if req.Shared {
+		if !middleware.HasPermission(c, models.PermLabelsManage) {
+			h.Log.SecurityLogger.Warn("You don't have permission to create shared labels", zap.Int("user_id", userID))
+			return c.Status(403).JSON(fiber.Map{
+				"message": "You don't have permission to manage shared labels",
+				"success": false,
+				"status":  403,
+			})
+		}
+	} else {
+		label.UserID = &userID
+	}
+
+	if err := h.Labels.Create(c.Context(), &label); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return duplicateLabel(c)
+		}
+		h.Log.ErrorLogger.Error("Error creating label", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error creating label",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Label created", zap.Int("label_id", label.ID), zap.Int("user_id", userID), zap.Bool("shared", label.Shared()))
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Label created successfully",
+		"success": true,
+		"status":  201,
+		"data":    label,
+	})
+}
+
+// UpdateLabel mengubah nama dan/atau warna label
+func (h *Handler) UpdateLabel(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	label, err := h.editableLabel(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	var req labelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := req.normalize(); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	updated, err := h.Labels.Update(c.Context(), label.ID, repository.LabelUpdate{Name: req.Name, Color: req.Color})
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return duplicateLabel(c)
+		}
+		h.Log.ErrorLogger.Error("Error updating label", zap.Int("label_id", label.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error updating label",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Label updated", zap.Int("label_id", label.ID), zap.Int("user_id", userID))
+	return c.JSON(fiber.Map{
+		"message": "Label updated successfully",
+		"success": true,
+		"status":  200,
+		"data":    updated,
+	})
+}
+
+// DeleteLabel menghapus label dan melepasnya dari semua task
+func (h *Handler) DeleteLabel(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	label, err := h.editableLabel(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	if err := h.Labels.Delete(c.Context(), label.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		h.Log.ErrorLogger.Error("Error deleting label", zap.Int("label_id", label.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error deleting label",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Label deleted", zap.Int("label_id", label.ID), zap.Int("user_id", userID))
+	return c.JSON(fiber.Map{
+		"message": "Label deleted successfully",
+		"success": true,
+		"status":  200,
+	})
+}
+
+// taskLabelsResponse mengirim label task setelah dipasang atau dilepas
+func (h *Handler) taskLabelsResponse(c *fiber.Ctx, task *models.Task, message string) error {
+	tasks := []models.Task{*task}
+	if err := h.fillTaskLabels(c.Context(), tasks); err != nil {
+		h.Log.ErrorLogger.Error("Error fetching labels", zap.Int("task_id", task.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching labels",
+			"success": false,
+			"status":  500,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": message,
+		"success": true,
+		"status":  200,
+		"data":    tasks[0].Labels,
+	})
+}
+
+// AttachTaskLabel memasang label ke task. Label harus terlihat oleh user,
+// dan label pribadi hanya bisa dipasang di task milik pemilik label.
+func (h *Handler) AttachTaskLabel(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	task, err := h.accessibleTask(c, models.PermTasksUpdateAny)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	labelID, err := c.ParamsInt("label_id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid label ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	label, err := h.Labels.GetByID(c.Context(), labelID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		h.Log.ErrorLogger.Error("Error fetching label", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching label",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if err != nil || !label.UsableOn(userID) {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Label not found",
+			"success": false,
+			"status":  404,
+		})
+	}
+	if !label.UsableOn(task.UserID) {
+		return c.Status(422).JSON(fiber.Map{
+			"message": "Personal labels can only be attached to tasks of the label owner",
+			"success": false,
+			"status":  422,
+		})
+	}
+
+	if err := h.Labels.Attach(c.Context(), task.ID, label.ID); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			// task atau label dihapus request lain
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Task or label not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error attaching label", zap.Int("task_id", task.ID), zap.Int("label_id", label.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error attaching label",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Label attached", zap.Int("task_id", task.ID), zap.Int("label_id", label.ID), zap.Int("user_id", userID))
+	return h.taskLabelsResponse(c, task, "Label attached successfully")
+}
+
+// DetachTaskLabel melepas label dari task
+func (h *Handler) DetachTaskLabel(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	task, err := h.accessibleTask(c, models.PermTasksUpdateAny)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	labelID, err := c.ParamsInt("label_id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid label ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	if err := h.Labels.Detach(c.Context(), task.ID, labelID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Label is not attached to this task",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error detaching label", zap.Int("task_id", task.ID), zap.Int("label_id", labelID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error detaching label",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Label detached", zap.Int("task_id", task.ID), zap.Int("label_id", labelID), zap.Int("user_id", userID))
+	return h.taskLabelsResponse(c, task, "Label detached successfully")
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"belajar-go/internal/service"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Two-factor authentication (TOTP) handlers
+
+// mfaCodeRequest adalah kode TOTP atau recovery code, salah satu wajib diisi
+type mfaCodeRequest struct {
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,max=32"`
+}
+
+// invalidMFACode mengembalikan respons untuk kode TOTP atau recovery code yang salah
+func invalidMFACode(c *fiber.Ctx, status int) error {
+	return c.Status(status).JSON(fiber.Map{
+		"message": "Invalid two-factor code",
+		"success": false,
+		"status":  status,
+	})
+}
+
+// LoginMFA menukar challenge token dari Login dan kode TOTP (atau recovery
+// code) dengan access token dan refresh token.
+func (h *Handler) LoginMFA(c *fiber.Ctx) error {
+	type LoginMFARequest struct {
+		MFAToken string `json:"mfa_token" validate:"required"`
+		mfaCodeRequest
+	}
+
+	var req LoginMFARequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in mfa login", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	invalidToken := func() error {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Invalid or expired mfa token, please log in again",
+			"success": false,
+			"status":  401,
+		})
+	}
+
+	// setiap request dengan challenge ini dihitung sebagai satu percobaan
+	challenge, err := h.MFAChallenges.Attempt(c.Context(), req.MFAToken)
+	if err != nil {
+		if errors.Is(err, service.ErrMFAChallengeInvalid) {
+			h.Log.SecurityLogger.Warn("Invalid mfa token", zap.String("ip", c.IP()))
+			return invalidToken()
+		}
+		h.Log.ErrorLogger.Error("Error fetching mfa challenge", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error verifying two-factor code",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	user, err := h.Users.GetByID(c.Context(), challenge.UserID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Int("user_id", challenge.UserID), zap.Error(err))
+		h.MFAChallenges.Delete(c.Context(), req.MFAToken)
+		return invalidToken()
+	}
+
+	// kode yang salah dihitung bersama password yang salah, sehingga
+	// backoff dan lockout juga berlaku untuk menebak kode TOTP
+	ip := c.IP()
+
+	// lockout menghapus hitungan LoginGuard user, jadi akun yang dikunci
+	// harus dicek sendiri agar challenge lama tidak bisa terus menebak kode
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		h.Log.SecurityLogger.Warn("Two-factor attempt on locked account", zap.Int("user_id", user.ID), zap.String("ip", ip), zap.Time("locked_until", *user.LockedUntil))
+		h.MFAChallenges.Delete(c.Context(), req.MFAToken)
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(time.Until(*user.LockedUntil)))
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"message": "Account is locked due to too many failed login attempts",
+			"success": false,
+			"status":  fiber.StatusLocked,
+		})
+	}
+	wait, err := h.LoginGuard.RetryAfter(c.Context(), user.Username, ip)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error checking login attempts", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error checking login attempts",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+
+	remaining, err := h.MFA.Verify(c.Context(), user.ID, req.Code, req.RecoveryCode)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMFAInvalidCode):
+			h.Log.SecurityLogger.Warn("Invalid two-factor code", zap.Int("user_id", user.ID), zap.String("ip", ip))
+			h.recordLoginFailure(c, user, user.Username, ip)
+			return invalidMFACode(c, 401)
+		case errors.Is(err, service.ErrMFANotEnrolled):
+			// TOTP dinonaktifkan setelah challenge dibuat
+			h.MFAChallenges.Delete(c.Context(), req.MFAToken)
+			return invalidToken()
+		}
+		h.Log.ErrorLogger.Error("Error verifying two-factor code", zap.Int("user_id", user.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error verifying two-factor code",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	// challenge hanya bisa ditukar sekali, request paralel yang kalah
+	// ditolak walaupun kodenya benar
+	if err := h.MFAChallenges.Consume(c.Context(), req.MFAToken); err != nil {
+		if errors.Is(err, service.ErrMFAChallengeInvalid) {
+			h.Log.SecurityLogger.Warn("Mfa token already used", zap.Int("user_id", user.ID), zap.String("ip", ip))
+			return invalidToken()
+		}
+		h.Log.ErrorLogger.Error("Error deleting mfa challenge", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error verifying two-factor code",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	var extra fiber.Map
+	if remaining >= 0 {
+		h.Log.SecurityLogger.Warn("Recovery code used for login", zap.Int("user_id", user.ID), zap.Int("remaining", remaining), zap.String("ip", ip))
+		extra = fiber.Map{"recovery_codes_remaining": remaining}
+	}
+	return h.completeLogin(c, user, service.Device{ID: challenge.DeviceID, Name: challenge.DeviceName}, extra)
+}
+
+// GetMFAStatus mengembalikan status TOTP user yang sedang login dan sisa
+// recovery code.
+func (h *Handler) GetMFAStatus(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	enabled, err := h.MFA.Enabled(c.Context(), userID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error checking two-factor status", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error checking two-factor status",
+			"success": false,
+			"status":  500,
+		})
+	}
+	data := fiber.Map{"enabled": enabled}
+	if enabled {
+		remaining, err := h.MFA.RemainingRecoveryCodes(c.Context(), userID)
+		if err != nil {
+			h.Log.ErrorLogger.Error("Error counting recovery codes", zap.Int("user_id", userID), zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error checking two-factor status",
+				"success": false,
+				"status":  500,
+			})
+		}
+		data["recovery_codes_remaining"] = remaining
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Two-factor status fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    data,
+	})
+}
+
+// EnrollMFA membuat secret TOTP baru untuk user yang sedang login. Secret
+// belum aktif sampai dikonfirmasi dengan ConfirmMFA. otpauth_uri adalah
+// isi QR code untuk aplikasi authenticator.
+func (h *Handler) EnrollMFA(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	user, err := h.Users.GetByID(c.Context(), userID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching user",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	secret, uri, err := h.MFA.Enroll(c.Context(), user.ID, user.Username)
+	if err != nil {
+		if errors.Is(err, service.ErrMFAAlreadyEnabled) {
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Two-factor authentication is already enabled",
+				"success": false,
+				"status":  409,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error enrolling two-factor authentication", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error enrolling two-factor authentication",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Two-factor enrollment started", zap.Int("user_id", userID))
+	return c.JSON(fiber.Map{
+		"message": "Scan the QR code with an authenticator app, then confirm with a code",
+		"success": true,
+		"status":  200,
+		"data": fiber.Map{
+			"secret":      secret,
+			"otpauth_uri": uri,
+		},
+	})
+}
+
+// ConfirmMFA mengaktifkan TOTP dengan kode pertama dari aplikasi
+// authenticator dan mengembalikan recovery code. Recovery code hanya
+// ditampilkan sekali ini.
+func (h *Handler) ConfirmMFA(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	type ConfirmMFARequest struct {
+		Code string `json:"code" validate:"required,numeric,len=6"`
+	}
+
+	var req ConfirmMFARequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in confirm mfa", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	codes, err := h.MFA.Confirm(c.Context(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMFAInvalidCode):
+			h.Log.SecurityLogger.Warn("Invalid two-factor code during enrollment", zap.Int("user_id", userID))
+			return invalidMFACode(c, 400)
+		case errors.Is(err, service.ErrMFANotEnrolled):
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Two-factor enrollment not started",
+				"success": false,
+				"status":  404,
+			})
+		case errors.Is(err, service.ErrMFAAlreadyEnabled):
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Two-factor authentication is already enabled",
+				"success": false,
+				"status":  409,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error confirming two-factor authentication", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error confirming two-factor authentication",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.SecurityLogger.Info("Two-factor authentication enabled", zap.Int("user_id", userID), zap.String("ip", c.IP()))
+	h.Log.AuditLogger.Info("Two-factor authentication enabled", zap.Int("user_id", userID))
+	return c.JSON(fiber.Map{
+		"message": "Two-factor authentication enabled, store the recovery codes in a safe place",
+		"success": true,
+		"status":  200,
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableMFA menonaktifkan TOTP. User harus login ulang dengan password dan
+// kode TOTP (atau recovery code), sehingga access token yang dicuri saja
+// tidak cukup untuk mematikan faktor kedua.
+func (h *Handler) DisableMFA(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	type DisableMFARequest struct {
+		Password string `json:"password" validate:"required"`
+		mfaCodeRequest
+	}
+
+	var req DisableMFARequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in disable mfa", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	// GetByID tidak mengisi hash password, yang hanya diisi GetByUsername
+	user, err := h.Users.GetByID(c.Context(), userID)
+	if err == nil {
+		user, err = h.Users.GetByUsername(c.Context(), user.Username)
+	}
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching user",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	// re-autentikasi memakai batas percobaan yang sama dengan login
+	ip := c.IP()
+	wait, err := h.LoginGuard.RetryAfter(c.Context(), user.Username, ip)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error checking login attempts", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error checking login attempts",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+
+	ok, err := h.Passwords.Verify(req.Password, user.Password)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error verifying password", zap.Int("user_id", userID), zap.Error(err))
+	}
+	if !ok {
+		h.Log.SecurityLogger.Warn("Invalid password while disabling two-factor", zap.Int("user_id", userID), zap.String("ip", ip))
+		h.recordLoginFailure(c, user, user.Username, ip)
+		return c.Status(403).JSON(fiber.Map{
+			"message": "Invalid password",
+			"success": false,
+			"status":  403,
+		})
+	}
+
+	if _, err := h.MFA.Verify(c.Context(), userID, req.Code, req.RecoveryCode); err != nil {
+		switch {
+		case errors.Is(err, service.ErrMFAInvalidCode):
+			h.Log.SecurityLogger.Warn("Invalid two-factor code while disabling two-factor", zap.Int("user_id", userID), zap.String("ip", ip))
+			h.recordLoginFailure(c, user, user.Username, ip)
+			return invalidMFACode(c, 403)
+		case errors.Is(err, service.ErrMFANotEnrolled):
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Two-factor authentication is not enabled",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error verifying two-factor code", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error verifying two-factor code",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	if err := h.MFA.Disable(c.Context(), userID); err != nil && !errors.Is(err, service.ErrMFANotEnrolled) {
+		h.Log.ErrorLogger.Error("Error disabling two-factor authentication", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error disabling two-factor authentication",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.SecurityLogger.Warn("Two-factor authentication disabled", zap.Int("user_id", userID), zap.String("ip", ip))
+	h.Log.AuditLogger.Info("Two-factor authentication disabled", zap.Int("user_id", userID))
+	return c.JSON(fiber.Map{
+		"message": "Two-factor authentication disabled",
+		"success": true,
+		"status":  200,
+	})
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"belajar-go/internal/auth"
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// OpenID Connect (login lewat identity provider) handlers
+
+// maxOIDCUsernameLength membatasi panjang username yang dibuat dari claim
+// preferred_username atau email
+const maxOIDCUsernameLength = 40
+
+// ListOIDCProviders mengembalikan nama identity provider yang bisa dipakai login
+func (h *Handler) ListOIDCProviders(c *fiber.Ctx) error {
+	names := make([]string, 0, len(h.OIDCProviders))
+	for name := range h.OIDCProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return c.JSON(fiber.Map{
+		"message": "OIDC providers fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    names,
+	})
+}
+
+// oidcProvider mencari provider dari parameter :provider
+func (h *Handler) oidcProvider(c *fiber.Ctx) (*auth.OIDCProvider, error) {
+	provider, ok := h.OIDCProviders[c.Params("provider")]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Unknown identity provider")
+	}
+	return provider, nil
+}
+
+// StartOIDCLogin membuat URL login identity provider dengan state, nonce,
+// dan PKCE baru. Client membuka URL tersebut, lalu identity provider
+// mengarahkan user kembali ke callback.
+func (h *Handler) StartOIDCLogin(c *fiber.Ctx) error {
+	provider, err := h.oidcProvider(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	device := service.Device{ID: c.Query("device_id"), Name: c.Query("device_name")}
+	if len(device.ID) > 255 || len(device.Name) > 100 {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "device_id must be at most 255 and device_name at most 100 characters",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	state, login, err := h.OIDCStates.Start(c.Context(), provider.Name(), device)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error creating oidc state", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error starting login",
+			"success": false,
+			"status":  500,
+		})
+	}
+	authURL, err := provider.AuthCodeURL(c.Context(), state, login.Nonce, login.Verifier)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching oidc discovery document", zap.String("provider", provider.Name()), zap.Error(err))
+		return c.Status(502).JSON(fiber.Map{
+			"message": "Identity provider is unavailable",
+			"success": false,
+			"status":  502,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Open authorization_url to continue login",
+		"success": true,
+		"status":  200,
+		"data": fiber.Map{
+			"authorization_url": authURL,
+			"expires_in":        int(h.OIDCStates.TTL().Seconds()),
+		},
+	})
+}
+
+// OIDCCallback menukar kode otorisasi dari identity provider, mencari atau
+// membuat user untuk identity tersebut, lalu melanjutkan login seperti
+// login dengan password (termasuk TOTP jika aktif).
+func (h *Handler) OIDCCallback(c *fiber.Ctx) error {
+	provider, err := h.oidcProvider(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	ip := c.IP()
+
+	if idpError := c.Query("error"); idpError != "" {
+		h.Log.SecurityLogger.Warn("Identity provider returned an error", zap.String("provider", provider.Name()), zap.String("error", idpError), zap.String("ip", ip))
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Login was cancelled or denied by the identity provider",
+			"success": false,
+			"status":  401,
+		})
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "code and state are required",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	// state hanya bisa dipakai sekali dan harus dibuat untuk provider ini
+	login, err := h.OIDCStates.Consume(c.Context(), state)
+	if err != nil && !errors.Is(err, service.ErrOIDCStateInvalid) {
+		h.Log.ErrorLogger.Error("Error fetching oidc state", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error completing login",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if err != nil || login.Provider != provider.Name() {
+		h.Log.SecurityLogger.Warn("Invalid oidc state", zap.String("provider", provider.Name()), zap.String("ip", ip))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid or expired login state, please start again",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	idToken, err := provider.Exchange(c.Context(), code, login.Verifier, login.Nonce)
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCRejected) || errors.Is(err, auth.ErrIDTokenInvalid) {
+			h.Log.SecurityLogger.Warn("OIDC login rejected", zap.String("provider", provider.Name()), zap.String("ip", ip), zap.Error(err))
+			return c.Status(401).JSON(fiber.Map{
+				"message": "Login with identity provider failed",
+				"success": false,
+				"status":  401,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error exchanging oidc code", zap.String("provider", provider.Name()), zap.Error(err))
+		return c.Status(502).JSON(fiber.Map{
+			"message": "Identity provider is unavailable",
+			"success": false,
+			"status":  502,
+		})
+	}
+
+	user, err := h.oidcUser(c, provider.Name(), idToken)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	return h.continueLogin(c, user, service.Device{ID: login.DeviceID, Name: login.DeviceName})
+}
+
+// oidcUser mencari user yang tertaut ke identity, menautkan user lama
+// dengan email yang sama, atau membuat user baru
+func (h *Handler) oidcUser(c *fiber.Ctx, provider string, idToken *auth.IDToken) (*models.User, error) {
+	now := time.Now()
+	identity, err := h.UserIdentities.Get(c.Context(), provider, idToken.Subject)
+	if err == nil {
+		if err := h.UserIdentities.TouchLastLogin(c.Context(), identity.ID, now); err != nil {
+			h.Log.ErrorLogger.Error("Error updating identity last login", zap.Int("identity_id", identity.ID), zap.Error(err))
+		}
+		user, err := h.Users.GetByID(c.Context(), identity.UserID)
+		if err != nil {
+			h.Log.ErrorLogger.Error("Error fetching user", zap.Int("user_id", identity.UserID), zap.Error(err))
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "Error fetching user")
+		}
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		h.Log.ErrorLogger.Error("Error fetching user identity", zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Error fetching user")
+	}
+
+	// identity baru hanya bisa ditautkan lewat email
+	if idToken.Email == "" {
+		h.Log.SecurityLogger.Warn("OIDC login without email", zap.String("provider", provider))
+		return nil, fiber.NewError(fiber.StatusForbidden, "The identity provider did not share an email address")
+	}
+
+	user, err := h.Users.GetByEmail(c.Context(), idToken.Email)
+	switch {
+	case err == nil:
+		// menautkan akun lama hanya aman jika kedua pihak sudah
+		// membuktikan kepemilikan email, jika tidak pemilik email di
+		// identity provider bisa mengambil alih akun orang lain
+		if !idToken.EmailVerified || user.EmailVerifiedAt == nil {
+			h.Log.SecurityLogger.Warn("OIDC identity not linked, email is not verified",
+				zap.String("provider", provider), zap.Int("user_id", user.ID), zap.Bool("provider_verified", idToken.EmailVerified))
+			return nil, fiber.NewError(fiber.StatusConflict, "An account with this email already exists, log in with your password and verify your email first")
+		}
+	case errors.Is(err, repository.ErrNotFound):
+		user, err = h.provisionOIDCUser(c, idToken)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Error fetching user")
+	}
+
+	identity = &models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     idToken.Subject,
+		Email:       idToken.Email,
+		LastLoginAt: &now,
+	}
+	if err := h.UserIdentities.Create(c.Context(), identity); err != nil {
+		h.Log.ErrorLogger.Error("Error linking user identity", zap.Int("user_id", user.ID), zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Error linking account")
+	}
+	h.Log.AuditLogger.Info("OIDC identity linked", zap.Int("user_id", user.ID), zap.String("provider", provider))
+	return user, nil
+}
+
+// provisionOIDCUser membuat user baru dengan role member untuk identity
+// yang belum punya akun. Password diisi nilai acak yang tidak diketahui
+// siapa pun, user bisa mengaturnya lewat reset password.
+func (h *Handler) provisionOIDCUser(c *fiber.Ctx, idToken *auth.IDToken) (*models.User, error) {
+	hashed, err := h.Passwords.Hash(uuid.NewString())
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error hashing password", zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Error creating user")
+	}
+
+	base := oidcUsername(idToken)
+	username := base
+	for attempt := 0; attempt < 5; attempt++ {
+		if attempt > 0 {
+			// username sudah dipakai, tambahkan akhiran acak
+			suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:6]
+			username = truncate(base, maxOIDCUsernameLength-len(suffix)-1) + "-" + suffix
+		}
+		user := &models.User{
+			Username: username,
+			Email:    idToken.Email,
+			Password: hashed,
+			Role:     models.RoleMember,
+		}
+		err = h.Users.Create(c.Context(), user)
+		if errors.Is(err, repository.ErrDuplicate) {
+			// email yang baru saja didaftarkan orang lain juga berakhir di sini
+			if _, err := h.Users.GetByEmail(c.Context(), idToken.Email); err == nil {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		if idToken.EmailVerified {
+			verifiedAt := time.Now()
+			if err := h.Users.MarkEmailVerified(c.Context(), user.ID, user.Email, verifiedAt); err != nil {
+				h.Log.ErrorLogger.Error("Error marking email verified", zap.Int("user_id", user.ID), zap.Error(err))
+			} else {
+				user.EmailVerifiedAt = &verifiedAt
+			}
+		} else {
+			h.sendVerificationEmail(c, user)
+		}
+		h.Log.AuditLogger.Info("User provisioned from identity provider", zap.Int("user_id", user.ID), zap.String("username", user.Username))
+		return user, nil
+	}
+
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, fiber.NewError(fiber.StatusConflict, "Could not create an account for this identity, please try again")
+	}
+	h.Log.ErrorLogger.Error("Error creating user", zap.Error(err))
+	return nil, fiber.NewError(fiber.StatusInternalServerError, "Error creating user")
+}
+
+// oidcUsername membuat username dari preferred_username atau bagian depan
+// email, hanya dengan huruf kecil, angka, '.', '_', dan '-'
+func oidcUsername(idToken *auth.IDToken) string {
+	name := idToken.PreferredUsername
+	if name == "" || strings.Contains(name, "@") {
+		name, _, _ = strings.Cut(idToken.Email, "@")
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+		}
+	}
+	username := truncate(b.String(), maxOIDCUsernameLength)
+	if username == "" {
+		username = "user"
+	}
+	return username
+}
+
+// truncate memotong s menjadi paling banyak n byte
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
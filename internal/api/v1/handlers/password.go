@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"belajar-go/pkg/mail"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Password reset handlers
+
+// ForgotPassword mengirim link reset password ke email user. Respons selalu
+// sama, baik email terdaftar maupun tidak, agar tidak bisa dipakai untuk
+// mengecek email mana yang terdaftar.
+func (h *Handler) ForgotPassword(c *fiber.Ctx) error {
+	type ForgotPasswordRequest struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	var req ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in forgot password", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	accepted := fiber.Map{
+		"message": "If the email is registered, a password reset link has been sent",
+		"success": true,
+		"status":  200,
+	}
+
+	user, err := h.Users.GetByEmail(c.Context(), req.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			h.Log.SecurityLogger.Warn("Password reset requested for unknown email", zap.String("ip", c.IP()))
+			return c.JSON(accepted)
+		}
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching user",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	token, expiresAt, err := h.PasswordResets.Issue(c.Context(), user.ID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error creating password reset token", zap.Int("user_id", user.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error creating password reset token",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	// kegagalan kirim email hanya dicatat agar respons tetap sama
+	if err := h.Mailer.Send(c.Context(), h.passwordResetMessage(user.Email, token, expiresAt)); err != nil {
+		h.Log.ErrorLogger.Error("Error sending password reset email", zap.Int("user_id", user.ID), zap.Error(err))
+	} else {
+		h.Log.AuditLogger.Info("Password reset email sent", zap.Int("user_id", user.ID))
+	}
+	return c.JSON(accepted)
+}
+
+// passwordResetMessage menyusun email berisi link dan token reset password
+func (h *Handler) passwordResetMessage(to, token string, expiresAt time.Time) mail.Message {
+	link := strings.TrimRight(h.Config.AppBaseURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+	return mail.Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(`Someone requested a password reset for your account.
+
+Open the link below to choose a new password:
+
+%s
+
+Or send this token to POST /api/v1/password/reset:
+
+%s
+
+The link expires at %s and can only be used once. If you did not request
+a password reset, you can ignore this email.
+`, link, token, expiresAt.UTC().Format(time.RFC1123)),
+	}
+}
+
+// ResetPassword mengganti password dengan token dari email. Setelah berhasil
+// semua sesi user (refresh token dan access token) dicabut.
+func (h *Handler) ResetPassword(c *fiber.Ctx) error {
+	type ResetPasswordRequest struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,min=6"`
+	}
+
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in reset password", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	// hash password dulu agar token tidak terpakai jika hashing gagal
+	hashedPassword, err := h.Passwords.Hash(req.Password)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error hashing password", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error hashing password",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	userID, err := h.PasswordResets.Consume(c.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, service.ErrResetTokenInvalid) {
+			h.Log.SecurityLogger.Warn("Invalid password reset token", zap.String("ip", c.IP()))
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Invalid or expired reset token",
+				"success": false,
+				"status":  400,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error using password reset token", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error resetting password",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	user, err := h.Users.Update(c.Context(), userID, repository.UserUpdate{Password: &hashedPassword})
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error updating password", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error resetting password",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	if err := h.revokeAllSessions(c, userID); err != nil {
+		h.Log.ErrorLogger.Error("Error revoking sessions after password reset", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Password changed but sessions could not be revoked",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	// pemilik email sudah terbukti, jadi lockout karena login gagal ikut dibuka
+	if err := h.LoginGuard.Reset(c.Context(), user.Username); err != nil {
+		h.Log.ErrorLogger.Error("Error resetting login attempts", zap.Int("user_id", userID), zap.Error(err))
+	}
+	if user.LockedUntil != nil {
+		h.unlockUser(c, userID)
+	}
+
+	h.Log.SecurityLogger.Warn("Password reset, all sessions revoked", zap.Int("user_id", userID), zap.String("ip", c.IP()))
+	return c.JSON(fiber.Map{
+		"message": "Password has been reset, please log in again",
+		"success": true,
+		"status":  200,
+	})
+}
+
+// revokeAllSessions mencabut semua refresh token dan access token milik user
+func (h *Handler) revokeAllSessions(c *fiber.Ctx, userID int) error {
+	if err := h.RefreshTokens.RevokeAllForUser(c.Context(), userID); err != nil {
+		return err
+	}
+	return h.Denylist.RevokeUser(c.Context(), userID)
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Role management handlers. Semua route membutuhkan permission roles:manage.
+
+// nama role dipakai di JWT dan log, dibatasi huruf kecil, angka, _ dan -
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// ListRoles mengembalikan semua role beserta permission-nya.
+func (h *Handler) ListRoles(c *fiber.Ctx) error {
+	roles, err := h.Roles.List(c.Context())
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching roles", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching roles",
+			"success": false,
+			"status":  500,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "Roles fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    roles,
+	})
+}
+
+// ListPermissions mengembalikan semua permission yang bisa diberikan ke role.
+func (h *Handler) ListPermissions(c *fiber.Ctx) error {
+	permissions, err := h.Roles.ListPermissions(c.Context())
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching permissions", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching permissions",
+			"success": false,
+			"status":  500,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "Permissions fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    permissions,
+	})
+}
+
+// unknownPermission mengembalikan 400 untuk permission yang tidak ada
+func unknownPermission(c *fiber.Ctx) error {
+	return c.Status(400).JSON(fiber.Map{
+		"message": "Unknown permission, see GET /api/v1/permissions",
+		"success": false,
+		"status":  400,
+	})
+}
+
+// CreateRole membuat role baru dengan daftar permission.
+func (h *Handler) CreateRole(c *fiber.Ctx) error {
+	type CreateRoleRequest struct {
+		Name        string   `json:"name" validate:"required"`
+		Description string   `json:"description" validate:"max=255"`
+		Permissions []string `json:"permissions" validate:"required,dive,required"`
+	}
+
+	var req CreateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in create role", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+	if !roleNamePattern.MatchString(req.Name) {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Role name must be 2-50 lowercase letters, digits, '_' or '-' and start with a letter",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	role := models.Role{Name: req.Name, Description: req.Description, Permissions: req.Permissions}
+	if err := h.Roles.Create(c.Context(), &role); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicate):
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Role already exists",
+				"success": false,
+				"status":  409,
+			})
+		case errors.Is(err, repository.ErrConflict):
+			return unknownPermission(c)
+		}
+		h.Log.ErrorLogger.Error("Error creating role", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error creating role",
+			"success": false,
+			"status":  500,
+		})
+	}
+	h.Authorizer.Invalidate()
+
+	// ambil ulang agar permission terurut seperti di List
+	created, err := h.Roles.Get(c.Context(), role.Name)
+	if err != nil {
+		created = &role
+	}
+
+	h.Log.SecurityLogger.Info("Role created", zap.Int("actor_id", c.Locals("userID").(int)), zap.String("role", role.Name), zap.Strings("permissions", created.Permissions))
+	h.Log.AuditLogger.Info("Role created", zap.String("role", role.Name))
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Role created successfully",
+		"success": true,
+		"status":  201,
+		"data":    created,
+	})
+}
+
+// UpdateRole mengubah deskripsi dan/atau mengganti semua permission role.
+// Permission role admin tidak bisa diubah agar selalu ada role yang bisa
+// mengelola role lain.
+func (h *Handler) UpdateRole(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	type UpdateRoleRequest struct {
+		Description *string   `json:"description" validate:"omitempty,max=255"`
+		Permissions *[]string `json:"permissions" validate:"omitempty,dive,required"`
+	}
+
+	var req UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in update role", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	var permissions []string
+	if req.Permissions != nil {
+		if name == models.RoleAdmin {
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Permissions of the admin role cannot be changed",
+				"success": false,
+				"status":  409,
+			})
+		}
+		// slice kosong (bukan nil) berarti semua permission dihapus
+		permissions = append([]string{}, *req.Permissions...)
+	}
+
+	role, err := h.Roles.Update(c.Context(), name, req.Description, permissions)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Role not found",
+				"success": false,
+				"status":  404,
+			})
+		case errors.Is(err, repository.ErrConflict):
+			return unknownPermission(c)
+		}
+		h.Log.ErrorLogger.Error("Error updating role", zap.String("role", name), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error updating role",
+			"success": false,
+			"status":  500,
+		})
+	}
+	h.Authorizer.Invalidate()
+
+	h.Log.SecurityLogger.Info("Role updated", zap.Int("actor_id", c.Locals("userID").(int)), zap.String("role", name), zap.Strings("permissions", role.Permissions))
+	h.Log.AuditLogger.Info("Role updated", zap.String("role", name))
+	return c.JSON(fiber.Map{
+		"message": "Role updated successfully",
+		"success": true,
+		"status":  200,
+		"data":    role,
+	})
+}
+
+// DeleteRole menghapus role yang bukan role bawaan dan tidak dipakai user.
+func (h *Handler) DeleteRole(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	role, err := h.Roles.Get(c.Context(), name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Role not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error fetching role", zap.String("role", name), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error deleting role",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if role.BuiltIn {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Built-in roles cannot be deleted",
+			"success": false,
+			"status":  409,
+		})
+	}
+
+	if err := h.Roles.Delete(c.Context(), name); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Role not found",
+				"success": false,
+				"status":  404,
+			})
+		case errors.Is(err, repository.ErrConflict):
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Role is still assigned to users",
+				"success": false,
+				"status":  409,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error deleting role", zap.String("role", name), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error deleting role",
+			"success": false,
+			"status":  500,
+		})
+	}
+	h.Authorizer.Invalidate()
+
+	h.Log.SecurityLogger.Info("Role deleted", zap.Int("actor_id", c.Locals("userID").(int)), zap.String("role", name))
+	h.Log.AuditLogger.Info("Role deleted", zap.String("role", name))
+	return c.JSON(fiber.Map{
+		"message": "Role deleted successfully",
+		"success": true,
+		"status":  200,
+	})
+}
+
+// AssignRole mengganti role user. Access token user yang sedang aktif
+// dicabut karena masih membawa role lama, refresh token tetap berlaku dan
+// menghasilkan access token dengan role baru.
+func (h *Handler) AssignRole(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	targetID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid user ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	type AssignRoleRequest struct {
+		Role string `json:"role" validate:"required"`
+	}
+
+	var req AssignRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in assign role", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	// mencegah admin terakhir tidak sengaja mencabut aksesnya sendiri
+	if targetID == userID {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "You cannot change your own role",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	if _, err := h.Roles.Get(c.Context(), req.Role); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("Role %q does not exist", req.Role),
+				"success": false,
+				"status":  400,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error fetching role", zap.String("role", req.Role), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error assigning role",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	if err := h.Users.SetRole(c.Context(), targetID, req.Role); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return c.Status(404).JSON(fiber.Map{
+				"message": "User not found",
+				"success": false,
+				"status":  404,
+			})
+		case errors.Is(err, repository.ErrConflict):
+			// role dihapus di antara Get dan SetRole
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("Role %q does not exist", req.Role),
+				"success": false,
+				"status":  400,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error assigning role", zap.Int("user_id", targetID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error assigning role",
+			"success": false,
+			"status":  500,
+		})
+	}
+	h.Redis.Del(c.Context(), fmt.Sprintf("user:%d", targetID))
+	if err := h.Denylist.RevokeUser(c.Context(), targetID); err != nil {
+		h.Log.ErrorLogger.Error("Error revoking access tokens after role change", zap.Int("user_id", targetID), zap.Error(err))
+	}
+
+	h.Log.SecurityLogger.Warn("Role assigned", zap.Int("actor_id", userID), zap.Int("user_id", targetID), zap.String("role", req.Role))
+	h.Log.AuditLogger.Info("Role assigned", zap.Int("user_id", targetID), zap.String("role", req.Role))
+	return c.JSON(fiber.Map{
+		"message": "Role assigned successfully",
+		"success": true,
+		"status":  200,
+	})
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"belajar-go/internal/middleware"
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Session handlers
+
+// sessionResponse adalah satu sesi di daftar sesi. current bernilai true
+// untuk sesi yang dipakai request ini.
+type sessionResponse struct {
+	service.Session
+	Current bool `json:"current"`
+}
+
+// sessionTarget membaca user ID dari parameter :id dan memastikan user
+// yang login adalah pemiliknya atau memiliki permission anyPermission
+func (h *Handler) sessionTarget(c *fiber.Ctx, anyPermission string) (int, error) {
+	userID := c.Locals("userID").(int)
+	targetID, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+	if targetID != userID && !middleware.HasPermission(c, anyPermission) {
+		h.Log.SecurityLogger.Warn("Forbidden", zap.Int("user_id", userID), zap.Int("target_id", targetID), zap.String("path", c.Path()))
+		return 0, fiber.NewError(fiber.StatusForbidden, "Forbidden")
+	}
+	return targetID, nil
+}
+
+// ListSessions menampilkan sesi login user yang masih aktif
+func (h *Handler) ListSessions(c *fiber.Ctx) error {
+	targetID, err := h.sessionTarget(c, models.PermUsersReadAny)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	sessions, err := h.RefreshTokens.Sessions(c.Context(), targetID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching sessions", zap.Int("user_id", targetID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching sessions",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	current, _ := c.Locals("sessionID").(string)
+	data := make([]sessionResponse, len(sessions))
+	for i, s := range sessions {
+		data[i] = sessionResponse{Session: s, Current: targetID == c.Locals("userID").(int) && s.ID == current}
+	}
+	return c.JSON(fiber.Map{
+		"message": "Sessions fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    data,
+	})
+}
+
+// RevokeSession mencabut satu sesi. Refresh token sesi itu tidak bisa
+// dipakai lagi dan access token-nya langsung ditolak.
+func (h *Handler) RevokeSession(c *fiber.Ctx) error {
+	targetID, err := h.sessionTarget(c, models.PermUsersLogout)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	sessionID := c.Params("session_id")
+
+	if err := h.RefreshTokens.RevokeSession(c.Context(), targetID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Session not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error revoking session", zap.Int("user_id", targetID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error revoking session",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Session revoked", zap.Int("by_user_id", c.Locals("userID").(int)), zap.Int("user_id", targetID), zap.String("session_id", sessionID))
+	return c.JSON(fiber.Map{
+		"message": "Session revoked successfully",
+		"success": true,
+		"status":  200,
+	})
+}
+
+// RevokeOtherSessions mencabut semua sesi user yang login kecuali sesi
+// yang dipakai request ini
+func (h *Handler) RevokeOtherSessions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	targetID, err := c.ParamsInt("id")
+	if err != nil || targetID != userID {
+		// admin memakai POST /users/:id/logout untuk user lain
+		return c.Status(403).JSON(fiber.Map{
+			"message": "You can only sign out your own sessions",
+			"success": false,
+			"status":  403,
+		})
+	}
+	current, _ := c.Locals("sessionID").(string)
+
+	revoked, err := h.RefreshTokens.RevokeOtherSessions(c.Context(), userID, current)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error revoking sessions", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error revoking sessions",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Other sessions revoked", zap.Int("user_id", userID), zap.Int("revoked", revoked))
+	return c.JSON(fiber.Map{
+		"message": "Other sessions revoked successfully",
+		"success": true,
+		"status":  200,
+		"data":    fiber.Map{"revoked": revoked},
+	})
+}
+
+// ForceLogout mengakhiri semua sesi user (admin). Access token yang tidak
+// terikat sesi juga dicabut lewat generasi token user.
+func (h *Handler) ForceLogout(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	targetID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid user ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	if _, err := h.Users.GetByID(c.Context(), targetID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "User not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching user",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	if err := h.RefreshTokens.RevokeAllForUser(c.Context(), targetID); err != nil {
+		h.Log.ErrorLogger.Error("Error revoking refresh tokens", zap.Int("user_id", targetID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error revoking sessions",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if err := h.Denylist.RevokeUser(c.Context(), targetID); err != nil {
+		h.Log.ErrorLogger.Error("Error revoking access tokens", zap.Int("user_id", targetID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error revoking sessions",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.SecurityLogger.Warn("User force logged out by admin", zap.Int("admin_id", userID), zap.Int("user_id", targetID))
+	h.Log.AuditLogger.Info("User force logged out", zap.Int("admin_id", userID), zap.Int("user_id", targetID))
+	return c.JSON(fiber.Map{
+		"message": "User logged out from all sessions",
+		"success": true,
+		"status":  200,
+	})
+}
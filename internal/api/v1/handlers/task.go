@@ -1,14 +1,16 @@
 package handlers
 
 import (
-	"belajar-go/internal/config"
+	"belajar-go/internal/middleware"
 	"belajar-go/internal/models"
-	"belajar-go/pkg/logger"
-	"belajar-go/pkg/crypto"
-	"database/sql"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,23 +19,55 @@ import (
 
 // Task handlers
 
-// validStatus is a function to validate the status of a task
-// it will return true if the status is one of the following:
-// - pending
-// - in_progress
-// - completed
-// and false otherwise
-func validStatus(status string) bool {
-	switch status {
-	case "pending", "in_progress", "completed":
-		return true
-	default:
-		return false
+// workflowError mengirim respons untuk error dari TaskWorkflow:
+// 422 untuk status yang tidak dikenal atau bukan status awal, 409 untuk
+// perubahan status yang tidak ada di workflow, dan 403 jika role user
+// tidak boleh melakukan perubahan itu
+func (h *Handler) workflowError(c *fiber.Ctx, err error) error {
+	code, message := 422, "Invalid status"
+	switch {
+	case errors.Is(err, service.ErrTransitionNotAllowed):
+		code, message = 409, "Status transition not allowed"
+	case errors.Is(err, service.ErrTransitionForbidden):
+		code, message = 403, "You don't have permission to perform this status transition"
+	}
+	h.Log.AuditLogger.Warn(message, zap.Int("user_id", c.Locals("userID").(int)), zap.Error(err))
+	return c.Status(code).JSON(fiber.Map{
+		"message": message,
+		"errors":  err.Error(),
+		"success": false,
+		"status":  code,
+	})
+}
+
+// GetTaskWorkflow menampilkan status task, status awal, dan perubahan
+// status yang diizinkan beserta role-nya
+func (h *Handler) GetTaskWorkflow(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"message": "Task workflow fetched successfully",
+		"success": true,
+		"status":  200,
+		"data": fiber.Map{
+			"statuses":    h.Workflow.Statuses(),
+			"initial":     h.Workflow.Initial(),
+			"transitions": h.Workflow.Transitions(),
+		},
+	})
+}
+
+// validPriority mengecek apakah priority adalah salah satu dari
+// models.TaskPriorities (low, medium, high, urgent)
+func validPriority(priority string) bool {
+	for _, p := range models.TaskPriorities {
+		if p == priority {
+			return true
+		}
 	}
+	return false
 }
 
 // createTask adalah fungsi untuk membuat task baru
-func CreateTask(c *fiber.Ctx) error {
+func (h *Handler) CreateTask(c *fiber.Ctx) error {
 	// ambil user ID dari locals
 	userID := c.Locals("userID").(int)
 
@@ -41,15 +75,19 @@ func CreateTask(c *fiber.Ctx) error {
 	type TaskRequest struct {
 		Title        string `json:"title" validate:"required"`
 		Description  string `json:"description" validate:"required"`
-		Status       string `json:"status" validate:"required,oneof=pending in_progress completed"`
+		Status       string `json:"status" validate:"required"`
 		SecurityCode string `json:"security_code"`
+		// Priority kosong berarti medium
+		Priority string `json:"priority" validate:"omitempty,oneof=low medium high urgent"`
+		// DueAt dalam format RFC3339 atau YYYY-MM-DD (sampai akhir hari)
+		DueAt string `json:"due_at"`
 	}
 
 	// variabel req digunakan untuk menerima inputan dari user
 	var req TaskRequest
 	if err := c.BodyParser(&req); err != nil {
 		// kembalikan error 400 jika inputan tidak valid
-		logger.ErrorLogger.Error("Bad request in create task", zap.Error(err))
+		h.Log.ErrorLogger.Error("Bad request in create task", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Bad request",
 			"success": false,
@@ -58,9 +96,9 @@ func CreateTask(c *fiber.Ctx) error {
 	}
 
 	// Enkripsi Security Code
-	encryptedCode, err := crypto.Encrypt(req.SecurityCode, "MySecretEncryptionKey!")
+	encryptedCode, err := h.Keyring.Encrypt(req.SecurityCode)
 	if err != nil {
-		logger.ErrorLogger.Error("Error encrypting security code", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error encrypting security code", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error encrypting security code",
 			"success": false,
@@ -68,8 +106,8 @@ func CreateTask(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := config.Validate.Struct(req); err != nil {
-		logger.ErrorLogger.Error("Validation error in create task", zap.Error(err))
+	if err := h.Validate.Struct(req); err != nil {
+		h.Log.ErrorLogger.Error("Validation error in create task", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Validation error",
 			"errors":  err.Error(),
@@ -78,36 +116,45 @@ func CreateTask(c *fiber.Ctx) error {
 		})
 	}
 
-	// validasi status, jika status tidak valid maka kembalikan error 400
-	// status hanya boleh berisi: pending, in_progress, completed
-	if !validStatus(req.Status) {
-		logger.ErrorLogger.Error("Invalid status in create task")
+	// status harus salah satu status awal di workflow
+	if err := h.Workflow.CheckCreate(req.Status); err != nil {
+		return h.workflowError(c, err)
+	}
+
+	dueAt, err := parseTimeParam("due_at", req.DueAt, true)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Invalid due_at in create task", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
-			"message": "Invalid status",
+			"message": err.Error(),
 			"success": false,
 			"status":  400,
 		})
 	}
 
-	// lakukan eksekusi query untuk membuat task baru di database
+	// simpan task baru di database
 	// jika gagal, maka kembalikan error 500
-	var taskID int
-	err = config.DB.QueryRow(
-		"INSERT INTO tasks (user_id, title, description, status, security_code) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-		userID, req.Title, req.Description, req.Status, encryptedCode,
-	).Scan(&taskID)
-	if err != nil {
+	task := models.Task{
+		UserID:       userID,
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       req.Status,
+		Priority:     req.Priority,
+		SecurityCode: encryptedCode,
+		DueAt:        dueAt,
+	}
+	if err := h.Tasks.Create(c.Context(), &task); err != nil {
 		log.Printf("Error creating task: %v", err)
-		logger.ErrorLogger.Error("Error creating task", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error creating task", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error creating task",
 			"success": false,
 			"status":  500,
 		})
 	}
+	taskID := task.ID
 
 	// kembalikan respons sukses jika task berhasil dibuat
-	logger.AuditLogger.Info("Task created successfully", zap.Int("task_id", taskID))
+	h.Log.AuditLogger.Info("Task created successfully", zap.Int("task_id", taskID))
 	return c.Status(201).JSON(fiber.Map{
 		"message": "Task created successfully",
 		"success": true,
@@ -116,132 +163,236 @@ func CreateTask(c *fiber.Ctx) error {
 	})
 }
 
-// listTasks adalah fungsi untuk mengambil semua task
-func ListTasks(c *fiber.Ctx) error {
-	// ambil user ID dan role dari locals
-	userID := c.Locals("userID").(int)
-	role := c.Locals("role").(string)
+// ukuran halaman ListTasks
+const (
+	defaultTaskPageSize = 20
+	maxTaskPageSize     = 100
+)
+
+// parseTimeParam membaca waktu dalam format RFC3339 atau tanggal (YYYY-MM-DD).
+// Jika endOfDay bernilai true, tanggal tanpa jam dianggap sampai akhir hari.
+func parseTimeParam(name, value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid %s, use RFC3339 or YYYY-MM-DD", name))
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// parseTaskQuery membaca query string ListTasks:
+// limit, cursor, status, priority, user_id (tasks:read:any), created_from,
+// created_to, updated_from, updated_to, due_from, due_to, title, label
+// (ID dipisah koma) dengan label_match (any/all), sort (default
+// defaultSort), dan order (asc/desc)
+func (h *Handler) parseTaskQuery(c *fiber.Ctx, userID int, readAny bool, defaultSort string) (repository.TaskQuery, error) {
+	query := repository.TaskQuery{Limit: defaultTaskPageSize}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxTaskPageSize {
+			return query, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxTaskPageSize))
+		}
+		query.Limit = limit
+	}
+
+	// user dengan tasks:read:any bisa melihat semua task, user lain hanya task miliknya
+	if v := c.Query("user_id"); v != "" {
+		filterUserID, err := strconv.Atoi(v)
+		if err != nil {
+			return query, fiber.NewError(fiber.StatusBadRequest, "Invalid user_id")
+		}
+		if !readAny && filterUserID != userID {
+			return query, fiber.NewError(fiber.StatusForbidden, "You don't have permission to filter tasks by user_id")
+		}
+		query.Filter.UserID = &filterUserID
+	}
+	if !readAny {
+		query.Filter.UserID = &userID
+	}
+
+	if v := c.Query("status"); v != "" {
+		if !h.Workflow.Valid(v) {
+			return query, fiber.NewError(fiber.StatusBadRequest, "Invalid status")
+		}
+		query.Filter.Status = v
+	}
+	if v := c.Query("priority"); v != "" {
+		if !validPriority(v) {
+			return query, fiber.NewError(fiber.StatusBadRequest, "Invalid priority, use one of: low, medium, high, urgent")
+		}
+		query.Filter.Priority = v
+	}
+	query.Filter.TitleContains = c.Query("title")
 
-	// variabel rows digunakan untuk menyimpan hasil query
-	// variabel err digunakan untuk menyimpan error jika ada
-	var rows *sql.Rows
 	var err error
+	if query.Filter.CreatedFrom, err = parseTimeParam("created_from", c.Query("created_from"), false); err != nil {
+		return query, err
+	}
+	if query.Filter.CreatedTo, err = parseTimeParam("created_to", c.Query("created_to"), true); err != nil {
+		return query, err
+	}
+	if query.Filter.UpdatedFrom, err = parseTimeParam("updated_from", c.Query("updated_from"), false); err != nil {
+		return query, err
+	}
+	if query.Filter.UpdatedTo, err = parseTimeParam("updated_to", c.Query("updated_to"), true); err != nil {
+		return query, err
+	}
+	if query.Filter.DueFrom, err = parseTimeParam("due_from", c.Query("due_from"), false); err != nil {
+		return query, err
+	}
+	if query.Filter.DueTo, err = parseTimeParam("due_to", c.Query("due_to"), true); err != nil {
+		return query, err
+	}
+
+	if v := c.Query("label"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			labelID, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || labelID < 1 {
+				return query, fiber.NewError(fiber.StatusBadRequest, "Invalid label, use comma-separated label IDs")
+			}
+			query.Filter.LabelIDs = append(query.Filter.LabelIDs, labelID)
+		}
+		if len(query.Filter.LabelIDs) > maxLabelFilter {
+			return query, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("label accepts at most %d labels", maxLabelFilter))
+		}
+	}
+	switch c.Query("label_match", "any") {
+	case "any":
+	case "all":
+		query.Filter.AllLabels = true
+	default:
+		return query, fiber.NewError(fiber.StatusBadRequest, "Invalid label_match, use any or all")
+	}
+
+	query.SortBy = c.Query("sort", defaultSort)
+	if !repository.TaskSortFields[query.SortBy] {
+		return query, fiber.NewError(fiber.StatusBadRequest, "Invalid sort field, use one of: id, created_at, updated_at, title, status, due_at")
+	}
+	switch c.Query("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, fiber.NewError(fiber.StatusBadRequest, "Invalid order, use asc or desc")
+	}
+
+	// cursor harus berasal dari request dengan sort dan order yang sama
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := repository.DecodeTaskCursor(v)
+		if err != nil || cursor.SortBy != query.SortBy || cursor.Desc != query.Desc {
+			return query, fiber.NewError(fiber.StatusBadRequest, "Invalid cursor")
+		}
+		query.After = cursor
+	}
+	return query, nil
+}
+
+// listTasks adalah fungsi untuk mengambil task dengan pagination (keyset),
+// filter, dan sorting. Security code tidak ikut dikembalikan di list,
+// gunakan GetTask untuk melihatnya.
+func (h *Handler) ListTasks(c *fiber.Ctx) error {
+	return h.listTasks(c, false)
+}
+
+// ListOverdueTasks mengambil task yang belum selesai dan sudah lewat
+// tenggat, diurutkan dari tenggat paling lama. Query string sama dengan
+// ListTasks.
+func (h *Handler) ListOverdueTasks(c *fiber.Ctx) error {
+	return h.listTasks(c, true)
+}
+
+// listTasks menjalankan ListTasks, atau hanya task yang overdue jika
+// overdue bernilai true
+func (h *Handler) listTasks(c *fiber.Ctx, overdue bool) error {
+	// ambil user ID dari locals dan cek apakah user boleh melihat task user lain
+	userID := c.Locals("userID").(int)
+	readAny := middleware.HasPermission(c, models.PermTasksReadAny)
 
-	// query untuk mengambil semua task
-	if role == "admin" {
-		rows, err = config.DB.Query("SELECT * FROM tasks")
-	} else {
-		// query untuk mengambil task berdasarkan user_id
-		rows, err = config.DB.Query("SELECT * FROM tasks WHERE user_id = $1", userID)
+	defaultSort := repository.TaskSortID
+	if overdue {
+		defaultSort = repository.TaskSortDueAt
+	}
+	query, err := h.parseTaskQuery(c, userID, readAny, defaultSort)
+	if err != nil {
+		var fiberErr *fiber.Error
+		errors.As(err, &fiberErr)
+		h.Log.AuditLogger.Warn("Invalid list tasks query", zap.Int("user_id", userID), zap.Error(err))
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"message": fiberErr.Message,
+			"success": false,
+			"status":  fiberErr.Code,
+		})
+	}
+
+	if overdue {
+		now := time.Now().UTC()
+		query.Filter.OverdueAt = &now
 	}
 
+	page, err := h.Tasks.List(c.Context(), query)
 	if err != nil {
 		// kembalikan error 500 jika terjadi kesalahan saat mengambil data dari database
-		logger.ErrorLogger.Error("Error fetching tasks", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error fetching tasks", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error fetching tasks",
 			"success": false,
 			"status":  500,
 		})
 	}
-	// .Close() digunakan untuk menutup koneksi setelah selesai digunakan
-	// hal ini perlu dilakukan agar koneksi tidak terbuka terus menerus
-	// dan agar database tidak mengalami overload
-	defer rows.Close()
-
-	tasks := []models.Task{}
-	for rows.Next() {
-		var task models.Task
-		// Scan semua kolom yang diambil
-		err := rows.Scan(&task.ID, &task.UserID, &task.Title, &task.Description, &task.Status, &task.SecurityCode, &task.CreatedAt, &task.UpdatedAt)
-		if err != nil {
-			// kembalikan error 500 jika terjadi kesalahan saat mengambil data dari database
-			logger.ErrorLogger.Error("Error scanning tasks", zap.Error(err))
-			return c.Status(500).JSON(fiber.Map{
-				"message": "Error scanning tasks",
-				"success": false,
-				"status":  500,
-			})
-		}
 
-		// Dekripsi security_code jika tidak kosong
-		if task.SecurityCode != "" {
-			decrypted, err := crypto.Decrypt(task.SecurityCode, "MySecretEncryptionKey!")
-			if err != nil {
-				// kembalikan error 500 jika terjadi kesalahan saat mengambil data dari database
-				logger.ErrorLogger.Error("Error decrypting security code", zap.Error(err))
-				return c.Status(500).JSON(fiber.Map{
-					"message": "Error decrypting security code",
-					"success": false,
-					"status":  500,
-				})
-			}
-			task.SecurityCode = decrypted
-		}
-
-		tasks = append(tasks, task)
+	for i := range page.Tasks {
+		page.Tasks[i].SecurityCode = ""
 	}
-
-	if err = rows.Err(); err != nil {
-		// kembalikan error 500 jika terjadi kesalahan saat mengulang data dari database
-		logger.ErrorLogger.Error("Error iterating over tasks", zap.Error(err))
+	if err := h.fillTaskLabels(c.Context(), page.Tasks); err != nil {
+		h.Log.ErrorLogger.Error("Error fetching labels", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
-			"message": "Error iterating over tasks",
+			"message": "Error fetching tasks",
 			"success": false,
 			"status":  500,
 		})
 	}
 
-	// Simpan ke Redis
-	for _, task := range tasks {
-		cacheKey := fmt.Sprintf("task:%d", task.ID)
-
-		// Convert task struct ke JSON sebelum disimpan di Redis
-		jsonData, err := json.Marshal(task)
-		if err != nil {
-			log.Println("Error encoding task to JSON:", err)
-			logger.ErrorLogger.Error("Error encoding task to JSON", zap.Error(err))
-			return c.Status(500).JSON(fiber.Map{
-				"message": "Error encoding task to JSON",
-				"success": false,
-				"status":  500,
-			})
-		}
-
-		// Set ke Redis dengan waktu kadaluarsa 1 jam
-		err = config.RedisClient.Set(config.Ctx, cacheKey, jsonData, time.Hour).Err()
-		if err != nil {
-			log.Println("Redis error:", err) // Debugging log
-			logger.ErrorLogger.Error("Error caching task", zap.Error(err))
-			return c.Status(500).JSON(fiber.Map{
-				"message": "Error caching task",
-				"success": false,
-				"status":  500,
-			})
-		}
+	var nextCursor interface{}
+	if page.Next != nil {
+		nextCursor = page.Next.Encode()
 	}
 
 	// kembalikan respons sukses jika task berhasil diambil
-	logger.AuditLogger.Info("Tasks fetched successfully")
+	h.Log.AuditLogger.Info("Tasks fetched successfully", zap.Int("user_id", userID), zap.Int("count", len(page.Tasks)))
 	return c.JSON(fiber.Map{
 		"message": "Tasks fetched successfully",
 		"success": true,
 		"status":  200,
-		"data":    tasks,
+		"data":    page.Tasks,
+		"meta": fiber.Map{
+			"limit":       query.Limit,
+			"total":       page.Total,
+			"next_cursor": nextCursor,
+		},
 	})
 }
 
 // getTask
-func GetTask(c *fiber.Ctx) error {
-	// Ambil user ID dan role dari locals
+func (h *Handler) GetTask(c *fiber.Ctx) error {
+	// Ambil user ID dari locals dan cek apakah user boleh melihat task user lain
 	userID := c.Locals("userID").(int)
-	role := c.Locals("role").(string)
+	readAny := middleware.HasPermission(c, models.PermTasksReadAny)
 
 	// Dapatkan task ID dari parameter URL
 	taskID, err := c.ParamsInt("id")
 	if err != nil {
 		// Kembalikan error jika ID task tidak valid
-		logger.ErrorLogger.Error("Invalid task ID", zap.Error(err))
+		h.Log.ErrorLogger.Error("Invalid task ID", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Invalid task ID",
 			"success": false,
@@ -251,13 +402,13 @@ func GetTask(c *fiber.Ctx) error {
 
 	// Coba ambil data task dari cache Redis
 	cacheKey := fmt.Sprintf("task:%d", taskID)
-	if cached, err := config.RedisClient.Get(config.Ctx, cacheKey).Result(); err == nil {
+	if cached, err := h.Redis.Get(c.Context(), cacheKey).Result(); err == nil {
 		var task models.Task
 		if err = json.Unmarshal([]byte(cached), &task); err == nil {
-			// Validasi hak akses: admin bisa akses semua, user hanya jika task miliknya
-			if role != "admin" && task.UserID != userID {
+			// Validasi hak akses: tasks:read:any bisa akses semua, user lain hanya jika task miliknya
+			if !readAny && task.UserID != userID {
 				// Kembalikan error jika hak akses tidak sesuai
-				logger.ErrorLogger.Error("Forbidden", zap.Error(err))
+				h.Log.ErrorLogger.Error("Forbidden", zap.Error(err))
 				return c.Status(403).JSON(fiber.Map{
 					"message": "Forbidden",
 					"success": false,
@@ -265,47 +416,22 @@ func GetTask(c *fiber.Ctx) error {
 				})
 			}
 
-			// Kembalikan data task
-			logger.AuditLogger.Info("Task found (from cache)")
-			return c.JSON(fiber.Map{
-				"message": "Task found (from cache)",
-				"success": true,
-				"status":  200,
-				"data":    task,
-			})
+			// Label tidak ikut di-cache agar perubahan label langsung terlihat
+			return h.taskFound(c, task, "Task found (from cache)")
 		}
 	}
 
-	// Ambil data task dari database
-	var task models.Task
-	err = config.DB.QueryRow(
-		"SELECT id, user_id, title, description, status, security_code FROM tasks WHERE id = $1",
-		taskID).Scan(&task.ID, &task.UserID, &task.Title, &task.Description, &task.Status, &task.SecurityCode)
+	// Ambil data task dari database beserta pemeriksaan hak aksesnya
+	task, err := h.accessibleTask(c, models.PermTasksReadAny)
 	if err != nil {
-		// Kembalikan error jika task tidak ditemukan
-		logger.ErrorLogger.Error("Task not found", zap.Error(err))
-		return c.Status(404).JSON(fiber.Map{
-			"message": "Task not found",
-			"success": false,
-			"status":  404,
-		})
-	}
-	// Periksa apakah user memiliki izin untuk melihat task ini
-	if role != "admin" && task.UserID != userID {
-		// Kembalikan status 403 jika user tidak memiliki izin
-		logger.ErrorLogger.Error("Forbidden", zap.Error(err))
-		return c.Status(403).JSON(fiber.Map{
-			"message": "Forbidden",
-			"success": false,
-			"status":  403,
-		})
+		return errorResponse(c, err)
 	}
 
 	// Dekripsi Security Code
-	task.SecurityCode, err = crypto.Decrypt(task.SecurityCode, "MySecretEncryptionKey!")
+	task.SecurityCode, err = h.Keyring.Decrypt(task.SecurityCode)
 	if err != nil {
 		// kembalikan error 500 jika terjadi kesalahan saat mengambil data dari database
-		logger.ErrorLogger.Error("Error decrypting security code", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error decrypting security code", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error decrypting security code",
 			"success": false,
@@ -316,30 +442,70 @@ func GetTask(c *fiber.Ctx) error {
 	// Simpan data task ke cache selama 1 jam
 	taskJSON, err := json.Marshal(task)
 	if err == nil {
-		config.RedisClient.SetEX(config.Ctx, cacheKey, taskJSON, time.Hour)
+		h.Redis.SetEX(c.Context(), cacheKey, taskJSON, time.Hour)
 	}
 
 	// Kembalikan respons sukses jika task ditemukan
-	logger.AuditLogger.Info("Task found")
+	return h.taskFound(c, *task, "Task found")
+}
+
+// accessibleTask mengambil task dari parameter :id yang boleh diakses user:
+// task miliknya sendiri, atau task siapa pun jika user punya anyPermission
+func (h *Handler) accessibleTask(c *fiber.Ctx, anyPermission string) (*models.Task, error) {
+	userID := c.Locals("userID").(int)
+	taskID, err := c.ParamsInt("id")
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid task ID")
+	}
+
+	task, err := h.Tasks.GetByID(c.Context(), taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Task not found")
+	}
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching task", zap.Int("task_id", taskID), zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Error fetching task")
+	}
+	if task.UserID != userID && !middleware.HasPermission(c, anyPermission) {
+		h.Log.SecurityLogger.Warn("Forbidden", zap.Int("user_id", userID), zap.Int("task_id", taskID), zap.String("path", c.Path()))
+		return nil, fiber.NewError(fiber.StatusForbidden, "Forbidden")
+	}
+	return task, nil
+}
+
+// taskFound melengkapi task dengan labelnya lalu mengirimnya sebagai
+// respons GetTask
+func (h *Handler) taskFound(c *fiber.Ctx, task models.Task, message string) error {
+	tasks := []models.Task{task}
+	if err := h.fillTaskLabels(c.Context(), tasks); err != nil {
+		h.Log.ErrorLogger.Error("Error fetching labels", zap.Int("task_id", task.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching task",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info(message)
 	return c.JSON(fiber.Map{
-		"message": "Task found",
+		"message": message,
 		"success": true,
 		"status":  200,
-		"data":    task,
+		"data":    tasks[0],
 	})
 }
 
 // updateTask
-func UpdateTask(c *fiber.Ctx) error {
-	// ambil user ID dan role dari locals
+func (h *Handler) UpdateTask(c *fiber.Ctx) error {
+	// ambil user ID dari locals dan cek apakah user boleh mengubah task user lain
 	userID := c.Locals("userID").(int)
-	role := c.Locals("role").(string)
+	updateAny := middleware.HasPermission(c, models.PermTasksUpdateAny)
 
 	// dapatkan target ID dari parameter URL
 	taskID, err := c.ParamsInt("id")
 	if err != nil {
 		// kembalikan error 400 jika ID tidak valid
-		logger.ErrorLogger.Error("Invalid task ID", zap.Error(err))
+		h.Log.ErrorLogger.Error("Invalid task ID", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Invalid task ID",
 			"success": false,
@@ -347,11 +513,18 @@ func UpdateTask(c *fiber.Ctx) error {
 		})
 	}
 
-	var task models.Task
-	err = config.DB.QueryRow("SELECT user_id FROM tasks WHERE id = $1", taskID).Scan(&task.UserID)
+	task, err := h.Tasks.GetByID(c.Context(), taskID)
 	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			h.Log.ErrorLogger.Error("Error fetching task", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error fetching task",
+				"success": false,
+				"status":  500,
+			})
+		}
 		// kembalikan error 404 jika task tidak ditemukan
-		logger.ErrorLogger.Error("Task not found", zap.Error(err))
+		h.Log.ErrorLogger.Error("Task not found", zap.Error(err))
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Task not found",
 			"success": false,
@@ -360,9 +533,9 @@ func UpdateTask(c *fiber.Ctx) error {
 	}
 
 	// periksa apakah user memiliki izin untuk mengupdate task ini
-	if role != "admin" && task.UserID != userID {
+	if !updateAny && task.UserID != userID {
 		// kembalikan error 403 jika user tidak memiliki izin
-		logger.ErrorLogger.Error("You don't have permission to update this task", zap.Error(err))
+		h.Log.ErrorLogger.Error("You don't have permission to update this task", zap.Error(err))
 		return c.Status(403).JSON(fiber.Map{
 			"message": "You don't have permission to update this task",
 			"success": false,
@@ -377,13 +550,16 @@ func UpdateTask(c *fiber.Ctx) error {
 		Description  *string `json:"description"`
 		Status       *string `json:"status"`
 		SecurityCode *string `json:"security_code"`
+		Priority     *string `json:"priority"`
+		// DueAt string kosong berarti tenggat dihapus
+		DueAt *string `json:"due_at"`
 	}
 
 	// parsing body request ke dalam struct
 	var req UpdateTaskRequest
 	if err := c.BodyParser(&req); err != nil {
 		// kembalikan error 400 jika body request tidak dapat diparsing
-		logger.ErrorLogger.Error("Bad request in update task", zap.Error(err))
+		h.Log.ErrorLogger.Error("Bad request in update task", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Bad request",
 			"success": false,
@@ -391,14 +567,30 @@ func UpdateTask(c *fiber.Ctx) error {
 		})
 	}
 
-	// periksa apakah status yang diinputkan valid
-	// status hanya boleh berisi: pending, in_progress, completed
+	// periksa apakah perubahan status diizinkan workflow untuk role user
 	if req.Status != nil {
-		if !validStatus(*req.Status) {
-			// kembalikan error 400 jika status tidak valid
-			logger.ErrorLogger.Error("Invalid status", zap.Error(err))
+		if err := h.Workflow.CheckTransition(task.Status, *req.Status, c.Locals("role").(string)); err != nil {
+			return h.workflowError(c, err)
+		}
+	}
+
+	// periksa apakah prioritas valid: low, medium, high, atau urgent
+	if req.Priority != nil && !validPriority(*req.Priority) {
+		h.Log.ErrorLogger.Error("Invalid priority in update task")
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid priority",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	var dueAt *time.Time
+	clearDueAt := req.DueAt != nil && *req.DueAt == ""
+	if req.DueAt != nil {
+		if dueAt, err = parseTimeParam("due_at", *req.DueAt, true); err != nil {
+			h.Log.ErrorLogger.Error("Invalid due_at in update task", zap.Error(err))
 			return c.Status(400).JSON(fiber.Map{
-				"message": "Invalid status",
+				"message": err.Error(),
 				"success": false,
 				"status":  400,
 			})
@@ -407,9 +599,9 @@ func UpdateTask(c *fiber.Ctx) error {
 
 	var encryptedCode string
 	if req.SecurityCode != nil {
-		encryptedCode, err = crypto.Encrypt(*req.SecurityCode, "MySecretEncryptionKey!")
+		encryptedCode, err = h.Keyring.Encrypt(*req.SecurityCode)
 		if err != nil {
-			logger.ErrorLogger.Error("Error encrypting security code", zap.Error(err))
+			h.Log.ErrorLogger.Error("Error encrypting security code", zap.Error(err))
 			return c.Status(500).JSON(fiber.Map{
 				"message": "Error encrypting security code",
 				"success": false,
@@ -418,46 +610,43 @@ func UpdateTask(c *fiber.Ctx) error {
 		}
 	}
 
-	// lakukan eksekusi query untuk mengupdate task di database
-	_, err = config.DB.Exec(`
-		UPDATE tasks 
-		SET title = COALESCE(NULLIF($1, ''), title), 
-			description = COALESCE(NULLIF($2, ''), description), 
-			status = COALESCE(NULLIF($3, ''), status),
-			security_code = COALESCE(NULLIF($4, ''), security_code)
-		WHERE id = $5`,
-		req.Title, req.Description, req.Status, encryptedCode, taskID,
-	)
-	if err != nil {
-		// kembalikan error 500 jika terjadi kesalahan saat mengupdate database
-		logger.ErrorLogger.Error("Error updating task", zap.Error(err))
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Error updating task",
+	// update task di database lalu ambil data task terbaru
+	update := repository.TaskUpdate{
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       req.Status,
+		Priority:     req.Priority,
+		SecurityCode: &encryptedCode,
+		DueAt:        dueAt,
+		ClearDueAt:   clearDueAt,
+		ActorID:      userID,
+	}
+	if req.Status != nil {
+		// status yang diperiksa di atas harus masih sama saat disimpan
+		update.ExpectedStatus = task.Status
+	}
+	updatedTask, err := h.Tasks.Update(c.Context(), taskID, update)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Task status was changed by another request, fetch the task and try again",
 			"success": false,
-			"status":  500,
+			"status":  409,
 		})
 	}
-
-	// Ambil data task terbaru dari database
-	var updatedTask models.Task
-	err = config.DB.QueryRow(
-		"SELECT id, user_id, title, description, status, security_code FROM tasks WHERE id = $1",
-		taskID,
-	).Scan(&updatedTask.ID, &updatedTask.UserID, &updatedTask.Title, &updatedTask.Description, &updatedTask.Status, &updatedTask.SecurityCode)
 	if err != nil {
-		// kembalikan error 500 jika terjadi kesalahan saat mengambil data task
-		logger.ErrorLogger.Error("Error fetching updated task", zap.Error(err))
+		// kembalikan error 500 jika terjadi kesalahan saat mengupdate database
+		h.Log.ErrorLogger.Error("Error updating task", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
-			"message": "Error fetching updated task",
+			"message": "Error updating task",
 			"success": false,
 			"status":  500,
 		})
 	}
 
 	// Dekripsi security code
-	updatedTask.SecurityCode, err = crypto.Decrypt(updatedTask.SecurityCode, "MySecretEncryptionKey!")
+	updatedTask.SecurityCode, err = h.Keyring.Decrypt(updatedTask.SecurityCode)
 	if err != nil {
-		logger.ErrorLogger.Error("Error decrypting security code", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error decrypting security code", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error decrypting security code",
 			"success": false,
@@ -467,14 +656,14 @@ func UpdateTask(c *fiber.Ctx) error {
 
 	// Perbarui cache Redis untuk task ini
 	cacheKey := fmt.Sprintf("task:%d", taskID)
-	config.RedisClient.Del(config.Ctx, cacheKey)
+	h.Redis.Del(c.Context(), cacheKey)
 	taskJSON, err := json.Marshal(updatedTask)
 	if err == nil {
-		config.RedisClient.SetEX(config.Ctx, cacheKey, taskJSON, time.Hour)
+		h.Redis.SetEX(c.Context(), cacheKey, taskJSON, time.Hour)
 	}
 
 	// kembalikan respons sukses jika task berhasil diupdate
-	logger.AuditLogger.Info("Task updated", zap.Int("taskID", taskID))
+	h.Log.AuditLogger.Info("Task updated", zap.Int("taskID", taskID), zap.Int("user_id", userID))
 	return c.Status(200).JSON(fiber.Map{
 		"message": "Task updated successfully",
 		"success": true,
@@ -483,16 +672,17 @@ func UpdateTask(c *fiber.Ctx) error {
 }
 
 // deleteTask
-func DeleteTask(c *fiber.Ctx) error {
+func (h *Handler) DeleteTask(c *fiber.Ctx) error {
 	// ambil user ID dan role dari locals
 	userID := c.Locals("userID").(int)
 	role := c.Locals("role").(string)
+	deleteAny := middleware.HasPermission(c, models.PermTasksDeleteAny)
 
 	// dapatkan task ID dari parameter URL
 	taskID, err := c.ParamsInt("id")
 	if err != nil {
 		// kembalikan error 400 jika ID tidak valid
-		logger.ErrorLogger.Error("Invalid task ID", zap.Error(err))
+		h.Log.ErrorLogger.Error("Invalid task ID", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Invalid task ID",
 			"success": false,
@@ -500,35 +690,30 @@ func DeleteTask(c *fiber.Ctx) error {
 		})
 	}
 
-	var task models.Task
-	err = config.DB.QueryRow("SELECT user_id FROM tasks WHERE id = $1", taskID).Scan(&task.UserID)
+	task, err := h.Tasks.GetByID(c.Context(), taskID)
 	if err != nil {
-		// kembalikan error 500 jika terjadi kesalahan saat mengambil data task
-		logger.ErrorLogger.Error("Error fetching task", zap.Error(err))
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Error fetching task",
-			"success": false,
-			"status":  500,
-		})
-	}
-
-	// Periksa apakah task ditemukan
-	if task.UserID == 0 {
 		// kembalikan status 404 jika task tidak ditemukan
-		if err == sql.ErrNoRows {
-			logger.ErrorLogger.Error("Task not found", zap.Error(err))
+		if errors.Is(err, repository.ErrNotFound) {
+			h.Log.ErrorLogger.Error("Task not found", zap.Error(err))
 			return c.Status(404).JSON(fiber.Map{
 				"message": "Task not found",
 				"success": false,
 				"status":  404,
 			})
 		}
+		// kembalikan error 500 jika terjadi kesalahan saat mengambil data task
+		h.Log.ErrorLogger.Error("Error fetching task", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching task",
+			"success": false,
+			"status":  500,
+		})
 	}
 
 	// periksa apakah user memiliki izin untuk menghapus task ini
-	if role != "admin" && userID != task.UserID {
+	if !deleteAny && userID != task.UserID {
 		// kembalikan status 403 jika user tidak memiliki izin
-		logger.SecurityLogger.Warn("You don't have permission to delete this task", zap.String("role", role), zap.Int("user_id", userID), zap.Int("task_id", taskID))
+		h.Log.SecurityLogger.Warn("You don't have permission to delete this task", zap.String("role", role), zap.Int("user_id", userID), zap.Int("task_id", taskID))
 		return c.Status(403).JSON(fiber.Map{
 			"message": "Forbidden",
 			"success": false,
@@ -536,11 +721,11 @@ func DeleteTask(c *fiber.Ctx) error {
 		})
 	}
 
-	// lakukan eksekusi query untuk menghapus task di database
-	_, err = config.DB.Exec("DELETE FROM tasks WHERE id = $1", taskID)
+	// hapus task dari database
+	err = h.Tasks.Delete(c.Context(), taskID, userID)
 	if err != nil {
 		// kembalikan error 500 jika terjadi kesalahan saat menghapus dari database
-		logger.ErrorLogger.Error("Error deleting task", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error deleting task", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error deleting task",
 			"success": false,
@@ -550,10 +735,10 @@ func DeleteTask(c *fiber.Ctx) error {
 
 	// Hapus cache Redis untuk task ini
 	cacheKey := fmt.Sprintf("task:%d", taskID)
-	config.RedisClient.Del(config.Ctx, cacheKey)
+	h.Redis.Del(c.Context(), cacheKey)
 
 	// kembalikan respons sukses jika task berhasil dihapus
-	logger.AuditLogger.Info("Task deleted", zap.Int("taskID", taskID))
+	h.Log.AuditLogger.Info("Task deleted", zap.Int("taskID", taskID), zap.Int("user_id", userID))
 	return c.Status(200).JSON(fiber.Map{
 		"message": "Task deleted successfully",
 		"success": true,
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"belajar-go/internal/middleware"
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Task comment handlers
+
+// mentionPattern menangkap @username. Tanda @ yang didahului huruf, angka,
+// atau titik tidak dianggap mention agar alamat email tidak ikut terbaca.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.-]+)`)
+
+// maxCommentMentions membatasi jumlah username yang dicari per komentar
+const maxCommentMentions = 20
+
+// commentRequest adalah isi komentar untuk create dan edit
+type commentRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+// mentionedUsernames mengembalikan username unik yang disebut di body,
+// sesuai urutan kemunculan
+func mentionedUsernames(body string) []string {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// tanda baca di akhir kalimat bukan bagian dari username
+		username := strings.TrimRight(match[1], ".-")
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == maxCommentMentions {
+			break
+		}
+	}
+	return usernames
+}
+
+// resolveMentions mengubah @username di body menjadi ID user. Username
+// yang tidak terdaftar diabaikan.
+func (h *Handler) resolveMentions(ctx context.Context, body string) ([]int, error) {
+	mentions := []int{}
+	for _, username := range mentionedUsernames(body) {
+		user, err := h.Users.GetByUsername(ctx, username)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, user.ID)
+	}
+	return mentions, nil
+}
+
+// taskComment mengambil komentar dari parameter :comment_id yang harus
+// milik task yang boleh dibaca user
+func (h *Handler) taskComment(c *fiber.Ctx) (*models.TaskComment, error) {
+	task, err := h.accessibleTask(c, models.PermTasksReadAny)
+	if err != nil {
+		return nil, err
+	}
+	commentID, err := c.ParamsInt("comment_id")
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid comment ID")
+	}
+	comment, err := h.TaskComments.GetByID(c.Context(), commentID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		h.Log.ErrorLogger.Error("Error fetching comment", zap.Error(err))
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Error fetching comment")
+	}
+	if err != nil || comment.TaskID != task.ID {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Comment not found")
+	}
+	return comment, nil
+}
+
+// ListTaskComments menampilkan komentar task, yang paling lama lebih dulu
+func (h *Handler) ListTaskComments(c *fiber.Ctx) error {
+	task, err := h.accessibleTask(c, models.PermTasksReadAny)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	comments, err := h.TaskComments.ListByTask(c.Context(), task.ID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching comments", zap.Int("task_id", task.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching comments",
+			"success": false,
+			"status":  500,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"message": "Comments fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    comments,
+	})
+}
+
+// CreateTaskComment menambahkan komentar ke task dan mencatat user yang
+// disebut dengan @username
+func (h *Handler) CreateTaskComment(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	task, err := h.accessibleTask(c, models.PermTasksReadAny)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	var req commentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	mentions, err := h.resolveMentions(c.Context(), req.Body)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error resolving mentions", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error creating comment",
+			"success": false,
+			"status":  500,
+		})
+	}
+	comment := models.TaskComment{TaskID: task.ID, UserID: userID, Body: req.Body, Mentions: mentions}
+	if err := h.TaskComments.Create(c.Context(), &comment); err != nil {
+		h.Log.ErrorLogger.Error("Error creating comment", zap.Int("task_id", task.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error creating comment",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Comment created", zap.Int("task_id", task.ID), zap.Int("comment_id", comment.ID), zap.Int("user_id", userID), zap.Ints("mentions", comment.Mentions))
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Comment created successfully",
+		"success": true,
+		"status":  201,
+		"data":    comment,
+	})
+}
+
+// UpdateTaskComment mengubah isi komentar. Hanya penulisnya yang boleh
+// mengubah, dan mention dihitung ulang dari isi yang baru.
+func (h *Handler) UpdateTaskComment(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	comment, err := h.taskComment(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	if comment.UserID != userID {
+		return c.Status(403).JSON(fiber.Map{
+			"message": "You can only edit your own comments",
+			"success": false,
+			"status":  403,
+		})
+	}
+
+	var req commentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	mentions, err := h.resolveMentions(c.Context(), req.Body)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error resolving mentions", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error updating comment",
+			"success": false,
+			"status":  500,
+		})
+	}
+	updated, err := h.TaskComments.Update(c.Context(), comment.ID, req.Body, mentions)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error updating comment", zap.Int("comment_id", comment.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error updating comment",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Comment updated", zap.Int("task_id", comment.TaskID), zap.Int("comment_id", comment.ID), zap.Int("user_id", userID))
+	return c.JSON(fiber.Map{
+		"message": "Comment updated successfully",
+		"success": true,
+		"status":  200,
+		"data":    updated,
+	})
+}
+
+// DeleteTaskComment menghapus komentar (soft delete). Penulisnya atau user
+// dengan tasks:delete:any boleh menghapus.
+func (h *Handler) DeleteTaskComment(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	comment, err := h.taskComment(c)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	if comment.UserID != userID && !middleware.HasPermission(c, models.PermTasksDeleteAny) {
+		h.Log.SecurityLogger.Warn("You don't have permission to delete this comment", zap.Int("user_id", userID), zap.Int("comment_id", comment.ID))
+		return c.Status(403).JSON(fiber.Map{
+			"message": "Forbidden",
+			"success": false,
+			"status":  403,
+		})
+	}
+
+	if err := h.TaskComments.Delete(c.Context(), comment.ID); err != nil {
+		h.Log.ErrorLogger.Error("Error deleting comment", zap.Int("comment_id", comment.ID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error deleting comment",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Comment deleted", zap.Int("task_id", comment.TaskID), zap.Int("comment_id", comment.ID), zap.Int("user_id", userID))
+	return c.JSON(fiber.Map{
+		"message": "Comment deleted successfully",
+		"success": true,
+		"status":  200,
+	})
+}
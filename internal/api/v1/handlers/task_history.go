@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"belajar-go/internal/middleware"
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// GetTaskHistory menampilkan riwayat perubahan task beserta diff per field.
+// Riwayat task yang sudah dihapus tetap bisa dibaca oleh pemiliknya.
+func (h *Handler) GetTaskHistory(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	readAny := middleware.HasPermission(c, models.PermTasksReadAny)
+
+	taskID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid task ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	history, err := h.Tasks.History(c.Context(), taskID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error fetching task history", zap.Int("task_id", taskID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching task history",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	// pemilik diambil dari task, atau dari versi terakhir jika task sudah dihapus
+	var ownerID int
+	task, err := h.Tasks.GetByID(c.Context(), taskID)
+	switch {
+	case err == nil:
+		ownerID = task.UserID
+	case errors.Is(err, repository.ErrNotFound) && len(history) > 0:
+		ownerID = history[len(history)-1].Snapshot.UserID
+	case errors.Is(err, repository.ErrNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Task not found",
+			"success": false,
+			"status":  404,
+		})
+	default:
+		h.Log.ErrorLogger.Error("Error fetching task", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching task",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if !readAny && ownerID != userID {
+		h.Log.SecurityLogger.Warn("Forbidden", zap.Int("user_id", userID), zap.Int("task_id", taskID), zap.String("path", c.Path()))
+		return c.Status(403).JSON(fiber.Map{
+			"message": "Forbidden",
+			"success": false,
+			"status":  403,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Task history fetched successfully",
+		"success": true,
+		"status":  200,
+		"data":    history,
+	})
+}
+
+// RevertTask mengembalikan title, description, status, priority, dan
+// due_at task ke isi versi tertentu dari riwayatnya. Security code tidak
+// ikut dipulihkan karena tidak disimpan di riwayat. Perubahan status tetap
+// harus diizinkan workflow.
+func (h *Handler) RevertTask(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	updateAny := middleware.HasPermission(c, models.PermTasksUpdateAny)
+
+	taskID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid task ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	var req struct {
+		Version int `json:"version" validate:"required,min=1"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	task, err := h.Tasks.GetByID(c.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Task not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error fetching task", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching task",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if !updateAny && task.UserID != userID {
+		h.Log.SecurityLogger.Warn("You don't have permission to revert this task", zap.Int("user_id", userID), zap.Int("task_id", taskID))
+		return c.Status(403).JSON(fiber.Map{
+			"message": "You don't have permission to update this task",
+			"success": false,
+			"status":  403,
+		})
+	}
+
+	version, err := h.Tasks.HistoryVersion(c.Context(), taskID, req.Version)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Version not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error fetching task history", zap.Int("task_id", taskID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching task history",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	snapshot := version.Snapshot
+	if err := h.Workflow.CheckTransition(task.Status, snapshot.Status, c.Locals("role").(string)); err != nil {
+		return h.workflowError(c, err)
+	}
+	update := repository.TaskUpdate{
+		Title:          &snapshot.Title,
+		Description:    &snapshot.Description,
+		SetDescription: true,
+		Status:         &snapshot.Status,
+		Priority:       &snapshot.Priority,
+		DueAt:          snapshot.DueAt,
+		ClearDueAt:     snapshot.DueAt == nil,
+		ExpectedStatus: task.Status,
+		ActorID:        userID,
+		RevertTo:       req.Version,
+	}
+	reverted, err := h.Tasks.Update(c.Context(), taskID, update)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Task status was changed by another request, fetch the task and try again",
+			"success": false,
+			"status":  409,
+		})
+	}
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error reverting task", zap.Int("task_id", taskID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error reverting task",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Redis.Del(c.Context(), fmt.Sprintf("task:%d", taskID))
+	reverted.SecurityCode = ""
+	tasks := []models.Task{*reverted}
+	if err := h.fillTaskLabels(c.Context(), tasks); err != nil {
+		h.Log.ErrorLogger.Error("Error fetching labels", zap.Int("task_id", taskID), zap.Error(err))
+	}
+
+	h.Log.AuditLogger.Info("Task reverted", zap.Int("task_id", taskID), zap.Int("user_id", userID), zap.Int("version", req.Version))
+	return c.JSON(fiber.Map{
+		"message": "Task reverted successfully",
+		"success": true,
+		"status":  200,
+		"data":    tasks[0],
+	})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"belajar-go/internal/middleware"
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// highlightHTML meng-escape teks hasil search lalu mengganti penanda
+// highlight dengan <mark>, sehingga aman ditampilkan sebagai HTML
+func highlightHTML(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, html.EscapeString(repository.HighlightStart), "<mark>")
+	return strings.ReplaceAll(s, html.EscapeString(repository.HighlightStop), "</mark>")
+}
+
+// SearchTasks mencari task berdasarkan title dan description (full-text,
+// prefix matching). User dengan tasks:read:any bisa mencari semua task, user
+// lain hanya task miliknya.
+func (h *Handler) SearchTasks(c *fiber.Ctx) error {
+	// ambil user ID dari locals dan cek apakah user boleh melihat task user lain
+	userID := c.Locals("userID").(int)
+	readAny := middleware.HasPermission(c, models.PermTasksReadAny)
+
+	terms := repository.SearchTerms(c.Query("q"))
+	if len(terms) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Query parameter q is required",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	search := repository.TaskSearch{Terms: terms, Limit: defaultTaskPageSize}
+	if !readAny {
+		search.UserID = &userID
+	}
+	if v := c.Query("status"); v != "" {
+		if !h.Workflow.Valid(v) {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Invalid status",
+				"success": false,
+				"status":  400,
+			})
+		}
+		search.Status = v
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxTaskPageSize {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("limit must be between 1 and %d", maxTaskPageSize),
+				"success": false,
+				"status":  400,
+			})
+		}
+		search.Limit = limit
+	}
+
+	results, err := h.Tasks.Search(c.Context(), search)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error searching tasks", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error searching tasks",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	type SearchResult struct {
+		ID             int       `json:"id"`
+		UserID         int       `json:"user_id"`
+		Title          string    `json:"title"`
+		Status         string    `json:"status"`
+		CreatedAt      time.Time `json:"created_at"`
+		UpdatedAt      time.Time `json:"updated_at"`
+		Rank           float64   `json:"rank"`
+		TitleHighlight string    `json:"title_highlight"`
+		Snippet        string    `json:"snippet"`
+	}
+	data := make([]SearchResult, len(results))
+	for i, r := range results {
+		data[i] = SearchResult{
+			ID:             r.Task.ID,
+			UserID:         r.Task.UserID,
+			Title:          r.Task.Title,
+			Status:         r.Task.Status,
+			CreatedAt:      r.Task.CreatedAt,
+			UpdatedAt:      r.Task.UpdatedAt,
+			Rank:           r.Rank,
+			TitleHighlight: highlightHTML(r.TitleHighlight),
+			Snippet:        highlightHTML(r.Snippet),
+		}
+	}
+
+	h.Log.AuditLogger.Info("Tasks searched", zap.Int("user_id", userID), zap.Int("count", len(data)))
+	return c.JSON(fiber.Map{
+		"message": "Tasks found",
+		"success": true,
+		"status":  200,
+		"data":    data,
+	})
+}
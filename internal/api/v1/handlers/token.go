@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"belajar-go/internal/service"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Token handlers
+
+// RefreshToken menukar refresh token dengan pasangan access token dan
+// refresh token baru. Refresh token lama tidak bisa dipakai lagi.
+func (h *Handler) RefreshToken(c *fiber.Ctx) error {
+	type RefreshRequest struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+		DeviceID     string `json:"device_id" validate:"required"`
+	}
+
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.Log.ErrorLogger.Error("Bad request in refresh token", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Bad request",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	if err := h.Validate.Struct(req); err != nil {
+		h.Log.AuditLogger.Warn("Validation error during refresh token", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Validation error",
+			"errors":  err.Error(),
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	// rotasi refresh token, jika token lama dipakai ulang
+	// maka seluruh family akan dicabut oleh service
+	newRefreshToken, rec, err := h.RefreshTokens.Rotate(c.Context(), req.RefreshToken, req.DeviceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenReused), errors.Is(err, service.ErrDeviceMismatch):
+			h.Log.SecurityLogger.Warn("Refresh token family revoked",
+				zap.Error(err), zap.Int("user_id", rec.UserID), zap.String("family_id", rec.FamilyID), zap.String("device_id", req.DeviceID))
+		case errors.Is(err, service.ErrRefreshTokenInvalid):
+			h.Log.SecurityLogger.Warn("Invalid refresh token", zap.String("device_id", req.DeviceID))
+		default:
+			h.Log.ErrorLogger.Error("Error rotating refresh token", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error rotating refresh token",
+				"success": false,
+				"status":  500,
+			})
+		}
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Invalid refresh token",
+			"success": false,
+			"status":  401,
+		})
+	}
+
+	// ambil role terbaru dari database, karena role bisa berubah sejak login
+	user, err := h.Users.GetByID(c.Context(), rec.UserID)
+	if err != nil {
+		h.Log.SecurityLogger.Warn("Refresh token for unknown user", zap.Int("user_id", rec.UserID), zap.Error(err))
+		_ = h.RefreshTokens.RevokeFamily(c.Context(), rec.UserID, rec.FamilyID)
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Invalid refresh token",
+			"success": false,
+			"status":  401,
+		})
+	}
+
+	tokenString, err := h.generateAccessToken(c.Context(), user, rec.FamilyID)
+	if err != nil {
+		h.Log.ErrorLogger.Error("Error generating token", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error generating token",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	h.Log.AuditLogger.Info("Token refreshed", zap.Int("user_id", rec.UserID), zap.String("device_id", rec.DeviceID))
+	return c.JSON(fiber.Map{
+		"message": "Token refreshed",
+		"success": true,
+		"status":  200,
+		"data": fiber.Map{
+			"user_id":       rec.UserID,
+			"role":          user.Role,
+			"token":         tokenString,
+			"expires_in":    int(h.Tokens.TTL().Seconds()),
+			"refresh_token": newRefreshToken,
+			"device_id":     rec.DeviceID,
+		},
+	})
+}
+
+// Logout mencabut access token yang sedang dipakai beserta sesinya, dan
+// refresh token milik device ini. Jika all_devices bernilai true, semua
+// refresh token milik user ikut dicabut.
+func (h *Handler) Logout(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	jti := c.Locals("jti").(string)
+	expiresAt := c.Locals("tokenExp").(time.Time)
+
+	type LogoutRequest struct {
+		RefreshToken string `json:"refresh_token"`
+		AllDevices   bool   `json:"all_devices"`
+	}
+
+	// body bersifat opsional
+	var req LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			h.Log.ErrorLogger.Error("Bad request in logout", zap.Error(err))
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Bad request",
+				"success": false,
+				"status":  400,
+			})
+		}
+	}
+
+	// masukkan jti access token ke denylist sampai token kadaluarsa
+	if err := h.Denylist.Add(c.Context(), jti, expiresAt); err != nil {
+		h.Log.ErrorLogger.Error("Error revoking access token", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error revoking access token",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	if req.AllDevices {
+		if err := h.RefreshTokens.RevokeAllForUser(c.Context(), userID); err != nil {
+			h.Log.ErrorLogger.Error("Error revoking refresh tokens", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error revoking refresh tokens",
+				"success": false,
+				"status":  500,
+			})
+		}
+	} else if req.RefreshToken != "" {
+		rec, err := h.RefreshTokens.Lookup(c.Context(), req.RefreshToken)
+		if err != nil && !errors.Is(err, service.ErrRefreshTokenInvalid) {
+			h.Log.ErrorLogger.Error("Error looking up refresh token", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error revoking refresh token",
+				"success": false,
+				"status":  500,
+			})
+		}
+		// refresh token milik user lain tidak boleh dicabut lewat logout ini
+		if rec != nil && rec.UserID == userID {
+			if err := h.RefreshTokens.RevokeFamily(c.Context(), userID, rec.FamilyID); err != nil {
+				h.Log.ErrorLogger.Error("Error revoking refresh token", zap.Error(err))
+				return c.Status(500).JSON(fiber.Map{
+					"message": "Error revoking refresh token",
+					"success": false,
+					"status":  500,
+				})
+			}
+		} else if rec != nil {
+			h.Log.SecurityLogger.Warn("Logout with refresh token of another user", zap.Int("user_id", userID), zap.Int("owner_id", rec.UserID))
+		}
+	}
+
+	// sesi access token ini ikut berakhir walaupun body tidak membawa
+	// refresh token, jika tidak refresh token sesi ini masih bisa dipakai
+	if sessionID, _ := c.Locals("sessionID").(string); sessionID != "" && !req.AllDevices {
+		if err := h.RefreshTokens.RevokeFamily(c.Context(), userID, sessionID); err != nil {
+			h.Log.ErrorLogger.Error("Error revoking session", zap.String("session_id", sessionID), zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error revoking refresh token",
+				"success": false,
+				"status":  500,
+			})
+		}
+	}
+
+	h.Log.AuditLogger.Info("Logout success", zap.Int("user_id", userID), zap.Bool("all_devices", req.AllDevices))
+	return c.JSON(fiber.Map{
+		"message": "Logout success",
+		"success": true,
+		"status":  200,
+	})
+}
@@ -1,80 +1,35 @@
 package handlers
 
 import (
-	"belajar-go/internal/config"
+	"belajar-go/internal/middleware"
 	"belajar-go/internal/models"
-	"belajar-go/pkg/logger"
+	"belajar-go/internal/repository"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // User handlers
-// getAllUsers is a function to get all users, accessible only by admin
-func GetAllUsers(c *fiber.Ctx) error {
-	// Ambil role dari locals
-	role := c.Locals("role").(string)
-
-	// Jika role bukan admin, kembalikan status 403 Forbidden
-	if role != "admin" {
-		logger.SecurityLogger.Warn("Forbidden", zap.String("role", role))
-		return c.Status(403).JSON(fiber.Map{
-			"message": "Forbidden",
-			"success": false,
-			"status":  403,
-		})
-	}
-
+// getAllUsers is a function to get all users,
+// accessible only with the users:read:any permission (checked by the route)
+func (h *Handler) GetAllUsers(c *fiber.Ctx) error {
 	// Ambil semua data user dari database
-	rows, err := config.DB.Query("SELECT id, username, email, role, profile_picture, created_at, updated_at FROM users")
+	users, err := h.Users.List(c.Context())
 	if err != nil {
-		logger.ErrorLogger.Error("Error fetching users", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error fetching users", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error fetching users",
 			"success": false,
 			"status":  500,
 		})
 	}
-	// .Close() digunakan untuk menutup koneksi setelah selesai digunakan
-	// hal ini perlu dilakukan agar koneksi tidak terbuka terus menerus
-	// dan agar database tidak mengalami overload
-	defer rows.Close()
-
-	var users []models.User
-	for rows.Next() {
-		var user models.User
-		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.ProfilePicture, &user.CreatedAt, &user.UpdatedAt)
-		if err != nil {
-			logger.ErrorLogger.Error("Error scanning users", zap.Error(err))
-			return c.Status(500).JSON(fiber.Map{
-				"message": "Error scanning users",
-				"success": false,
-				"status":  500,
-			})
-		}
-
-		// Jika ProfilePicture NULL, set jadi string kosong
-		if !user.ProfilePicture.Valid {
-			user.ProfilePicture.String = ""
-		}
-		users = append(users, user)
-	}
-
-	if err = rows.Err(); err != nil {
-		logger.ErrorLogger.Error("Error iterating over users", zap.Error(err))
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Error iterating over users",
-			"success": false,
-			"status":  500,
-		})
-	}
 
 	// kembalikan response success
-	logger.AuditLogger.Info("Users fetched successfully")
+	h.Log.AuditLogger.Info("Users fetched successfully")
 	return c.JSON(fiber.Map{
 		"message": "Users fetched successfully",
 		"success": true,
@@ -84,14 +39,15 @@ func GetAllUsers(c *fiber.Ctx) error {
 }
 
 // getUser is a function to get a single user by ID
-// accessible by admin and the user itself
-func GetUser(c *fiber.Ctx) error {
+// accessible with users:read:any and by the user itself
+func (h *Handler) GetUser(c *fiber.Ctx) error {
 	// Ambil user ID dan role dari locals
 	userID := c.Locals("userID").(int)
 	role := c.Locals("role").(string)
+	readAny := middleware.HasPermission(c, models.PermUsersReadAny)
 	targetID, err := c.ParamsInt("id")
 	if err != nil {
-		logger.ErrorLogger.Error("Invalid user ID", zap.Error(err))
+		h.Log.ErrorLogger.Error("Invalid user ID", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Invalid user ID",
 			"success": false,
@@ -99,9 +55,9 @@ func GetUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Jika role bukan admin dan user ID tidak sama dengan target ID
-	if role != "admin" && userID != targetID {
-		logger.SecurityLogger.Warn("Forbidden", zap.String("role", role), zap.Int("user_id", userID), zap.Int("target_id", targetID))
+	// Jika tidak punya users:read:any dan user ID tidak sama dengan target ID
+	if !readAny && userID != targetID {
+		h.Log.SecurityLogger.Warn("Forbidden", zap.String("role", role), zap.Int("user_id", userID), zap.Int("target_id", targetID))
 		return c.Status(403).JSON(fiber.Map{
 			"message": "Forbidden",
 			"success": false,
@@ -111,7 +67,7 @@ func GetUser(c *fiber.Ctx) error {
 
 	// Coba ambil data dari cache Redis
 	cacheKey := fmt.Sprintf("user:%d", targetID)
-	if cached, err := config.RedisClient.Get(config.Ctx, cacheKey).Result(); err == nil {
+	if cached, err := h.Redis.Get(c.Context(), cacheKey).Result(); err == nil {
 		var user models.User
 		if err = json.Unmarshal([]byte(cached), &user); err == nil {
 			return c.JSON(fiber.Map{
@@ -123,13 +79,18 @@ func GetUser(c *fiber.Ctx) error {
 		}
 	}
 
-	// Jika tidak ada di cache, ambil data dari databas
-	var user models.User
-	err = config.DB.QueryRow(
-		"SELECT id, username, email, role, created_at, updated_at FROM users WHERE id = $1",
-		targetID).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	// Jika tidak ada di cache, ambil data dari database
+	user, err := h.Users.GetByID(c.Context(), targetID)
 	if err != nil {
-		logger.SecurityLogger.Warn("User not found", zap.Error(err))
+		if !errors.Is(err, repository.ErrNotFound) {
+			h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error fetching user",
+				"success": false,
+				"status":  500,
+			})
+		}
+		h.Log.SecurityLogger.Warn("User not found", zap.Error(err))
 		return c.Status(404).JSON(fiber.Map{
 			"message": "User not found",
 			"success": false,
@@ -140,11 +101,11 @@ func GetUser(c *fiber.Ctx) error {
 	// Simpan data user ke cache Redis selama 1 jam
 	userJSON, err := json.Marshal(user)
 	if err == nil {
-		config.RedisClient.SetEX(config.Ctx, cacheKey, userJSON, time.Hour)
+		h.Redis.SetEX(c.Context(), cacheKey, userJSON, time.Hour)
 	}
 
 	// Kembalikan response
-	logger.AuditLogger.Info("User found")
+	h.Log.AuditLogger.Info("User found")
 	return c.JSON(fiber.Map{
 		"message": "User found",
 		"success": true,
@@ -154,16 +115,17 @@ func GetUser(c *fiber.Ctx) error {
 }
 
 // updateUser
-func UpdateUser(c *fiber.Ctx) error {
+func (h *Handler) UpdateUser(c *fiber.Ctx) error {
 	// Ambil user ID dan role dari locals
 	userID := c.Locals("userID").(int)
 	role := c.Locals("role").(string)
+	updateAny := middleware.HasPermission(c, models.PermUsersUpdateAny)
 
 	// Dapatkan target ID dari parameter URL
 	targetID, err := c.ParamsInt("id")
 	if err != nil {
 		// Kembalikan error jika ID tidak valid
-		logger.ErrorLogger.Error("Invalid user ID", zap.Error(err))
+		h.Log.ErrorLogger.Error("Invalid user ID", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Invalid user ID",
 			"success": false,
@@ -172,8 +134,8 @@ func UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// Periksa apakah user memiliki izin untuk memperbarui user ini
-	if role != "admin" && userID != targetID {
-		logger.SecurityLogger.Warn("You don't have permission to update this user", zap.String("role", role), zap.Int("user_id", userID), zap.Int("target_id", targetID))
+	if !updateAny && userID != targetID {
+		h.Log.SecurityLogger.Warn("You don't have permission to update this user", zap.String("role", role), zap.Int("user_id", userID), zap.Int("target_id", targetID))
 		return c.Status(403).JSON(fiber.Map{
 			"message": "You don't have permission to update this user",
 			"success": false,
@@ -193,7 +155,7 @@ func UpdateUser(c *fiber.Ctx) error {
 	var req UpdateUserRequest
 	if err := c.BodyParser(&req); err != nil {
 		// Kembalikan error jika body request tidak dapat diparsing
-		logger.ErrorLogger.Error("Bad request", zap.Error(err))
+		h.Log.ErrorLogger.Error("Bad request", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Bad request",
 			"success": false,
@@ -201,48 +163,49 @@ func UpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Hash the password using bcrypt with default cost
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		// Return error response if password hashing fails
-		logger.ErrorLogger.Error("Error hashing password", zap.Error(err))
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Error hashing password",
-			"success": false,
-			"status":  500,
-		})
+	update := repository.UserUpdate{
+		Username: req.Username,
+		Email:    req.Email,
 	}
 
-	// Update hanya field yang dikirim (gunakan COALESCE di SQL)
-	_, err = config.DB.Exec(`
-        UPDATE users 
-        SET username = COALESCE(NULLIF($1, ''), username), 
-			email = COALESCE(NULLIF($2, ''), email),
-			password = COALESCE(NULLIF($3, ''), password)
-        WHERE id = $4`,
-		req.Username, req.Email, string(hashedPassword), targetID,
-	)
-	if err != nil {
-		// Kembalikan error jika terjadi kesalahan saat memperbarui database
-		logger.ErrorLogger.Error("Error updating user", zap.Error(err))
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Error updating user",
-			"success": false,
-			"status":  500,
-		})
+	// Hash password baru hanya jika dikirim
+	if req.Password != nil && *req.Password != "" {
+		hashedPassword, err := h.Passwords.Hash(*req.Password)
+		if err != nil {
+			// Return error response if password hashing fails
+			h.Log.ErrorLogger.Error("Error hashing password", zap.Error(err))
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Error hashing password",
+				"success": false,
+				"status":  500,
+			})
+		}
+		update.Password = &hashedPassword
 	}
 
-	// Ambil data user terbaru dari database
-	var updatedUser models.User
-	err = config.DB.QueryRow(
-		"SELECT id, username, email, role, created_at, updated_at FROM users WHERE id = $1",
-		targetID,
-	).Scan(&updatedUser.ID, &updatedUser.Username, &updatedUser.Email, &updatedUser.Role, &updatedUser.CreatedAt, &updatedUser.UpdatedAt)
+	// Update hanya field yang dikirim, lalu ambil data user terbaru
+	updatedUser, err := h.Users.Update(c.Context(), targetID, update)
 	if err != nil {
-		// Kembalikan error jika terjadi kesalahan saat mengambil data user
-		logger.ErrorLogger.Error("Error fetching updated user", zap.Error(err))
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			h.Log.ErrorLogger.Error("User not found", zap.Error(err))
+			return c.Status(404).JSON(fiber.Map{
+				"message": "User not found",
+				"success": false,
+				"status":  404,
+			})
+		case errors.Is(err, repository.ErrDuplicate):
+			h.Log.SecurityLogger.Warn("Duplicate username or email", zap.Int("user_id", targetID))
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Username or email already exists",
+				"success": false,
+				"status":  409,
+			})
+		}
+		// Kembalikan error jika terjadi kesalahan saat memperbarui database
+		h.Log.ErrorLogger.Error("Error updating user", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
-			"message": "Error fetching updated user",
+			"message": "Error updating user",
 			"success": false,
 			"status":  500,
 		})
@@ -250,14 +213,14 @@ func UpdateUser(c *fiber.Ctx) error {
 
 	// Perbarui cache Redis
 	cacheKey := fmt.Sprintf("user:%d", targetID)
-	config.RedisClient.Del(config.Ctx, cacheKey)
+	h.Redis.Del(c.Context(), cacheKey)
 	userJSON, err := json.Marshal(updatedUser)
 	if err == nil {
-		config.RedisClient.SetEX(config.Ctx, cacheKey, userJSON, time.Hour)
+		h.Redis.SetEX(c.Context(), cacheKey, userJSON, time.Hour)
 	}
 
 	// Kembalikan respons sukses jika user berhasil diperbarui
-	logger.AuditLogger.Info("User updated successfully", zap.Int("user_id", targetID))
+	h.Log.AuditLogger.Info("User updated successfully", zap.Int("user_id", targetID))
 	return c.JSON(fiber.Map{
 		"message": "User updated successfully",
 		"success": true,
@@ -266,16 +229,17 @@ func UpdateUser(c *fiber.Ctx) error {
 }
 
 // deleteUser
-func DeleteUser(c *fiber.Ctx) error {
+func (h *Handler) DeleteUser(c *fiber.Ctx) error {
 	// Ambil user ID dan role dari locals
 	userID := c.Locals("userID").(int)
 	role := c.Locals("role").(string)
+	deleteAny := middleware.HasPermission(c, models.PermUsersDeleteAny)
 
 	// Dapatkan target ID dari parameter URL
 	targetID, err := c.ParamsInt("id")
 	if err != nil {
 		// Kembalikan error jika ID tidak valid
-		logger.ErrorLogger.Error("Invalid user ID", zap.Error(err))
+		h.Log.ErrorLogger.Error("Invalid user ID", zap.Error(err))
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Invalid user ID",
 			"success": false,
@@ -284,8 +248,8 @@ func DeleteUser(c *fiber.Ctx) error {
 	}
 
 	// Periksa apakah user memiliki izin untuk menghapus user ini
-	if role != "admin" && userID != targetID {
-		logger.SecurityLogger.Warn("You don't have permission to delete this user", zap.String("role", role), zap.Int("user_id", userID), zap.Int("target_id", targetID))
+	if !deleteAny && userID != targetID {
+		h.Log.SecurityLogger.Warn("You don't have permission to delete this user", zap.String("role", role), zap.Int("user_id", userID), zap.Int("target_id", targetID))
 		return c.Status(403).JSON(fiber.Map{
 			"message": "You don't have permission to delete this user",
 			"success": false,
@@ -293,13 +257,27 @@ func DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Lakukan eksekusi query untuk menghapus user dari database
-	_, err = config.DB.Exec(
-		"DELETE FROM users WHERE id = $1",
-		targetID)
+	// Hapus user dari database
+	err = h.Users.Delete(c.Context(), targetID)
 	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			h.Log.ErrorLogger.Error("User not found", zap.Error(err))
+			return c.Status(404).JSON(fiber.Map{
+				"message": "User not found",
+				"success": false,
+				"status":  404,
+			})
+		case errors.Is(err, repository.ErrConflict):
+			h.Log.ErrorLogger.Error("User still has tasks", zap.Error(err))
+			return c.Status(409).JSON(fiber.Map{
+				"message": "User still has tasks",
+				"success": false,
+				"status":  409,
+			})
+		}
 		// Kembalikan error jika terjadi kesalahan saat menghapus dari database
-		logger.ErrorLogger.Error("Error deleting user", zap.Error(err))
+		h.Log.ErrorLogger.Error("Error deleting user", zap.Error(err))
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Error deleting user",
 			"success": false,
@@ -309,13 +287,68 @@ func DeleteUser(c *fiber.Ctx) error {
 
 	// Hapus cache Redis untuk user ini
 	cacheKey := fmt.Sprintf("user:%d", targetID)
-	config.RedisClient.Del(config.Ctx, cacheKey)
+	h.Redis.Del(c.Context(), cacheKey)
 
 	// Kembalikan respons sukses jika user berhasil dihapus
-	logger.AuditLogger.Info("User deleted successfully", zap.Int("user_id", targetID))
+	h.Log.AuditLogger.Info("User deleted successfully", zap.Int("user_id", targetID))
 	return c.Status(200).JSON(fiber.Map{
 		"message": "User deleted successfully",
 		"success": true,
 		"status":  200,
 	})
 }
+
+// UnlockUser membuka kunci akun yang terkunci karena terlalu banyak login
+// gagal, sekaligus menghapus hitungan dan jeda login untuk username tersebut.
+// Membutuhkan permission users:unlock (dicek oleh route).
+func (h *Handler) UnlockUser(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	targetID, err := c.ParamsInt("id")
+	if err != nil {
+		h.Log.ErrorLogger.Error("Invalid user ID", zap.Error(err))
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid user ID",
+			"success": false,
+			"status":  400,
+		})
+	}
+
+	user, err := h.Users.GetByID(c.Context(), targetID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "User not found",
+				"success": false,
+				"status":  404,
+			})
+		}
+		h.Log.ErrorLogger.Error("Error fetching user", zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error fetching user",
+			"success": false,
+			"status":  500,
+		})
+	}
+
+	if err := h.Users.SetLockedUntil(c.Context(), targetID, nil); err != nil {
+		h.Log.ErrorLogger.Error("Error unlocking user", zap.Int("user_id", targetID), zap.Error(err))
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Error unlocking user",
+			"success": false,
+			"status":  500,
+		})
+	}
+	if err := h.LoginGuard.Reset(c.Context(), user.Username); err != nil {
+		h.Log.ErrorLogger.Error("Error resetting login attempts", zap.Int("user_id", targetID), zap.Error(err))
+	}
+	h.Redis.Del(c.Context(), fmt.Sprintf("user:%d", targetID))
+
+	h.Log.SecurityLogger.Warn("Account unlocked by admin", zap.Int("admin_id", userID), zap.Int("user_id", targetID), zap.Bool("was_locked", user.LockedUntil != nil))
+	h.Log.AuditLogger.Info("User unlocked", zap.Int("admin_id", userID), zap.Int("user_id", targetID))
+	return c.JSON(fiber.Map{
+		"message": "User unlocked successfully",
+		"success": true,
+		"status":  200,
+	})
+}
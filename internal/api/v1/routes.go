@@ -2,36 +2,126 @@ package v1
 
 import (
 	"belajar-go/internal/api/v1/handlers"
+	"belajar-go/internal/config"
 	"belajar-go/internal/middleware"
+	"belajar-go/internal/models"
+	"belajar-go/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func RegisterRoutes(app *fiber.App) {
-	api := app.Group("/api/v1")
+// RegisterRoutes mendaftarkan semua route API v1 ke router.
+// Router biasanya adalah group "/api/v1" dari aplikasi Fiber.
+func RegisterRoutes(router fiber.Router, a *config.App) {
+	h := handlers.New(a)
+	auth := middleware.UseToken(a)
+	// member dengan email yang belum diverifikasi hanya bisa logout
+	// jika REQUIRE_EMAIL_VERIFICATION aktif
+	verified := middleware.RequireVerifiedEmail(a)
+	// route yang tidak bisa diakses dengan personal access token
+	session := middleware.RequireSession()
+	scope := middleware.RequireScope
+	perm := middleware.RequirePermission
 
 	// Auth
-	api.Post("/login", handlers.Login)
-	api.Post("/register", handlers.Register)
+	router.Post("/login", h.Login)
+	router.Post("/login/mfa", h.LoginMFA)
+	router.Post("/register", h.Register)
+	router.Post("/token/refresh", h.RefreshToken)
+	router.Post("/logout", auth, session, h.Logout)
+	router.Post("/password/forgot", h.ForgotPassword)
+	router.Post("/password/reset", h.ResetPassword)
+	router.Get("/email/verify", h.VerifyEmail)
+	router.Post("/email/verify/resend", h.ResendVerificationEmail)
+
+	// Login dengan identity provider OpenID Connect
+	router.Get("/oidc/providers", h.ListOIDCProviders)
+	router.Get("/oidc/:provider/login", h.StartOIDCLogin)
+	router.Get("/oidc/:provider/callback", h.OIDCCallback)
+
+	// Two-factor authentication
+	mfaRoutes := router.Group("/mfa/totp", auth, verified, session)
+	mfaRoutes.Get("/", h.GetMFAStatus)
+	mfaRoutes.Post("/enroll", h.EnrollMFA)
+	mfaRoutes.Post("/confirm", h.ConfirmMFA)
+	mfaRoutes.Post("/disable", h.DisableMFA)
+
+	// Personal access tokens
+	tokenRoutes := router.Group("/tokens", auth, verified, session)
+	tokenRoutes.Get("/", h.ListAccessTokens)
+	tokenRoutes.Post("/", h.CreateAccessToken)
+	tokenRoutes.Delete("/:id", h.RevokeAccessToken)
 
 	// User
-	userRoutes := api.Group("/users", middleware.UseToken)
-	userRoutes.Get("/", handlers.GetAllUsers)
-	userRoutes.Get("/:id", handlers.GetUser)
-	userRoutes.Put("/:id", handlers.UpdateUser)
-	userRoutes.Delete("/:id", handlers.DeleteUser)
+	userRoutes := router.Group("/users", auth, verified)
+	userRoutes.Get("/", scope(service.ScopeUsersRead), perm(models.PermUsersReadAny), h.GetAllUsers)
+	userRoutes.Get("/:id", scope(service.ScopeUsersRead), perm(models.PermUsersReadOwn, models.PermUsersReadAny), h.GetUser)
+	userRoutes.Put("/:id", scope(service.ScopeUsersWrite), perm(models.PermUsersUpdateOwn, models.PermUsersUpdateAny), h.UpdateUser)
+	userRoutes.Delete("/:id", scope(service.ScopeUsersWrite), perm(models.PermUsersDeleteOwn, models.PermUsersDeleteAny), h.DeleteUser)
+	userRoutes.Post("/:id/unlock", scope(service.ScopeUsersWrite), perm(models.PermUsersUnlock), h.UnlockUser)
+	userRoutes.Put("/:id/role", session, perm(models.PermRolesManage), h.AssignRole)
+	userRoutes.Get("/:id/sessions", session, perm(models.PermUsersReadOwn, models.PermUsersReadAny), h.ListSessions)
+	userRoutes.Delete("/:id/sessions", session, perm(models.PermUsersUpdateOwn), h.RevokeOtherSessions)
+	userRoutes.Delete("/:id/sessions/:session_id", session, perm(models.PermUsersUpdateOwn, models.PermUsersLogout), h.RevokeSession)
+	userRoutes.Post("/:id/logout", session, perm(models.PermUsersLogout), h.ForceLogout)
+
+	// Roles
+	roleRoutes := router.Group("/roles", auth, verified, session, perm(models.PermRolesManage))
+	roleRoutes.Get("/", h.ListRoles)
+	roleRoutes.Post("/", h.CreateRole)
+	roleRoutes.Put("/:name", h.UpdateRole)
+	roleRoutes.Delete("/:name", h.DeleteRole)
+	router.Get("/permissions", auth, verified, session, perm(models.PermRolesManage), h.ListPermissions)
 
 	// Task
-	taskRoutes := api.Group("/tasks", middleware.UseToken)
-	taskRoutes.Post("/", handlers.CreateTask)
-	taskRoutes.Get("/", handlers.ListTasks)
-	taskRoutes.Get("/:id", handlers.GetTask)
-	taskRoutes.Put("/:id", handlers.UpdateTask)
-	taskRoutes.Delete("/:id", handlers.DeleteTask)
+	taskRoutes := router.Group("/tasks", auth, verified)
+	taskRoutes.Post("/", scope(service.ScopeTasksWrite), perm(models.PermTasksCreate), h.CreateTask)
+	taskRoutes.Get("/", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.ListTasks)
+	taskRoutes.Get("/search", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.SearchTasks)
+	taskRoutes.Get("/overdue", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.ListOverdueTasks)
+	taskRoutes.Get("/workflow", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.GetTaskWorkflow)
+	taskRoutes.Get("/:id", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.GetTask)
+	taskRoutes.Put("/:id", scope(service.ScopeTasksWrite), perm(models.PermTasksUpdateOwn, models.PermTasksUpdateAny), h.UpdateTask)
+	taskRoutes.Delete("/:id", scope(service.ScopeTasksWrite), perm(models.PermTasksDeleteOwn, models.PermTasksDeleteAny), h.DeleteTask)
+	taskRoutes.Get("/:id/history", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.GetTaskHistory)
+	taskRoutes.Post("/:id/revert", scope(service.ScopeTasksWrite), perm(models.PermTasksUpdateOwn, models.PermTasksUpdateAny), h.RevertTask)
+
+	// komentar memakai hak akses baca task yang sama dengan GetTask
+	taskRoutes.Get("/:id/comments", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.ListTaskComments)
+	taskRoutes.Post("/:id/comments", scope(service.ScopeTasksWrite), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.CreateTaskComment)
+	taskRoutes.Put("/:id/comments/:comment_id", scope(service.ScopeTasksWrite), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.UpdateTaskComment)
+	taskRoutes.Delete("/:id/comments/:comment_id", scope(service.ScopeTasksWrite), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.DeleteTaskComment)
+
+	// label dipasang dan dilepas dengan hak akses ubah task yang sama dengan UpdateTask
+	taskRoutes.Post("/:id/labels/:label_id", scope(service.ScopeTasksWrite), perm(models.PermTasksUpdateOwn, models.PermTasksUpdateAny), h.AttachTaskLabel)
+	taskRoutes.Delete("/:id/labels/:label_id", scope(service.ScopeTasksWrite), perm(models.PermTasksUpdateOwn, models.PermTasksUpdateAny), h.DetachTaskLabel)
+
+	// Labels
+	labelRoutes := router.Group("/labels", auth, verified)
+	labelRoutes.Get("/", scope(service.ScopeTasksRead), perm(models.PermTasksReadOwn, models.PermTasksReadAny), h.ListLabels)
+	labelRoutes.Post("/", scope(service.ScopeTasksWrite), perm(models.PermTasksUpdateOwn, models.PermTasksUpdateAny), h.CreateLabel)
+	labelRoutes.Put("/:id", scope(service.ScopeTasksWrite), perm(models.PermTasksUpdateOwn, models.PermTasksUpdateAny), h.UpdateLabel)
+	labelRoutes.Delete("/:id", scope(service.ScopeTasksWrite), perm(models.PermTasksUpdateOwn, models.PermTasksUpdateAny), h.DeleteLabel)
 
 	// File Upload
-	uploadRoutes := api.Group("/upload", middleware.UseToken)
-	uploadRoutes.Post("/", handlers.UploadFile)
-	uploadRoutes.Get("/:filename", handlers.GetFile)
-	uploadRoutes.Post("/profile_picture", handlers.UploadProfilePicture)
+	uploadRoutes := router.Group("/upload", auth, verified)
+	uploadRoutes.Post("/", scope(service.ScopeFilesWrite), h.UploadFile)
+	uploadRoutes.Get("/:filename", scope(service.ScopeFilesRead), h.GetFile)
+	uploadRoutes.Post("/profile_picture", scope(service.ScopeFilesWrite), h.UploadProfilePicture)
+}
+
+// RegisterHealthRoutes mendaftarkan /healthz dan /readyz. Route ini
+// sebaiknya didaftarkan sebelum middleware rate limiter agar probe dari
+// orchestrator tidak ikut dibatasi.
+func RegisterHealthRoutes(router fiber.Router, a *config.App) {
+	h := handlers.New(a)
+	router.Get("/healthz", h.Healthz)
+	router.Get("/readyz", h.Readyz)
+}
+
+// RegisterWellKnownRoutes mendaftarkan /.well-known/jwks.json di root
+// aplikasi (di luar /api/v1) sesuai lokasi standar discovery JWKS.
+func RegisterWellKnownRoutes(router fiber.Router, a *config.App) {
+	h := handlers.New(a)
+	router.Get("/.well-known/jwks.json", h.JWKS)
 }
@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"belajar-go/pkg/jwk"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	// ErrOIDCRejected dikembalikan jika identity provider menolak kode
+	// otorisasi, misalnya karena kode sudah dipakai atau PKCE tidak cocok
+	ErrOIDCRejected = errors.New("identity provider rejected the authorization code")
+	// ErrIDTokenInvalid dikembalikan jika ID token tidak lolos validasi
+	ErrIDTokenInvalid = errors.New("invalid ID token")
+)
+
+const (
+	// maxOIDCResponseSize membatasi ukuran respons dari identity provider
+	maxOIDCResponseSize = 1 << 20
+	// jwksRefreshInterval adalah jarak minimal antara dua pengambilan JWKS
+	// saat ada kid yang tidak dikenal, agar token palsu tidak membanjiri
+	// identity provider
+	jwksRefreshInterval = time.Minute
+)
+
+// algoritma ID token yang diterima. HS256 (client secret sebagai key) dan
+// "none" sengaja tidak didukung.
+var idTokenMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// OIDCProviderConfig berisi pengaturan satu identity provider.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL adalah URL callback yang didaftarkan di identity provider
+	RedirectURL string
+	Scopes      []string
+}
+
+// oidcMetadata adalah bagian dokumen discovery
+// (/.well-known/openid-configuration) yang dipakai aplikasi.
+type oidcMetadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// IDToken berisi claim ID token yang dipakai untuk menautkan user.
+type IDToken struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+// idTokenClaims adalah isi ID token dari identity provider
+type idTokenClaims struct {
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	PreferredUsername string   `json:"preferred_username"`
+	Name              string   `json:"name"`
+	AuthorizedParty   string   `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// flexBool menerima boolean maupun string "true"/"false", karena sebagian
+// identity provider mengirim email_verified sebagai string
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	case "false", "null", "":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// OIDCProvider adalah relying party untuk satu identity provider OpenID
+// Connect dengan authorization code flow dan PKCE. Dokumen discovery dan
+// JWKS diambil saat pertama dibutuhkan lalu disimpan di memori.
+type OIDCProvider struct {
+	cfg    OIDCProviderConfig
+	client *http.Client
+	leeway time.Duration
+
+	mu          sync.Mutex
+	meta        *oidcMetadata
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// NewOIDCProvider membuat OIDCProvider. leeway adalah toleransi selisih
+// jam saat mengecek exp dan iat ID token.
+func NewOIDCProvider(cfg OIDCProviderConfig, client *http.Client, leeway time.Duration) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCProvider{cfg: cfg, client: client, leeway: leeway}
+}
+
+// Name mengembalikan nama provider di URL, misalnya "google".
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+// PKCEChallenge menghitung code_challenge metode S256 dari code_verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL membuat URL halaman login identity provider. state, nonce,
+// dan verifier (PKCE) harus disimpan pemanggil sampai callback.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", PKCEChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange menukar kode otorisasi dengan token di token endpoint lalu
+// memvalidasi ID token-nya: tanda tangan (JWKS), iss, aud, azp, exp, iat,
+// dan nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	// client_secret_basic adalah default OIDC, client_secret_post hanya
+	// dipakai jika provider tidak mendukung basic
+	useBasic := len(meta.TokenAuthMethods) == 0 || contains(meta.TokenAuthMethods, "client_secret_basic")
+	if !useBasic {
+		form.Set("client_id", p.cfg.ClientID)
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if useBasic {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseSize)).Decode(&body)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return nil, fmt.Errorf("%w: %s %s", ErrOIDCRejected, body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("token response: %w", decodeErr)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in token response", ErrIDTokenInvalid)
+	}
+	return p.verifyIDToken(ctx, meta, body.IDToken, nonce)
+}
+
+// verifyIDToken memvalidasi tanda tangan dan claim ID token
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, meta *oidcMetadata, raw, nonce string) (*IDToken, error) {
+	claims := &idTokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(idTokenMethods), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, meta, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIDTokenInvalid, err)
+	}
+
+	now := time.Now()
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s", ErrIDTokenInvalid, reason)
+	}
+	switch {
+	case claims.Issuer != meta.Issuer:
+		return nil, invalid("issuer mismatch")
+	case !claims.VerifyAudience(p.cfg.ClientID, true):
+		return nil, invalid("audience mismatch")
+	// azp wajib sama dengan client ID jika token untuk lebih dari satu audience
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID:
+		return nil, invalid("authorized party mismatch")
+	case claims.ExpiresAt == nil || now.After(claims.ExpiresAt.Add(p.leeway)):
+		return nil, invalid("token expired")
+	case claims.IssuedAt == nil || claims.IssuedAt.After(now.Add(p.leeway)):
+		return nil, invalid("token issued in the future")
+	case claims.Subject == "":
+		return nil, invalid("missing subject")
+	case nonce == "" || claims.Nonce != nonce:
+		return nil, invalid("nonce mismatch")
+	}
+
+	return &IDToken{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		PreferredUsername: claims.PreferredUsername,
+		Name:              claims.Name,
+	}, nil
+}
+
+// metadata mengambil dokumen discovery, sekali saja per proses
+func (p *OIDCProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta oidcMetadata
+	discoveryURL := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &meta); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	// issuer di dokumen discovery harus sama persis dengan yang dikonfigurasi
+	// (OpenID Connect Discovery 1.0, bagian 4.3)
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery: issuer %q does not match configured issuer %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("discovery: authorization_endpoint, token_endpoint and jwks_uri are required")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key mencari public key untuk kid. JWKS diambil ulang jika kid belum
+// dikenal, karena identity provider bisa merotasi key kapan saja.
+func (p *OIDCProvider) key(ctx context.Context, meta *oidcMetadata, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lookup := func() (crypto.PublicKey, bool) {
+		// token tanpa kid hanya diterima jika JWKS berisi satu key
+		if kid == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key, true
+			}
+		}
+		key, ok := p.keys[kid]
+		return key, ok
+	}
+	if key, ok := lookup(); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < jwksRefreshInterval {
+		return nil, jwk.ErrUnknownKey
+	}
+
+	var set jwk.Set
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			// key dengan tipe yang tidak didukung dilewati saja
+			continue
+		}
+		keys[k.KeyID] = pub
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := lookup(); ok {
+		return key, nil
+	}
+	return nil, jwk.ErrUnknownKey
+}
+
+// getJSON mengambil dan men-decode dokumen JSON dari identity provider
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseSize)).Decode(out)
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Package auth menerbitkan dan memverifikasi access token JWT. Handler
+// login dan middleware UseToken memakai service yang sama sehingga format
+// claim, key, dan aturan validasinya hanya didefinisikan di satu tempat.
+package auth
+
+import (
+	"belajar-go/pkg/jwk"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// TokenTypeAccess adalah nilai claim typ untuk access token. Token dengan
+// tipe lain (misalnya jika nanti ada token sementara) ditolak oleh Verify.
+const TokenTypeAccess = "access"
+
+var (
+	// ErrInvalidToken dikembalikan jika tanda tangan, key, atau claim token tidak valid
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired dikembalikan jika token sudah kadaluarsa
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Claims adalah isi access token. Claim standar (iss, aud, sub, iat, nbf,
+// exp, jti) ada di RegisteredClaims dan diisi oleh Issue.
+type Claims struct {
+	UserID        int    `json:"user_id"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	// SessionID adalah family refresh token tempat token ini diterbitkan
+	SessionID string `json:"sid,omitempty"`
+	// Scopes kosong berarti token tidak dibatasi scope
+	Scopes    []string `json:"scopes,omitempty"`
+	TokenType string   `json:"typ"`
+	// Generation adalah generasi token user, untuk mencabut semua token user sekaligus
+	Generation int64 `json:"gen"`
+	jwt.RegisteredClaims
+}
+
+// TokenConfig berisi pengaturan TokenService.
+type TokenConfig struct {
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+	// Leeway adalah toleransi selisih jam saat mengecek exp, nbf, dan iat
+	Leeway time.Duration
+}
+
+// TokenService menandatangani access token dengan key aktif dan
+// memverifikasinya dengan key mana pun di key set.
+type TokenService struct {
+	keys *jwk.KeySet
+	cfg  TokenConfig
+}
+
+// NewTokenService membuat TokenService.
+func NewTokenService(keys *jwk.KeySet, cfg TokenConfig) *TokenService {
+	return &TokenService{keys: keys, cfg: cfg}
+}
+
+// TTL mengembalikan umur access token.
+func (s *TokenService) TTL() time.Duration {
+	return s.cfg.TTL
+}
+
+// JWKS mengembalikan public key untuk /.well-known/jwks.json.
+func (s *TokenService) JWKS() jwk.Set {
+	return s.keys.JWKS()
+}
+
+// Issue menandatangani claims sebagai access token. Claim standar dan jti
+// diisi di sini, lalu claims yang sudah lengkap ikut dikembalikan.
+func (s *TokenService) Issue(claims Claims) (string, *Claims, error) {
+	key := s.keys.Signing()
+	now := time.Now()
+	claims.TokenType = TokenTypeAccess
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Issuer:    s.cfg.Issuer,
+		Audience:  jwt.ClaimStrings{s.cfg.Audience},
+		Subject:   strconv.Itoa(claims.UserID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.TTL)),
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), &claims)
+	// kid disimpan di header agar verifikasi bisa memilih key yang benar
+	// setelah rotasi
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, &claims, nil
+}
+
+// Verify memeriksa tanda tangan dan claim access token lalu mengembalikan
+// claims-nya. Pencabutan token (jti dan gen) dicek oleh pemanggil.
+func (s *TokenService) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	// claim waktu dicek sendiri oleh validate karena parser jwt/v4 tidak
+	// mendukung leeway
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(tokenString, claims, s.verificationKey)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := s.validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verificationKey memilih public key berdasarkan kid di header token.
+// Algoritma token harus sama dengan algoritma key agar token tidak bisa
+// memakai algoritma lain (misalnya HS256 dengan public key sebagai secret).
+func (s *TokenService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := s.keys.Lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+	return key.Public, nil
+}
+
+// validate mengecek claim standar dengan toleransi Leeway. Token dari
+// issuer atau untuk audience lain ditolak walaupun ditandatangani dengan
+// key yang sama.
+func (s *TokenService) validate(c *Claims, now time.Time) error {
+	leeway := s.cfg.Leeway
+	switch {
+	case c.ExpiresAt == nil:
+		return ErrInvalidToken
+	case now.After(c.ExpiresAt.Add(leeway)):
+		return ErrTokenExpired
+	case c.IssuedAt == nil || c.IssuedAt.After(now.Add(leeway)):
+		return ErrInvalidToken
+	case c.NotBefore == nil || c.NotBefore.After(now.Add(leeway)):
+		return ErrInvalidToken
+	case c.Issuer != s.cfg.Issuer || !c.VerifyAudience(s.cfg.Audience, true):
+		return ErrInvalidToken
+	case c.TokenType != TokenTypeAccess:
+		return ErrInvalidToken
+	// jti wajib ada agar token bisa dicabut saat logout
+	case c.ID == "":
+		return ErrInvalidToken
+	case c.UserID <= 0 || c.Subject != strconv.Itoa(c.UserID) || c.Role == "":
+		return ErrInvalidToken
+	}
+	return nil
+}
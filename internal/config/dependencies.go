@@ -1,7 +1,15 @@
 package config
 
-
 import (
+	"belajar-go/configs"
+	"belajar-go/internal/auth"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"belajar-go/pkg/crypto"
+	"belajar-go/pkg/database"
+	"belajar-go/pkg/logger"
+	"belajar-go/pkg/mail"
+	"belajar-go/pkg/password"
 	"context"
 	"database/sql"
 
@@ -9,11 +17,188 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
-var (
-	// Global dependency yang akan digunakan di seluruh aplikasi
-	DB          *sql.DB
-	SecretKey   = []byte("secret")
-	Validate    = validator.New()
-	Ctx         = context.Background()
-	RedisClient *redis.Client
-)
+// App menyimpan semua dependency yang dipakai handler dan middleware.
+// Setiap instance berdiri sendiri, sehingga beberapa App bisa berjalan
+// dalam satu proses (misalnya test yang berjalan paralel).
+type App struct {
+	Config   configs.Config
+	DB       *sql.DB // nil jika memakai repository in-memory
+	Redis    *redis.Client
+	Log      *logger.Loggers
+	Validate *validator.Validate
+	// Tokens menerbitkan dan memverifikasi access token
+	Tokens *auth.TokenService
+
+	repository.Repositories
+
+	RefreshTokens *service.RefreshTokenService
+	Denylist      *service.TokenDenylist
+	LoginGuard    *service.LoginGuard
+	Health        *service.Health
+	// Keyring mengenkripsi dan mendekripsi security code task
+	Keyring *crypto.Keyring
+	// Passwords membuat dan memverifikasi hash password user
+	Passwords password.Hasher
+	// PasswordResets menerbitkan dan memakai token reset password
+	PasswordResets *service.PasswordResetService
+	// Mailer mengirim email ke user, misalnya link reset password
+	Mailer mail.Mailer
+	// EmailVerifier membuat dan memeriksa link verifikasi email
+	EmailVerifier *service.EmailVerifier
+	// VerificationResends membatasi pengiriman ulang email verifikasi
+	VerificationResends *service.RateLimiter
+	// MFA mengelola TOTP dan recovery code user
+	MFA *service.MFAService
+	// MFAChallenges menyimpan login yang masih menunggu kode TOTP
+	MFAChallenges *service.MFAChallengeService
+	// AccessTokens membuat dan memverifikasi personal access token
+	AccessTokens *service.AccessTokenService
+	// Authorizer memetakan role user ke permission-nya
+	Authorizer *service.Authorizer
+	// OIDCProviders berisi identity provider OpenID Connect per nama
+	OIDCProviders map[string]*auth.OIDCProvider
+	// OIDCStates menyimpan state, nonce, dan code_verifier login OpenID Connect
+	OIDCStates *service.OIDCStateService
+	// Workflow menentukan status task dan perubahan status yang diizinkan
+	Workflow *service.TaskWorkflow
+}
+
+// New membuat App dari dependency yang sudah dibuat sebelumnya.
+func New(cfg configs.Config, db *sql.DB, rdb *redis.Client, log *logger.Loggers, repos repository.Repositories) (*App, error) {
+	keyring, err := cfg.Keyring()
+	if err != nil {
+		return nil, err
+	}
+	jwtKeys, err := cfg.JWTKeys()
+	if err != nil {
+		return nil, err
+	}
+	providers, err := cfg.OIDCProviders()
+	if err != nil {
+		return nil, err
+	}
+	oidcProviders := make(map[string]*auth.OIDCProvider, len(providers))
+	for _, p := range providers {
+		oidcProviders[p.Name] = auth.NewOIDCProvider(auth.OIDCProviderConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil, cfg.JWTLeeway)
+	}
+
+	workflow, err := cfg.TaskWorkflow()
+	if err != nil {
+		return nil, err
+	}
+	transitions := make([]service.TaskTransition, len(workflow.Transitions))
+	for i, t := range workflow.Transitions {
+		transitions[i] = service.TaskTransition{From: t.From, To: t.To, Roles: t.Roles}
+	}
+
+	health := service.NewHealth(cfg.ReadinessTimeout)
+	if db != nil {
+		health.Register("postgres", db.PingContext)
+	}
+	if rdb != nil {
+		health.Register("redis", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		})
+	}
+
+	loginGuard := service.NewLoginGuard(rdb, service.LoginGuardConfig{
+		MaxFailures:     cfg.LoginMaxFailures,
+		IPMaxFailures:   cfg.LoginIPMaxFailures,
+		Window:          cfg.LoginFailureWindow,
+		LockoutDuration: cfg.LoginLockoutDuration,
+		BackoffBase:     cfg.LoginBackoffBase,
+		BackoffMax:      cfg.LoginBackoffMax,
+	})
+
+	tokens := auth.NewTokenService(jwtKeys, auth.TokenConfig{
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+		TTL:      cfg.AccessTokenTTL,
+		Leeway:   cfg.JWTLeeway,
+	})
+
+	return &App{
+		Config:              cfg,
+		DB:                  db,
+		Redis:               rdb,
+		Log:                 log,
+		Validate:            validator.New(),
+		Tokens:              tokens,
+		Repositories:        repos,
+		RefreshTokens:       service.NewRefreshTokenService(rdb, cfg.RefreshTokenTTL),
+		Denylist:            service.NewTokenDenylist(rdb),
+		LoginGuard:          loginGuard,
+		PasswordResets:      service.NewPasswordResetService(repos.PasswordResetTokens, cfg.PasswordResetTTL),
+		Mailer:              newMailer(cfg, log),
+		EmailVerifier:       service.NewEmailVerifier([]byte(cfg.EmailLinkSecret), cfg.EmailVerificationTTL),
+		VerificationResends: service.NewRateLimiter(rdb, "verification_resend", 1, cfg.VerificationResendWindow),
+		MFA:                 service.NewMFAService(repos.UserMFA, keyring, cfg.MFAIssuer),
+		MFAChallenges:       service.NewMFAChallengeService(rdb, cfg.MFAChallengeTTL),
+		AccessTokens:        service.NewAccessTokenService(repos.PersonalAccessTokens),
+		Authorizer:          service.NewAuthorizer(repos.Roles),
+		OIDCProviders:       oidcProviders,
+		OIDCStates:          service.NewOIDCStateService(rdb, cfg.OIDCStateTTL),
+		Workflow:            service.NewTaskWorkflow(workflow.Statuses, workflow.Initial, transitions),
+		Health:              health,
+		Keyring:             keyring,
+		Passwords:           password.New(cfg.PasswordParams()),
+	}, nil
+}
+
+// newMailer memilih implementasi Mailer sesuai MAIL_DRIVER
+func newMailer(cfg configs.Config, log *logger.Loggers) mail.Mailer {
+	switch cfg.MailDriver {
+	case "smtp":
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case "file":
+		return mail.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+	default:
+		return mail.NewLogMailer(log.SystemLogger)
+	}
+}
+
+// Open membuat App untuk production: membuka file log, koneksi Postgres,
+// koneksi Redis, dan repository Postgres.
+func Open(cfg configs.Config) (*App, error) {
+	log, err := logger.New(cfg.LogDir)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rdb, err := database.ConnectRedis(cfg)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	app, err := New(cfg, db, rdb, log, repository.NewPostgresRepositories(db))
+	if err != nil {
+		rdb.Close()
+		db.Close()
+		return nil, err
+	}
+	return app, nil
+}
+
+// Close menutup koneksi database dan Redis lalu menulis sisa log.
+func (a *App) Close() {
+	if a.Redis != nil {
+		a.Redis.Close()
+	}
+	if a.DB != nil {
+		a.DB.Close()
+	}
+	a.Log.Sync()
+}
@@ -1,50 +1,215 @@
 package middleware
 
 import (
+	"belajar-go/internal/auth"
+	"belajar-go/internal/config"
+	"belajar-go/internal/models"
+	"belajar-go/internal/service"
+	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
 )
 
-var secretKey = []byte("secret") // Bisa digantikan dengan nilai dari configs
+// UseToken memvalidasi access token (JWT atau personal access token) di
+// header Authorization lalu menyimpan userID dan role ke locals
+func UseToken(a *config.App) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+		if authHeader == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "No token provided"})
+		}
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token format"})
+		}
+		if strings.HasPrefix(parts[1], service.AccessTokenPrefix) {
+			return useAccessToken(a, c, parts[1])
+		}
+		claims, err := a.Tokens.Verify(parts[1])
+		if err != nil {
+			if errors.Is(err, auth.ErrTokenExpired) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token expired"})
+			}
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token"})
+		}
+		revoked, err := a.Denylist.Contains(c.Context(), claims.ID)
+		if err != nil {
+			a.Log.ErrorLogger.Error("Error checking token denylist", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error checking token"})
+		}
+		if revoked {
+			a.Log.SecurityLogger.Warn("Revoked token used", zap.String("jti", claims.ID))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token revoked"})
+		}
+		// token dari generasi lama sudah dicabut, misalnya setelah reset password
+		current, err := a.Denylist.UserGeneration(c.Context(), claims.UserID)
+		if err != nil {
+			a.Log.ErrorLogger.Error("Error checking token denylist", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error checking token"})
+		}
+		if claims.Generation < current {
+			a.Log.SecurityLogger.Warn("Revoked session token used", zap.String("jti", claims.ID), zap.Int("user_id", claims.UserID))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token revoked"})
+		}
+		// sesi yang sudah dicabut (logout, revoke sesi, atau force logout
+		// oleh admin) membuat semua access token di sesi itu tidak berlaku
+		if claims.SessionID != "" {
+			active, err := a.RefreshTokens.TouchSession(c.Context(), claims.UserID, claims.SessionID)
+			if err != nil {
+				a.Log.ErrorLogger.Error("Error checking session", zap.Error(err))
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error checking token"})
+			}
+			if !active {
+				a.Log.SecurityLogger.Warn("Token from revoked session used", zap.String("session_id", claims.SessionID), zap.Int("user_id", claims.UserID))
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Session revoked"})
+			}
+		}
+		if err := setPermissions(a, c, claims.Role); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error checking token"})
+		}
+		c.Locals("userID", claims.UserID)
+		c.Locals("role", claims.Role)
+		// claim email_verified bisa sudah usang, RequireVerifiedEmail
+		// mengecek ulang ke database jika bernilai false
+		c.Locals("emailVerified", claims.EmailVerified)
+		c.Locals("jti", claims.ID)
+		c.Locals("tokenExp", claims.ExpiresAt.Time)
+		c.Locals("sessionID", claims.SessionID)
+		if len(claims.Scopes) > 0 {
+			c.Locals("scopes", claims.Scopes)
+		}
+		return c.Next()
+	}
+}
 
-func UseToken(c *fiber.Ctx) error {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "No token provided"})
+// useAccessToken memvalidasi personal access token. Role dan status email
+// dibaca dari database karena token berumur panjang, dan scope token
+// disimpan ke locals untuk dicek oleh RequireScope.
+func useAccessToken(a *config.App, c *fiber.Ctx, token string) error {
+	pat, err := a.AccessTokens.Authenticate(c.Context(), token)
+	if err != nil {
+		if errors.Is(err, service.ErrAccessTokenInvalid) {
+			a.Log.SecurityLogger.Warn("Invalid personal access token", zap.String("ip", c.IP()))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token"})
+		}
+		a.Log.ErrorLogger.Error("Error checking personal access token", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error checking token"})
 	}
-	parts := strings.Split(authHeader, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token format"})
+	user, err := a.Users.GetByID(c.Context(), pat.UserID)
+	if err != nil {
+		a.Log.ErrorLogger.Error("Error fetching user for personal access token", zap.Int("token_id", pat.ID), zap.Error(err))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid user"})
 	}
-	token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return secretKey, nil
-	})
-	if err != nil || !token.Valid {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token"})
+	if err := setPermissions(a, c, user.Role); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error checking token"})
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token claims"})
+	c.Locals("userID", user.ID)
+	c.Locals("role", user.Role)
+	c.Locals("emailVerified", user.EmailVerifiedAt != nil)
+	c.Locals("accessTokenID", pat.ID)
+	c.Locals("scopes", pat.Scopes)
+	return c.Next()
+}
+
+// setPermissions menyimpan permission milik role ke locals untuk dicek
+// oleh RequirePermission dan handler
+func setPermissions(a *config.App, c *fiber.Ctx, role string) error {
+	permissions, err := a.Authorizer.Permissions(c.Context(), role)
+	if err != nil {
+		a.Log.ErrorLogger.Error("Error loading role permissions", zap.String("role", role), zap.Error(err))
+		return err
 	}
-	if exp, ok := claims["exp"].(float64); !ok || int64(exp) < time.Now().Unix() {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token expired"})
+	c.Locals("permissions", permissions)
+	return nil
+}
+
+// HasPermission mengecek apakah user yang sedang login memiliki permission.
+// Hanya berlaku setelah UseToken.
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	permissions, _ := c.Locals("permissions").(service.PermissionSet)
+	return permissions.Has(permission)
+}
+
+// RequirePermission menolak request jika role user tidak memiliki satu pun
+// dari permissions. Untuk aksi dengan cakupan own/any, route mendaftarkan
+// keduanya dan handler memakai HasPermission untuk mengecek pemilik data.
+// Harus dipasang setelah UseToken.
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, p := range permissions {
+			if HasPermission(c, p) {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "Forbidden",
+			"errors":  fmt.Sprintf("missing permission %s", strings.Join(permissions, " or ")),
+			"success": false,
+			"status":  fiber.StatusForbidden,
+		})
 	}
-	userID, ok := claims["user_id"].(float64)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid user ID in token"})
+}
+
+// RequireScope membatasi route untuk token yang memiliki scope, yaitu
+// personal access token atau JWT dengan claim scopes. Login biasa (JWT
+// tanpa scope) tidak dibatasi. Harus dipasang setelah UseToken.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scopes, ok := c.Locals("scopes").([]string)
+		if !ok || service.HasScope(scopes, scope) {
+			return c.Next()
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": fmt.Sprintf("Token is missing the %s scope", scope),
+			"success": false,
+			"status":  fiber.StatusForbidden,
+		})
+	}
+}
+
+// RequireSession menolak personal access token dan token lain yang
+// dibatasi scope, untuk route yang hanya boleh dipakai dari login biasa
+// seperti logout dan pengelolaan token. Harus dipasang setelah UseToken.
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals("scopes").([]string); ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Personal access tokens cannot be used for this endpoint",
+				"success": false,
+				"status":  fiber.StatusForbidden,
+			})
+		}
+		return c.Next()
 	}
-	role, ok := claims["role"].(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid role in token"})
+}
+
+// RequireVerifiedEmail menolak user yang belum memverifikasi email jika
+// REQUIRE_EMAIL_VERIFICATION aktif. Harus dipasang setelah UseToken.
+// Role dengan email:verification:bypass tidak dibatasi.
+func RequireVerifiedEmail(a *config.App) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !a.Config.RequireEmailVerification || c.Locals("emailVerified") == true || HasPermission(c, models.PermEmailVerificationBypass) {
+			return c.Next()
+		}
+
+		// token dibuat sebelum email diverifikasi, cek status terbaru
+		userID := c.Locals("userID").(int)
+		user, err := a.Users.GetByID(c.Context(), userID)
+		if err != nil {
+			a.Log.ErrorLogger.Error("Error fetching user for email verification check", zap.Int("user_id", userID), zap.Error(err))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid user"})
+		}
+		if user.EmailVerifiedAt == nil {
+			a.Log.SecurityLogger.Warn("Unverified email", zap.Int("user_id", userID), zap.String("path", c.Path()))
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Email address is not verified",
+				"success": false,
+				"status":  fiber.StatusForbidden,
+			})
+		}
+		return c.Next()
 	}
-	c.Locals("userID", int(userID))
-	c.Locals("role", role)
-	return c.Next()
 }
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"belajar-go/pkg/logger"
+	"belajar-go/internal/config"
 	"fmt"
 	"runtime/debug"
 
@@ -9,20 +9,20 @@ import (
 	"go.uber.org/zap"
 )
 
-func ErrorHandler() fiber.Handler {
+func ErrorHandler(a *config.App) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		defer func() {
 			if r := recover(); r != nil {
 				errMsg := fmt.Sprintf("Recovered from panic: %v", r)
 				stack := string(debug.Stack())
-				logger.ErrorLogger.Error(errMsg, zap.String("stack", stack))
+				a.Log.ErrorLogger.Error(errMsg, zap.String("stack", stack))
 				c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"message": errMsg,
 				})
 			}
 		}()
 		// Logging request masuk
-		logger.RequestLogger.Info("Incoming request",
+		a.Log.RequestLogger.Info("Incoming request",
 			zap.String("method", c.Method()),
 			zap.String("url", c.OriginalURL()),
 		)
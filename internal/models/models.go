@@ -9,19 +9,179 @@ type User struct {
 	ID             int            `json:"id"`
 	Username       string         `json:"username"`
 	Email          string         `json:"email"`
+	Password       string         `json:"-"`
 	Role           string         `json:"role"`
 	ProfilePicture sql.NullString `json:"profile_picture"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
+	// LockedUntil terisi jika akun dikunci karena terlalu banyak login gagal
+	LockedUntil *time.Time `json:"locked_until"`
+	// EmailVerifiedAt kosong jika user belum membuka link verifikasi email
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type Task struct {
-	ID           int       `json:"id"`
-	UserID       int       `json:"user_id"`
-	Title        string    `json:"title"`
-	Description  string    `json:"description"`
-	Status       string    `json:"status"`
-	SecurityCode string    `json:"security_code,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int    `json:"id"`
+	UserID       int    `json:"user_id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Status       string `json:"status"`
+	Priority     string `json:"priority"`
+	SecurityCode string `json:"security_code,omitempty"`
+	// DueAt kosong jika task tidak punya tenggat
+	DueAt *time.Time `json:"due_at"`
+	// CompletedAt diisi otomatis saat status berubah menjadi completed
+	// dan dikosongkan lagi jika task dibuka kembali
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// Labels tidak dibaca oleh TaskRepository, handler mengisinya dari
+	// LabelRepository.ForTasks
+	Labels []Label `json:"labels"`
+}
+
+// TaskStatusCompleted adalah status task yang sudah selesai. Status ini
+// wajib ada di setiap workflow (lihat configs.TaskWorkflow) karena SQL
+// repository dan index tasks_overdue_idx memakainya langsung.
+const TaskStatusCompleted = "completed"
+
+// Prioritas task, dari yang paling rendah
+const (
+	TaskPriorityLow    = "low"
+	TaskPriorityMedium = "medium"
+	TaskPriorityHigh   = "high"
+	TaskPriorityUrgent = "urgent"
+)
+
+// TaskPriorities adalah daftar prioritas yang valid, urut dari yang paling rendah
+var TaskPriorities = []string{TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityUrgent}
+
+// Aksi yang dicatat di riwayat task
+const (
+	TaskActionCreate = "create"
+	TaskActionUpdate = "update"
+	TaskActionDelete = "delete"
+	TaskActionRevert = "revert"
+)
+
+// TaskHistory adalah satu baris riwayat task yang tidak pernah diubah
+// setelah ditulis. Security code tidak pernah disimpan di riwayat.
+type TaskHistory struct {
+	ID     int `json:"id"`
+	TaskID int `json:"task_id"`
+	// Version dimulai dari 1 untuk create dan naik setiap perubahan
+	Version int    `json:"version"`
+	Action  string `json:"action"`
+	// ActorID adalah user yang melakukan perubahan
+	ActorID int `json:"actor_id"`
+	// RevertedTo berisi versi yang dipulihkan jika Action adalah revert
+	RevertedTo *int `json:"reverted_to,omitempty"`
+	// Changes berisi nilai sebelum dan sesudah untuk setiap field yang berubah
+	Changes map[string]FieldChange `json:"changes"`
+	// Snapshot adalah isi task setelah perubahan (sebelum dihapus untuk delete)
+	Snapshot  TaskSnapshot `json:"snapshot"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// FieldChange adalah nilai satu field sebelum dan sesudah perubahan.
+// Nilai nil berarti field belum ada (create) atau sudah tidak ada (delete).
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// TaskSnapshot adalah isi task di satu versi, tanpa security code.
+type TaskSnapshot struct {
+	UserID      int        `json:"user_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueAt       *time.Time `json:"due_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// PasswordResetToken adalah token reset password. Token aslinya hanya
+// dikirim lewat email, yang disimpan hanya hash SHA-256-nya.
+type PasswordResetToken struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// UserMFA adalah pengaturan TOTP milik user. Secret disimpan terenkripsi.
+type UserMFA struct {
+	UserID int
+	Secret string
+	// EnabledAt kosong selama pendaftaran belum dikonfirmasi dengan kode
+	EnabledAt *time.Time
+	// LastUsedStep adalah periode TOTP terakhir yang dipakai login,
+	// kode dari periode yang sama atau lebih lama ditolak
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// PersonalAccessToken adalah token jangka panjang untuk script dan CI.
+// Token aslinya hanya ditampilkan sekali saat dibuat, yang disimpan hanya
+// hash SHA-256-nya.
+type PersonalAccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// UserIdentity menautkan user ke akun di identity provider OpenID Connect.
+// Subject adalah claim sub dari provider, yang tetap walaupun email berubah.
+type UserIdentity struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TaskComment adalah komentar di task. Komentar yang dihapus hanya ditandai
+// DeletedAt dan tidak lagi ditampilkan.
+type TaskComment struct {
+	ID     int `json:"id"`
+	TaskID int `json:"task_id"`
+	// UserID adalah penulis komentar
+	UserID int    `json:"user_id"`
+	Body   string `json:"body"`
+	// Mentions berisi ID user yang disebut dengan @username di Body
+	Mentions  []int     `json:"mentions"`
+	CreatedAt time.Time `json:"created_at"`
+	// EditedAt terisi jika komentar pernah diubah
+	EditedAt  *time.Time `json:"edited_at"`
+	DeletedAt *time.Time `json:"-"`
+}
+
+// Label adalah penanda task milik seorang user, atau label bersama yang
+// bisa dipakai semua user jika UserID nil.
+type Label struct {
+	ID     int    `json:"id"`
+	UserID *int   `json:"user_id"`
+	Name   string `json:"name"`
+	// Color berformat #rrggbb dengan huruf kecil
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Shared mengecek apakah label bisa dipakai semua user
+func (l Label) Shared() bool { return l.UserID == nil }
+
+// UsableOn mengecek apakah label boleh dipasang di task milik ownerID:
+// label bersama, atau label pribadi milik pemilik task
+func (l Label) UsableOn(ownerID int) bool {
+	return l.UserID == nil || *l.UserID == ownerID
 }
@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// Role adalah kumpulan permission yang diberikan ke user lewat users.role.
+type Role struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	// BuiltIn bernilai true untuk role bawaan (admin dan member) yang
+	// tidak bisa dihapus
+	BuiltIn   bool      `json:"built_in"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Permission adalah satu izin yang bisa diberikan ke role. Formatnya
+// "resource:aksi" atau "resource:aksi:cakupan", dengan cakupan "own"
+// (data milik sendiri) atau "any" (data semua user).
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Nama role bawaan
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Permission yang dikenal aplikasi
+const (
+	PermTasksCreate    = "tasks:create"
+	PermTasksReadOwn   = "tasks:read:own"
+	PermTasksReadAny   = "tasks:read:any"
+	PermTasksUpdateOwn = "tasks:update:own"
+	PermTasksUpdateAny = "tasks:update:any"
+	PermTasksDeleteOwn = "tasks:delete:own"
+	PermTasksDeleteAny = "tasks:delete:any"
+
+	PermUsersReadOwn   = "users:read:own"
+	PermUsersReadAny   = "users:read:any"
+	PermUsersUpdateOwn = "users:update:own"
+	PermUsersUpdateAny = "users:update:any"
+	PermUsersDeleteOwn = "users:delete:own"
+	PermUsersDeleteAny = "users:delete:any"
+	PermUsersUnlock    = "users:unlock"
+	PermUsersLogout    = "users:logout"
+
+	PermRolesManage = "roles:manage"
+
+	PermLabelsManage = "labels:manage"
+
+	// PermEmailVerificationBypass membebaskan user dari REQUIRE_EMAIL_VERIFICATION
+	PermEmailVerificationBypass = "email:verification:bypass"
+)
+
+// Permissions adalah daftar semua permission. Daftar yang sama diisi ke
+// tabel permissions oleh migration 0009 dan migration setelahnya.
+var Permissions = []Permission{
+	{PermTasksCreate, "Create tasks"},
+	{PermTasksReadOwn, "Read own tasks"},
+	{PermTasksReadAny, "Read tasks of every user"},
+	{PermTasksUpdateOwn, "Update own tasks"},
+	{PermTasksUpdateAny, "Update tasks of every user"},
+	{PermTasksDeleteOwn, "Delete own tasks"},
+	{PermTasksDeleteAny, "Delete tasks of every user"},
+	{PermUsersReadOwn, "Read own profile"},
+	{PermUsersReadAny, "List and read every user"},
+	{PermUsersUpdateOwn, "Update own profile"},
+	{PermUsersUpdateAny, "Update every user"},
+	{PermUsersDeleteOwn, "Delete own account"},
+	{PermUsersDeleteAny, "Delete every user"},
+	{PermUsersUnlock, "Unlock accounts locked after failed logins"},
+	{PermUsersLogout, "Revoke sessions of every user (force logout)"},
+	{PermRolesManage, "Manage roles and assign them to users"},
+	{PermLabelsManage, "Manage shared labels"},
+	{PermEmailVerificationBypass, "Use the API without a verified email address"},
+}
+
+// DefaultRoles adalah role bawaan yang sama dengan perilaku admin/member
+// sebelum ada RBAC.
+func DefaultRoles() []Role {
+	admin := make([]string, len(Permissions))
+	for i, p := range Permissions {
+		admin[i] = p.Name
+	}
+	return []Role{
+		{
+			Name:        RoleAdmin,
+			Description: "Full access to all users and tasks",
+			Permissions: admin,
+			BuiltIn:     true,
+		},
+		{
+			Name:        RoleMember,
+			Description: "Access to own profile and own tasks",
+			Permissions: []string{
+				PermTasksCreate, PermTasksReadOwn, PermTasksUpdateOwn, PermTasksDeleteOwn,
+				PermUsersReadOwn, PermUsersUpdateOwn, PermUsersDeleteOwn,
+			},
+			BuiltIn: true,
+		},
+	}
+}
@@ -1,56 +1,40 @@
 package repository
 
 import (
+	"belajar-go/internal/repository/migrations"
+	"belajar-go/pkg/migrate"
+	"belajar-go/pkg/password"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
-func CreateTableIfNotExists(db *sql.DB) {
-	query := `
-CREATE TABLE IF NOT EXISTS users (
-    id SERIAL PRIMARY KEY,
-    username VARCHAR(255) NOT NULL UNIQUE,
-    email VARCHAR(255) NOT NULL UNIQUE,
-    password VARCHAR(255) NOT NULL,
-    role VARCHAR(255) NOT NULL,
-    profile_picture VARCHAR(255),
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS tasks (
-        id SERIAL PRIMARY KEY,
-        user_id INT NOT NULL REFERENCES users (id),
-        title VARCHAR(255) NOT NULL,
-        description TEXT,
-        status VARCHAR(255) NOT NULL,
-        security_code TEXT,
-        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-        updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-    );
-    `
+// NewMigrator membuat Migrator dari file migrasi yang di-embed
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrations.FS)
+}
 
-	_, err := db.Exec(query)
+// RunMigrations menjalankan semua migrasi yang belum diterapkan
+// dan mengembalikan migrasi yang baru saja dijalankan
+func RunMigrations(ctx context.Context, db *sql.DB) ([]migrate.Migration, error) {
+	migrator, err := NewMigrator(db)
 	if err != nil {
-		log.Fatalf("Error creating table: %v", err)
-	} else {
-		fmt.Println("Table 'data', 'tasks', 'users' are ready.")
+		return nil, err
 	}
+	return migrator.Up(ctx)
 }
 
-func CreateAdminUser(db *sql.DB) {
+func CreateAdminUser(db *sql.DB, hasher password.Hasher) {
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.DefaultCost)
+	hashedPassword, err := hasher.Hash("admin")
 	if err != nil {
 		log.Fatalf("Error hashing password: %v", err)
 	}
 
 	// Insert admin user
 	query := "INSERT INTO users (username, email, password, role) VALUES ($1, $2, $3, $4)"
-	_, err = db.Exec(query, "admin", "admin@mail.com", string(hashedPassword), "admin")
+	_, err = db.Exec(query, "admin", "admin@mail.com", hashedPassword, "admin")
 	if err != nil {
 		log.Fatalf("Error inserting admin user: %v", err)
 	} else {
@@ -58,16 +42,15 @@ func CreateAdminUser(db *sql.DB) {
 	}
 }
 
+// DeleteAllTable membatalkan semua migrasi sehingga semua tabel terhapus
 func DeleteAllTable(db *sql.DB) {
-	query := `
-    DROP TABLE IF EXISTS tasks;
-    DROP TABLE IF EXISTS users;
-    `
-
-	_, err := db.Exec(query)
+	migrator, err := NewMigrator(db)
 	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	if _, err := migrator.Down(context.Background(), 0); err != nil {
 		log.Fatalf("Error deleting table: %v", err)
 	} else {
-		fmt.Println("Table 'data', 'tasks', 'users' are deleted.")
+		fmt.Println("All migrations reverted, tables are deleted.")
 	}
 }
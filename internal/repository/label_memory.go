@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryLabelRepository adalah implementasi LabelRepository di memori.
+// Label yang terpasang di task disimpan di MemoryTaskRepository agar filter
+// label di List bisa memakainya.
+type MemoryLabelRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	labels map[int]models.Label
+	tasks  *MemoryTaskRepository
+}
+
+// NewMemoryLabelRepository membuat MemoryLabelRepository kosong yang
+// memasang label ke task di tasks.
+func NewMemoryLabelRepository(tasks *MemoryTaskRepository) *MemoryLabelRepository {
+	return &MemoryLabelRepository{nextID: 1, labels: map[int]models.Label{}, tasks: tasks}
+}
+
+// nameTaken mengecek unique constraint nama label, seperti index
+// labels_user_id_name_idx dan labels_shared_name_idx. Pemanggil harus
+// memegang r.mu.
+func (r *MemoryLabelRepository) nameTaken(userID *int, name string, exceptID int) bool {
+	for _, l := range r.labels {
+		sameOwner := l.UserID == nil && userID == nil || l.UserID != nil && userID != nil && *l.UserID == *userID
+		if l.ID != exceptID && sameOwner && strings.EqualFold(l.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryLabelRepository) Create(ctx context.Context, label *models.Label) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(label.UserID, label.Name, 0) {
+		return ErrDuplicate
+	}
+	label.ID = r.nextID
+	label.CreatedAt = time.Now()
+	r.nextID++
+	r.labels[label.ID] = *label
+	return nil
+}
+
+func (r *MemoryLabelRepository) GetByID(ctx context.Context, id int) (*models.Label, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	label, ok := r.labels[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &label, nil
+}
+
+// sortLabels mengurutkan label berdasarkan nama lalu ID
+func sortLabels(labels []models.Label) {
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := strings.ToLower(labels[i].Name), strings.ToLower(labels[j].Name)
+		if a != b {
+			return a < b
+		}
+		return labels[i].ID < labels[j].ID
+	})
+}
+
+func (r *MemoryLabelRepository) ListForUser(ctx context.Context, userID int) ([]models.Label, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	labels := []models.Label{}
+	for _, l := range r.labels {
+		if l.UsableOn(userID) {
+			labels = append(labels, l)
+		}
+	}
+	sortLabels(labels)
+	return labels, nil
+}
+
+func (r *MemoryLabelRepository) Update(ctx context.Context, id int, update LabelUpdate) (*models.Label, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	label, ok := r.labels[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if update.Name != nil {
+		if r.nameTaken(label.UserID, *update.Name, id) {
+			return nil, ErrDuplicate
+		}
+		label.Name = *update.Name
+	}
+	if update.Color != nil {
+		label.Color = *update.Color
+	}
+	r.labels[id] = label
+	return &label, nil
+}
+
+func (r *MemoryLabelRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	_, ok := r.labels[id]
+	delete(r.labels, id)
+	r.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+	for _, labels := range r.tasks.labels {
+		delete(labels, id)
+	}
+	return nil
+}
+
+func (r *MemoryLabelRepository) Attach(ctx context.Context, taskID, labelID int) error {
+	r.mu.RLock()
+	_, ok := r.labels[labelID]
+	r.mu.RUnlock()
+	if !ok {
+		return ErrConflict
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+	if _, ok := r.tasks.tasks[taskID]; !ok {
+		return ErrConflict
+	}
+	if r.tasks.labels[taskID] == nil {
+		r.tasks.labels[taskID] = map[int]bool{}
+	}
+	r.tasks.labels[taskID][labelID] = true
+	return nil
+}
+
+func (r *MemoryLabelRepository) Detach(ctx context.Context, taskID, labelID int) error {
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+
+	if !r.tasks.labels[taskID][labelID] {
+		return ErrNotFound
+	}
+	delete(r.tasks.labels[taskID], labelID)
+	return nil
+}
+
+func (r *MemoryLabelRepository) ForTasks(ctx context.Context, taskIDs []int) (map[int][]models.Label, error) {
+	attached := map[int][]int{}
+	r.tasks.mu.RLock()
+	for _, taskID := range taskIDs {
+		for labelID := range r.tasks.labels[taskID] {
+			attached[taskID] = append(attached[taskID], labelID)
+		}
+	}
+	r.tasks.mu.RUnlock()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := map[int][]models.Label{}
+	for taskID, labelIDs := range attached {
+		for _, id := range labelIDs {
+			if label, ok := r.labels[id]; ok {
+				result[taskID] = append(result[taskID], label)
+			}
+		}
+		sortLabels(result[taskID])
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const labelColumns = "id, user_id, name, color, created_at"
+
+// PostgresLabelRepository adalah implementasi LabelRepository dengan Postgres.
+type PostgresLabelRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLabelRepository membuat PostgresLabelRepository baru.
+func NewPostgresLabelRepository(db *sql.DB) *PostgresLabelRepository {
+	return &PostgresLabelRepository{db: db}
+}
+
+// labelFields mengembalikan tujuan Scan untuk labelColumns
+func labelFields(label *models.Label) []interface{} {
+	return []interface{}{&label.ID, &label.UserID, &label.Name, &label.Color, &label.CreatedAt}
+}
+
+func scanLabel(row rowScanner) (*models.Label, error) {
+	var label models.Label
+	if err := row.Scan(labelFields(&label)...); err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return &label, nil
+}
+
+func (r *PostgresLabelRepository) Create(ctx context.Context, label *models.Label) error {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO labels (user_id, name, color) VALUES ($1, $2, $3) RETURNING id, created_at",
+		label.UserID, label.Name, label.Color,
+	).Scan(&label.ID, &label.CreatedAt)
+	return mapPostgresError(err)
+}
+
+func (r *PostgresLabelRepository) GetByID(ctx context.Context, id int) (*models.Label, error) {
+	return scanLabel(r.db.QueryRowContext(ctx, "SELECT "+labelColumns+" FROM labels WHERE id = $1", id))
+}
+
+func (r *PostgresLabelRepository) ListForUser(ctx context.Context, userID int) ([]models.Label, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+labelColumns+" FROM labels WHERE user_id = $1 OR user_id IS NULL ORDER BY LOWER(name), id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []models.Label{}
+	for rows.Next() {
+		label, err := scanLabel(rows)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, *label)
+	}
+	return labels, rows.Err()
+}
+
+func (r *PostgresLabelRepository) Update(ctx context.Context, id int, update LabelUpdate) (*models.Label, error) {
+	return scanLabel(r.db.QueryRowContext(ctx, `
+		UPDATE labels SET name = COALESCE($2, name), color = COALESCE($3, color)
+		WHERE id = $1
+		RETURNING `+labelColumns,
+		id, update.Name, update.Color))
+}
+
+func (r *PostgresLabelRepository) Delete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM labels WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresLabelRepository) Attach(ctx context.Context, taskID, labelID int) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO task_labels (task_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", taskID, labelID)
+	return mapPostgresError(err)
+}
+
+func (r *PostgresLabelRepository) Detach(ctx context.Context, taskID, labelID int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2", taskID, labelID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresLabelRepository) ForTasks(ctx context.Context, taskIDs []int) (map[int][]models.Label, error) {
+	result := map[int][]models.Label{}
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+	ids := make(pq.Int64Array, len(taskIDs))
+	for i, id := range taskIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tl.task_id, l.id, l.user_id, l.name, l.color, l.created_at
+		FROM task_labels tl
+		JOIN labels l ON l.id = tl.label_id
+		WHERE tl.task_id = ANY($1)
+		ORDER BY LOWER(l.name), l.id`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		var label models.Label
+		if err := rows.Scan(append([]interface{}{&taskID}, labelFields(&label)...)...); err != nil {
+			return nil, err
+		}
+		result[taskID] = append(result[taskID], label)
+	}
+	return result, rows.Err()
+}
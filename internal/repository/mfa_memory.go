@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryMFARepository adalah implementasi MFARepository di memori.
+type MemoryMFARepository struct {
+	mu    sync.Mutex
+	mfa   map[int]models.UserMFA
+	codes map[int]map[string]*time.Time // user ID -> code hash -> used_at
+}
+
+// NewMemoryMFARepository membuat MemoryMFARepository kosong.
+func NewMemoryMFARepository() *MemoryMFARepository {
+	return &MemoryMFARepository{mfa: map[int]models.UserMFA{}, codes: map[int]map[string]*time.Time{}}
+}
+
+func (r *MemoryMFARepository) Get(ctx context.Context, userID int) (*models.UserMFA, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mfa, ok := r.mfa[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &mfa, nil
+}
+
+func (r *MemoryMFARepository) SavePending(ctx context.Context, userID int, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if mfa, ok := r.mfa[userID]; ok && mfa.EnabledAt != nil {
+		return ErrDuplicate
+	}
+	r.mfa[userID] = models.UserMFA{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	return nil
+}
+
+func (r *MemoryMFARepository) Enable(ctx context.Context, userID int, at time.Time, step int64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mfa, ok := r.mfa[userID]
+	if !ok || mfa.EnabledAt != nil {
+		return ErrNotFound
+	}
+	mfa.EnabledAt = &at
+	mfa.LastUsedStep = step
+	r.mfa[userID] = mfa
+
+	codes := map[string]*time.Time{}
+	for _, hash := range codeHashes {
+		codes[hash] = nil
+	}
+	r.codes[userID] = codes
+	return nil
+}
+
+func (r *MemoryMFARepository) UseStep(ctx context.Context, userID int, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mfa, ok := r.mfa[userID]
+	if !ok || mfa.EnabledAt == nil || mfa.LastUsedStep >= step {
+		return ErrNotFound
+	}
+	mfa.LastUsedStep = step
+	r.mfa[userID] = mfa
+	return nil
+}
+
+func (r *MemoryMFARepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usedAt, ok := r.codes[userID][codeHash]
+	if !ok || usedAt != nil {
+		return 0, ErrNotFound
+	}
+	r.codes[userID][codeHash] = &at
+	return r.remaining(userID), nil
+}
+
+func (r *MemoryMFARepository) RemainingRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remaining(userID), nil
+}
+
+func (r *MemoryMFARepository) remaining(userID int) int {
+	n := 0
+	for _, usedAt := range r.codes[userID] {
+		if usedAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *MemoryMFARepository) Delete(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.mfa[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.mfa, userID)
+	delete(r.codes, userID)
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PostgresMFARepository adalah implementasi MFARepository dengan Postgres.
+type PostgresMFARepository struct {
+	db *sql.DB
+}
+
+// NewPostgresMFARepository membuat PostgresMFARepository baru.
+func NewPostgresMFARepository(db *sql.DB) *PostgresMFARepository {
+	return &PostgresMFARepository{db: db}
+}
+
+func (r *PostgresMFARepository) Get(ctx context.Context, userID int) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	err := r.db.QueryRowContext(ctx,
+		"SELECT user_id, secret, enabled_at, last_used_step, created_at FROM user_mfa WHERE user_id = $1", userID,
+	).Scan(&mfa.UserID, &mfa.Secret, &mfa.EnabledAt, &mfa.LastUsedStep, &mfa.CreatedAt)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return &mfa, nil
+}
+
+func (r *PostgresMFARepository) SavePending(ctx context.Context, userID int, secret string) error {
+	// pendaftaran yang sudah aktif tidak boleh ditimpa
+	res, err := r.db.ExecContext(ctx, `
+        INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE
+        SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+        WHERE user_mfa.enabled_at IS NULL`,
+		userID, secret)
+	err = checkAffected(res, err)
+	if errors.Is(err, ErrNotFound) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *PostgresMFARepository) Enable(ctx context.Context, userID int, at time.Time, step int64, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE user_mfa SET enabled_at = $2, last_used_step = $3 WHERE user_id = $1 AND enabled_at IS NULL",
+		userID, at.UTC(), step)
+	if err := checkAffected(res, err); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash); err != nil {
+			return mapPostgresError(err)
+		}
+	}
+	return nil
+}
+
+func (r *PostgresMFARepository) UseStep(ctx context.Context, userID int, step int64) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2",
+		userID, step)
+	return checkAffected(res, err)
+}
+
+func (r *PostgresMFARepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string, at time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE mfa_recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+		userID, codeHash, at.UTC())
+	if err := checkAffected(res, err); err != nil {
+		return 0, err
+	}
+	var remaining int
+	err = r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID,
+	).Scan(&remaining)
+	return remaining, err
+}
+
+func (r *PostgresMFARepository) RemainingRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var remaining int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID,
+	).Scan(&remaining)
+	return remaining, err
+}
+
+func (r *PostgresMFARepository) Delete(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id = $1", userID)
+	if err := checkAffected(res, err); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
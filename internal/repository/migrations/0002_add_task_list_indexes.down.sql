@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tasks_status_id_idx;
+DROP INDEX IF EXISTS tasks_updated_at_id_idx;
+DROP INDEX IF EXISTS tasks_created_at_id_idx;
+DROP INDEX IF EXISTS tasks_user_id_id_idx;
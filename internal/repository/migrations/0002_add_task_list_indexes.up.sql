@@ -0,0 +1,5 @@
+-- index untuk pagination keyset dan filter di ListTasks
+CREATE INDEX IF NOT EXISTS tasks_user_id_id_idx ON tasks (user_id, id);
+CREATE INDEX IF NOT EXISTS tasks_created_at_id_idx ON tasks (created_at, id);
+CREATE INDEX IF NOT EXISTS tasks_updated_at_id_idx ON tasks (updated_at, id);
+CREATE INDEX IF NOT EXISTS tasks_status_id_idx ON tasks (status, id);
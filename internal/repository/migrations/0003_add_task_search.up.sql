@@ -0,0 +1,10 @@
+-- kolom full-text search yang selalu diperbarui oleh Postgres setiap kali
+-- title atau description berubah. Konfigurasi 'simple' dipakai karena isi
+-- task bisa berbahasa Indonesia maupun Inggris (tanpa stemming).
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS tasks_search_vector_idx ON tasks USING GIN (search_vector);
@@ -0,0 +1,3 @@
+-- waktu berakhirnya lockout akun setelah terlalu banyak percobaan login
+-- yang gagal; NULL berarti akun tidak dikunci
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
@@ -0,0 +1,4 @@
+-- waktu user membuktikan bahwa email miliknya; NULL berarti belum
+-- diverifikasi. User yang sudah ada sebelum fitur ini dianggap terverifikasi.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    -- secret TOTP terenkripsi dengan keyring (ENCRYPTION_KEYS)
+    secret TEXT NOT NULL,
+    -- NULL selama pendaftaran belum dikonfirmasi
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- hash SHA-256 (hex) dari recovery code
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    -- role bawaan tidak bisa dihapus
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(100) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('tasks:create', 'Create tasks'),
+    ('tasks:read:own', 'Read own tasks'),
+    ('tasks:read:any', 'Read tasks of every user'),
+    ('tasks:update:own', 'Update own tasks'),
+    ('tasks:update:any', 'Update tasks of every user'),
+    ('tasks:delete:own', 'Delete own tasks'),
+    ('tasks:delete:any', 'Delete tasks of every user'),
+    ('users:read:own', 'Read own profile'),
+    ('users:read:any', 'List and read every user'),
+    ('users:update:own', 'Update own profile'),
+    ('users:update:any', 'Update every user'),
+    ('users:delete:own', 'Delete own account'),
+    ('users:delete:any', 'Delete every user'),
+    ('users:unlock', 'Unlock accounts locked after failed logins'),
+    ('roles:manage', 'Manage roles and assign them to users')
+ON CONFLICT (name) DO NOTHING;
+
+-- role bawaan sesuai perilaku admin/member sebelum RBAC
+INSERT INTO roles (name, description, built_in) VALUES
+    ('admin', 'Full access to all users and tasks', TRUE),
+    ('member', 'Access to own profile and own tasks', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.name FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.name FROM roles r JOIN permissions p ON p.name IN (
+    'tasks:create', 'tasks:read:own', 'tasks:update:own', 'tasks:delete:own',
+    'users:read:own', 'users:update:own', 'users:delete:own'
+) WHERE r.name = 'member'
+ON CONFLICT DO NOTHING;
+
+-- role user yang tidak dikenal dijadikan member agar foreign key bisa dibuat
+UPDATE users SET role = 'member' WHERE role NOT IN (SELECT name FROM roles);
+
+ALTER TABLE users
+    ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles (name) ON UPDATE CASCADE;
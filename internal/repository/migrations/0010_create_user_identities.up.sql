@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- nama provider di OIDC_PROVIDERS_FILE
+    provider VARCHAR(50) NOT NULL,
+    -- claim sub dari ID token
+    subject VARCHAR(255) NOT NULL,
+    -- email dari ID token saat identity ditautkan
+    email VARCHAR(255) NOT NULL,
+    last_login_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id);
@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission = 'users:logout';
+DELETE FROM permissions WHERE name = 'users:logout';
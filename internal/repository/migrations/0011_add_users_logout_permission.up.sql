@@ -0,0 +1,8 @@
+-- permission untuk memaksa user logout dari semua sesi, diberikan ke admin
+INSERT INTO permissions (name, description) VALUES
+    ('users:logout', 'Revoke sessions of every user (force logout)')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'users:logout' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
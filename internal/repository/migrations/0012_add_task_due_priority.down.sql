@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_overdue_idx;
+DROP INDEX IF EXISTS tasks_priority_id_idx;
+DROP INDEX IF EXISTS tasks_due_at_id_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS completed_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_at;
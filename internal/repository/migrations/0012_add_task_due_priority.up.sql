@@ -0,0 +1,15 @@
+-- tenggat, prioritas, dan waktu selesai task. completed_at diisi oleh
+-- aplikasi saat status berubah menjadi completed.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority VARCHAR(10) NOT NULL DEFAULT 'medium'
+    CHECK (priority IN ('low', 'medium', 'high', 'urgent'));
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+-- task yang sudah completed sebelum fitur ini dianggap selesai saat terakhir diubah
+UPDATE tasks SET completed_at = updated_at WHERE status = 'completed' AND completed_at IS NULL;
+
+-- sort=due_at menaruh task tanpa tenggat di akhir, sama dengan ekspresi di ListTasks
+CREATE INDEX IF NOT EXISTS tasks_due_at_id_idx ON tasks ((COALESCE(due_at, 'infinity'::timestamp)), id);
+CREATE INDEX IF NOT EXISTS tasks_priority_id_idx ON tasks (priority, id);
+-- index parsial untuk /tasks/overdue
+CREATE INDEX IF NOT EXISTS tasks_overdue_idx ON tasks (due_at, id) WHERE status <> 'completed' AND due_at IS NOT NULL;
@@ -0,0 +1,29 @@
+-- riwayat perubahan task. Tidak memakai foreign key ke tasks atau users
+-- agar riwayat tetap ada setelah task atau user dihapus.
+CREATE TABLE IF NOT EXISTS task_history (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    version INT NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'revert')),
+    actor_id INT NOT NULL,
+    -- versi yang dipulihkan untuk action revert
+    reverted_to INT,
+    -- {"field": {"from": ..., "to": ...}}, security code selalu disamarkan
+    changes JSONB NOT NULL,
+    -- isi task setelah perubahan (sebelum dihapus untuk delete), tanpa security code
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (task_id, version)
+);
+
+-- riwayat hanya boleh ditambah, tidak boleh diubah atau dihapus
+CREATE OR REPLACE FUNCTION task_history_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'task_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_history_append_only ON task_history;
+CREATE TRIGGER task_history_append_only
+    BEFORE UPDATE OR DELETE ON task_history
+    FOR EACH ROW EXECUTE FUNCTION task_history_append_only();
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- terisi jika komentar pernah diubah
+    edited_at TIMESTAMP,
+    -- soft delete: komentar yang dihapus tetap disimpan tetapi tidak ditampilkan
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS task_comments_task_id_id_idx ON task_comments (task_id, id);
+
+-- user yang disebut dengan @username di komentar
+CREATE TABLE IF NOT EXISTS task_comment_mentions (
+    comment_id INT NOT NULL REFERENCES task_comments (id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_comment_mentions_user_id_idx ON task_comment_mentions (user_id);
@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission = 'labels:manage';
+DELETE FROM permissions WHERE name = 'labels:manage';
+DROP TABLE IF EXISTS task_labels;
+DROP TABLE IF EXISTS labels;
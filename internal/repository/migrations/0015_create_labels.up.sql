@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS labels (
+    id SERIAL PRIMARY KEY,
+    -- NULL berarti label bersama yang bisa dipakai semua user
+    user_id INT REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color CHAR(7) NOT NULL CHECK (color ~ '^#[0-9a-f]{6}$'),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- nama label unik tanpa membedakan huruf besar/kecil per pemilik, dan di
+-- antara sesama label bersama
+CREATE UNIQUE INDEX IF NOT EXISTS labels_user_id_name_idx ON labels (user_id, LOWER(name)) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS labels_shared_name_idx ON labels (LOWER(name)) WHERE user_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS task_labels (
+    task_id INT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    label_id INT NOT NULL REFERENCES labels (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+-- dipakai filter label= di ListTasks
+CREATE INDEX IF NOT EXISTS task_labels_label_id_idx ON task_labels (label_id, task_id);
+
+-- permission untuk mengelola label bersama, diberikan ke admin
+INSERT INTO permissions (name, description) VALUES
+    ('labels:manage', 'Manage shared labels')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'labels:manage' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission = 'email:verification:bypass';
+DELETE FROM permissions WHERE name = 'email:verification:bypass';
@@ -0,0 +1,9 @@
+-- permission yang membebaskan user dari REQUIRE_EMAIL_VERIFICATION,
+-- diberikan ke admin seperti perilaku sebelumnya
+INSERT INTO permissions (name, description) VALUES
+    ('email:verification:bypass', 'Use the API without a verified email address')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'email:verification:bypass' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
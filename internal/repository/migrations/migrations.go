@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS berisi semua file migrasi SQL yang ikut di-embed ke dalam binary.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryPasswordResetRepository adalah implementasi PasswordResetRepository di memori.
+type MemoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	nextID int
+	tokens map[string]models.PasswordResetToken // key: token hash
+}
+
+// NewMemoryPasswordResetRepository membuat MemoryPasswordResetRepository kosong.
+func NewMemoryPasswordResetRepository() *MemoryPasswordResetRepository {
+	return &MemoryPasswordResetRepository{nextID: 1, tokens: map[string]models.PasswordResetToken{}}
+}
+
+func (r *MemoryPasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[token.TokenHash]; ok {
+		return ErrDuplicate
+	}
+	token.ID = r.nextID
+	token.CreatedAt = time.Now()
+	r.nextID++
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *MemoryPasswordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, ErrNotFound
+	}
+	token.UsedAt = &now
+	r.tokens[tokenHash] = token
+	return &token, nil
+}
+
+func (r *MemoryPasswordResetRepository) DeleteByUser(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.UserID == userID {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresPasswordResetRepository adalah implementasi PasswordResetRepository dengan Postgres.
+type PostgresPasswordResetRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresPasswordResetRepository membuat PostgresPasswordResetRepository baru.
+func NewPostgresPasswordResetRepository(db *sql.DB) *PostgresPasswordResetRepository {
+	return &PostgresPasswordResetRepository{db: db}
+}
+
+func (r *PostgresPasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id, created_at",
+		token.UserID, token.TokenHash, token.ExpiresAt.UTC(),
+	).Scan(&token.ID, &token.CreatedAt)
+	return mapPostgresError(err)
+}
+
+func (r *PostgresPasswordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	// satu UPDATE sekaligus mengecek dan menandai token, sehingga dua
+	// request dengan token yang sama tidak bisa sama-sama berhasil
+	var token models.PasswordResetToken
+	err := r.db.QueryRowContext(ctx, `
+        UPDATE password_reset_tokens
+        SET used_at = $2
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+        RETURNING id, user_id, token_hash, expires_at, used_at, created_at`,
+		tokenHash, now.UTC(),
+	).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return &token, nil
+}
+
+func (r *PostgresPasswordResetRepository) DeleteByUser(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id = $1", userID)
+	return mapPostgresError(err)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryPersonalAccessTokenRepository adalah implementasi
+// PersonalAccessTokenRepository di memori.
+type MemoryPersonalAccessTokenRepository struct {
+	mu     sync.Mutex
+	nextID int
+	tokens map[int]models.PersonalAccessToken
+}
+
+// NewMemoryPersonalAccessTokenRepository membuat MemoryPersonalAccessTokenRepository kosong.
+func NewMemoryPersonalAccessTokenRepository() *MemoryPersonalAccessTokenRepository {
+	return &MemoryPersonalAccessTokenRepository{nextID: 1, tokens: map[int]models.PersonalAccessToken{}}
+}
+
+// copyAccessToken menyalin slice scopes agar data di map tidak ikut berubah
+func copyAccessToken(token models.PersonalAccessToken) models.PersonalAccessToken {
+	token.Scopes = append([]string(nil), token.Scopes...)
+	return token
+}
+
+func (r *MemoryPersonalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.tokens {
+		if existing.TokenHash == token.TokenHash {
+			return ErrDuplicate
+		}
+	}
+	token.ID = r.nextID
+	token.CreatedAt = time.Now()
+	r.nextID++
+	r.tokens[token.ID] = copyAccessToken(*token)
+	return nil
+}
+
+func (r *MemoryPersonalAccessTokenRepository) ListByUser(ctx context.Context, userID int) ([]models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens := []models.PersonalAccessToken{}
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, copyAccessToken(token))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID > tokens[j].ID })
+	return tokens, nil
+}
+
+func (r *MemoryPersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			token = copyAccessToken(token)
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil
+	}
+	token.LastUsedAt = &at
+	r.tokens[id] = token
+	return nil
+}
+
+func (r *MemoryPersonalAccessTokenRepository) Delete(ctx context.Context, userID, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.tokens, id)
+	return nil
+}
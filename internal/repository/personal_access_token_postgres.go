@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgresPersonalAccessTokenRepository adalah implementasi
+// PersonalAccessTokenRepository dengan Postgres.
+type PostgresPersonalAccessTokenRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresPersonalAccessTokenRepository membuat PostgresPersonalAccessTokenRepository baru.
+func NewPostgresPersonalAccessTokenRepository(db *sql.DB) *PostgresPersonalAccessTokenRepository {
+	return &PostgresPersonalAccessTokenRepository{db: db}
+}
+
+const accessTokenColumns = "id, user_id, name, token_hash, scopes, last_used_at, expires_at, created_at"
+
+func scanAccessToken(row interface{ Scan(...interface{}) error }) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, pq.Array(&token.Scopes),
+		&token.LastUsedAt, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PostgresPersonalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	var expiresAt *time.Time
+	if token.ExpiresAt != nil {
+		t := token.ExpiresAt.UTC()
+		expiresAt = &t
+	}
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		token.UserID, token.Name, token.TokenHash, pq.Array(token.Scopes), expiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	return mapPostgresError(err)
+}
+
+func (r *PostgresPersonalAccessTokenRepository) ListByUser(ctx context.Context, userID int) ([]models.PersonalAccessToken, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+accessTokenColumns+" FROM personal_access_tokens WHERE user_id = $1 ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *PostgresPersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	token, err := scanAccessToken(r.db.QueryRowContext(ctx,
+		"SELECT "+accessTokenColumns+" FROM personal_access_tokens WHERE token_hash = $1", tokenHash))
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return token, nil
+}
+
+func (r *PostgresPersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE personal_access_tokens SET last_used_at = $2 WHERE id = $1", id, at.UTC())
+	return mapPostgresError(err)
+}
+
+func (r *PostgresPersonalAccessTokenRepository) Delete(ctx context.Context, userID, id int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return mapPostgresError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// mapPostgresError menerjemahkan error dari driver Postgres
+// menjadi error repository yang tidak tergantung database
+func mapPostgresError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			return ErrDuplicate
+		case "23503": // foreign_key_violation
+			return ErrConflict
+		}
+	}
+	return err
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNotFound dikembalikan jika data yang dicari tidak ada
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate dikembalikan jika data melanggar unique constraint
+	ErrDuplicate = errors.New("record already exists")
+	// ErrConflict dikembalikan jika data masih direferensikan data lain
+	ErrConflict = errors.New("record is still referenced")
+	// ErrStatusChanged dikembalikan jika status task sudah diubah request
+	// lain sejak dibaca (lihat TaskUpdate.ExpectedStatus)
+	ErrStatusChanged = errors.New("task status has changed")
+)
+
+// UserUpdate berisi field user yang ingin diubah.
+// Field nil atau string kosong berarti tidak diubah.
+type UserUpdate struct {
+	Username *string
+	Email    *string
+	// Password berisi hash password, bukan password asli
+	Password *string
+}
+
+// UserRepository adalah operasi penyimpanan data user.
+type UserRepository interface {
+	// Create menyimpan user baru dan mengisi ID, CreatedAt, dan UpdatedAt
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	// GetByUsername juga mengisi field Password (hash) untuk keperluan login
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetByEmail mencari user berdasarkan email tanpa membedakan huruf besar/kecil
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	Update(ctx context.Context, id int, update UserUpdate) (*models.User, error)
+	UpdateProfilePicture(ctx context.Context, id int, url string) error
+	// SetLockedUntil mengunci akun sampai until, atau membuka kunci jika nil.
+	// updated_at tidak diubah karena data profil user tidak berubah.
+	SetLockedUntil(ctx context.Context, id int, until *time.Time) error
+	// MarkEmailVerified menandai email user terverifikasi pada waktu at.
+	// ErrNotFound dikembalikan jika user tidak ada atau email-nya sudah
+	// berubah. Update dengan email baru mengosongkan email_verified_at.
+	MarkEmailVerified(ctx context.Context, id int, email string, at time.Time) error
+	// SetRole mengganti role user. Role harus sudah ada di RoleRepository.
+	SetRole(ctx context.Context, id int, role string) error
+	Delete(ctx context.Context, id int) error
+}
+
+// TaskUpdate berisi field task yang ingin diubah.
+// Field nil atau string kosong berarti tidak diubah, kecuali Description
+// dengan SetDescription.
+// completed_at ikut diperbarui jika Status mengubah status task.
+type TaskUpdate struct {
+	Title       *string
+	Description *string
+	Status      *string
+	Priority    *string
+	// SecurityCode berisi security code yang sudah dienkripsi
+	SecurityCode *string
+	DueAt        *time.Time
+	// ClearDueAt menghapus tenggat task, DueAt diabaikan
+	ClearDueAt bool
+	// SetDescription menyimpan Description apa adanya, termasuk string
+	// kosong. Dipakai revert untuk memulihkan deskripsi yang kosong.
+	SetDescription bool
+	// ExpectedStatus, jika diisi, membuat update gagal dengan
+	// ErrStatusChanged jika status task saat ini berbeda. Dipakai agar
+	// pemeriksaan workflow tidak memakai status yang sudah basi.
+	ExpectedStatus string
+	// ActorID adalah user yang mengubah task, dicatat di riwayat
+	ActorID int
+	// RevertTo diisi dengan versi riwayat yang dipulihkan, sehingga
+	// riwayat mencatat aksi revert
+	RevertTo int
+}
+
+// TaskRepository adalah operasi penyimpanan data task.
+// SecurityCode disimpan apa adanya (terenkripsi), enkripsi dilakukan di handler.
+// Create, Update, dan Delete menulis riwayat task dalam transaksi yang sama.
+type TaskRepository interface {
+	// Create menyimpan task baru dan mengisi ID, CreatedAt, dan UpdatedAt.
+	// Pemilik task (UserID) dicatat sebagai pembuatnya di riwayat.
+	Create(ctx context.Context, task *models.Task) error
+	GetByID(ctx context.Context, id int) (*models.Task, error)
+	// List mengembalikan satu halaman task sesuai filter, urutan, dan cursor
+	List(ctx context.Context, query TaskQuery) (*TaskPage, error)
+	// Search mencari task berdasarkan title dan description, diurutkan dari
+	// ranking tertinggi
+	Search(ctx context.Context, search TaskSearch) ([]TaskSearchResult, error)
+	// Update tidak menulis riwayat jika tidak ada field yang berubah
+	Update(ctx context.Context, id int, update TaskUpdate) (*models.Task, error)
+	// Delete menghapus task, riwayatnya tetap disimpan
+	Delete(ctx context.Context, id int, actorID int) error
+	// History mengembalikan riwayat task urut dari versi pertama, juga
+	// untuk task yang sudah dihapus
+	History(ctx context.Context, taskID int) ([]models.TaskHistory, error)
+	// HistoryVersion mengembalikan satu versi riwayat task
+	HistoryVersion(ctx context.Context, taskID, version int) (*models.TaskHistory, error)
+	// ReencryptSecurityCodes memproses paling banyak limit task dengan
+	// ID > afterID (urut ID) dan menyimpan security code hasil rotate.
+	// updated_at tidak diubah dan riwayat tidak ditulis karena isi task tidak berubah.
+	ReencryptSecurityCodes(ctx context.Context, afterID, limit int, rotate RotateFunc) (ReencryptBatch, error)
+}
+
+// PasswordResetRepository adalah operasi penyimpanan token reset password.
+type PasswordResetRepository interface {
+	// Create menyimpan token baru dan mengisi ID dan CreatedAt
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	// Consume menandai token sebagai sudah dipakai dan mengembalikannya.
+	// ErrNotFound dikembalikan jika token tidak ada, sudah dipakai, atau
+	// sudah kadaluarsa pada waktu now.
+	Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error)
+	// DeleteByUser menghapus semua token milik user
+	DeleteByUser(ctx context.Context, userID int) error
+}
+
+// MFARepository adalah operasi penyimpanan TOTP dan recovery code user.
+// Secret disimpan apa adanya (terenkripsi), enkripsi dilakukan di service.
+type MFARepository interface {
+	Get(ctx context.Context, userID int) (*models.UserMFA, error)
+	// SavePending menyimpan secret baru yang belum dikonfirmasi, menimpa
+	// pendaftaran sebelumnya yang juga belum dikonfirmasi. ErrDuplicate
+	// dikembalikan jika TOTP user sudah aktif.
+	SavePending(ctx context.Context, userID int, secret string) error
+	// Enable mengaktifkan TOTP yang masih pending dan mengganti semua
+	// recovery code dengan codeHashes. step adalah periode kode konfirmasi.
+	Enable(ctx context.Context, userID int, at time.Time, step int64, codeHashes []string) error
+	// UseStep mencatat periode TOTP yang dipakai login. ErrNotFound
+	// dikembalikan jika periode tersebut (atau yang lebih baru) sudah dipakai.
+	UseStep(ctx context.Context, userID int, step int64) error
+	// UseRecoveryCode menandai recovery code sebagai terpakai dan
+	// mengembalikan jumlah recovery code yang tersisa. ErrNotFound
+	// dikembalikan jika code tidak ada atau sudah dipakai.
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string, at time.Time) (int, error)
+	RemainingRecoveryCodes(ctx context.Context, userID int) (int, error)
+	// Delete menghapus TOTP beserta semua recovery code user
+	Delete(ctx context.Context, userID int) error
+}
+
+// PersonalAccessTokenRepository adalah operasi penyimpanan personal access token.
+type PersonalAccessTokenRepository interface {
+	// Create menyimpan token baru dan mengisi ID dan CreatedAt
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	// ListByUser mengembalikan token milik user, terbaru lebih dulu
+	ListByUser(ctx context.Context, userID int) ([]models.PersonalAccessToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	// TouchLastUsed mengisi last_used_at
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+	// Delete menghapus token id milik userID. ErrNotFound dikembalikan jika
+	// token tidak ada atau milik user lain.
+	Delete(ctx context.Context, userID, id int) error
+}
+
+// RoleRepository adalah operasi penyimpanan role dan permission-nya.
+type RoleRepository interface {
+	// List mengembalikan semua role beserta permission-nya
+	List(ctx context.Context) ([]models.Role, error)
+	Get(ctx context.Context, name string) (*models.Role, error)
+	// Create menyimpan role baru dan mengisi ID dan CreatedAt. ErrConflict
+	// dikembalikan jika ada permission yang tidak dikenal.
+	Create(ctx context.Context, role *models.Role) error
+	// Update mengubah deskripsi dan/atau mengganti semua permission role.
+	// Parameter nil berarti tidak diubah.
+	Update(ctx context.Context, name string, description *string, permissions []string) (*models.Role, error)
+	// Delete menghapus role. ErrConflict dikembalikan jika role masih
+	// dipakai oleh user.
+	Delete(ctx context.Context, name string) error
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+}
+
+// UserIdentityRepository adalah operasi penyimpanan identity OpenID Connect
+// yang tertaut ke user.
+type UserIdentityRepository interface {
+	// Get mencari identity berdasarkan provider dan subject (claim sub).
+	// ErrNotFound dikembalikan jika belum ada user yang tertaut.
+	Get(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+	// Create menyimpan identity baru dan mengisi ID dan CreatedAt.
+	// ErrDuplicate dikembalikan jika provider dan subject sudah tertaut.
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	// TouchLastLogin mengisi last_login_at
+	TouchLastLogin(ctx context.Context, id int, at time.Time) error
+}
+
+// TaskCommentRepository adalah operasi penyimpanan komentar task.
+// Komentar yang sudah dihapus dianggap tidak ada (ErrNotFound).
+type TaskCommentRepository interface {
+	// Create menyimpan komentar beserta mention-nya dan mengisi ID dan CreatedAt
+	Create(ctx context.Context, comment *models.TaskComment) error
+	GetByID(ctx context.Context, id int) (*models.TaskComment, error)
+	// ListByTask mengembalikan komentar task, yang paling lama lebih dulu
+	ListByTask(ctx context.Context, taskID int) ([]models.TaskComment, error)
+	// Update mengganti isi dan mention komentar dan mengisi edited_at
+	Update(ctx context.Context, id int, body string, mentions []int) (*models.TaskComment, error)
+	// Delete menandai komentar terhapus (soft delete)
+	Delete(ctx context.Context, id int) error
+}
+
+// LabelUpdate berisi field label yang ingin diubah. Field nil berarti
+// tidak diubah.
+type LabelUpdate struct {
+	Name  *string
+	Color *string
+}
+
+// LabelRepository adalah operasi penyimpanan label dan pemasangannya di
+// task. Nama label unik tanpa membedakan huruf besar/kecil di antara label
+// milik user yang sama, dan di antara sesama label bersama (ErrDuplicate).
+type LabelRepository interface {
+	// Create menyimpan label baru dan mengisi ID dan CreatedAt
+	Create(ctx context.Context, label *models.Label) error
+	GetByID(ctx context.Context, id int) (*models.Label, error)
+	// ListForUser mengembalikan label milik user dan semua label bersama,
+	// urut nama
+	ListForUser(ctx context.Context, userID int) ([]models.Label, error)
+	Update(ctx context.Context, id int, update LabelUpdate) (*models.Label, error)
+	// Delete menghapus label dan melepasnya dari semua task
+	Delete(ctx context.Context, id int) error
+	// Attach memasang label ke task. Label yang sudah terpasang tidak
+	// dianggap error, ErrConflict dikembalikan jika task atau label tidak ada.
+	Attach(ctx context.Context, taskID, labelID int) error
+	// Detach melepas label dari task. ErrNotFound dikembalikan jika label
+	// tidak terpasang.
+	Detach(ctx context.Context, taskID, labelID int) error
+	// ForTasks mengembalikan label yang terpasang di setiap task, urut nama.
+	// Task tanpa label tidak ada di map.
+	ForTasks(ctx context.Context, taskIDs []int) (map[int][]models.Label, error)
+}
+
+// nonEmpty mengembalikan nilai string pointer, atau "" jika nil
+func nonEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// uniqueInts menyalin ID tanpa duplikat dan mengurutkannya
+func uniqueInts(ids []int) []int {
+	seen := map[int]bool{}
+	result := []int{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// Repositories mengelompokkan semua repository yang dipakai aplikasi,
+// sehingga implementasi Postgres dan in-memory bisa ditukar sekaligus.
+type Repositories struct {
+	Users                UserRepository
+	Tasks                TaskRepository
+	PasswordResetTokens  PasswordResetRepository
+	UserMFA              MFARepository
+	PersonalAccessTokens PersonalAccessTokenRepository
+	Roles                RoleRepository
+	UserIdentities       UserIdentityRepository
+	TaskComments         TaskCommentRepository
+	Labels               LabelRepository
+}
+
+// NewPostgresRepositories membuat semua repository dengan implementasi Postgres.
+func NewPostgresRepositories(db *sql.DB) Repositories {
+	return Repositories{
+		Users:                NewPostgresUserRepository(db),
+		Tasks:                NewPostgresTaskRepository(db),
+		PasswordResetTokens:  NewPostgresPasswordResetRepository(db),
+		UserMFA:              NewPostgresMFARepository(db),
+		PersonalAccessTokens: NewPostgresPersonalAccessTokenRepository(db),
+		Roles:                NewPostgresRoleRepository(db),
+		UserIdentities:       NewPostgresUserIdentityRepository(db),
+		TaskComments:         NewPostgresTaskCommentRepository(db),
+		Labels:               NewPostgresLabelRepository(db),
+	}
+}
+
+// NewMemoryRepositories membuat semua repository dengan implementasi in-memory.
+// Role bawaan (admin dan member) langsung tersedia seperti setelah migrasi.
+func NewMemoryRepositories() Repositories {
+	users := NewMemoryUserRepository()
+	tasks := NewMemoryTaskRepository()
+	return Repositories{
+		Users:                users,
+		Tasks:                tasks,
+		PasswordResetTokens:  NewMemoryPasswordResetRepository(),
+		UserMFA:              NewMemoryMFARepository(),
+		PersonalAccessTokens: NewMemoryPersonalAccessTokenRepository(),
+		Roles:                NewMemoryRoleRepository(users),
+		UserIdentities:       NewMemoryUserIdentityRepository(),
+		TaskComments:         NewMemoryTaskCommentRepository(),
+		Labels:               NewMemoryLabelRepository(tasks),
+	}
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRoleRepository adalah implementasi RoleRepository di memori. Role
+// bawaan dan semua permission di models.Permissions langsung tersedia.
+type MemoryRoleRepository struct {
+	mu          sync.Mutex
+	nextID      int
+	roles       map[string]models.Role
+	permissions map[string]models.Permission
+	// users dipakai untuk menolak penghapusan role yang masih dipakai
+	users *MemoryUserRepository
+}
+
+// NewMemoryRoleRepository membuat MemoryRoleRepository berisi role bawaan.
+func NewMemoryRoleRepository(users *MemoryUserRepository) *MemoryRoleRepository {
+	r := &MemoryRoleRepository{
+		nextID:      1,
+		roles:       map[string]models.Role{},
+		permissions: map[string]models.Permission{},
+		users:       users,
+	}
+	for _, p := range models.Permissions {
+		r.permissions[p.Name] = p
+	}
+	for _, role := range models.DefaultRoles() {
+		r.Create(context.Background(), &role)
+	}
+	return r
+}
+
+// normalizePermissions menghapus duplikat dan mengurutkan permissions
+// seperti array_agg di Postgres. ErrConflict dikembalikan jika ada
+// permission yang tidak dikenal.
+func (r *MemoryRoleRepository) normalizePermissions(permissions []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, p := range permissions {
+		if _, ok := r.permissions[p]; !ok {
+			return nil, ErrConflict
+		}
+		if !seen[p] {
+			seen[p] = true
+			normalized = append(normalized, p)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func copyRole(role models.Role) models.Role {
+	role.Permissions = append([]string{}, role.Permissions...)
+	return role
+}
+
+func (r *MemoryRoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	roles := make([]models.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, copyRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].ID < roles[j].ID })
+	return roles, nil
+}
+
+func (r *MemoryRoleRepository) Get(ctx context.Context, name string) (*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	role = copyRole(role)
+	return &role, nil
+}
+
+func (r *MemoryRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[role.Name]; ok {
+		return ErrDuplicate
+	}
+	permissions, err := r.normalizePermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	role.ID = r.nextID
+	role.CreatedAt = time.Now()
+	role.Permissions = permissions
+	r.nextID++
+	r.roles[role.Name] = copyRole(*role)
+	return nil
+}
+
+func (r *MemoryRoleRepository) Update(ctx context.Context, name string, description *string, permissions []string) (*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if permissions != nil {
+		normalized, err := r.normalizePermissions(permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = normalized
+	}
+	if description != nil {
+		role.Description = *description
+	}
+	r.roles[name] = role
+	role = copyRole(role)
+	return &role, nil
+}
+
+func (r *MemoryRoleRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[name]; !ok {
+		return ErrNotFound
+	}
+	if r.users.hasRole(name) {
+		return ErrConflict
+	}
+	delete(r.roles, name)
+	return nil
+}
+
+func (r *MemoryRoleRepository) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	permissions := make([]models.Permission, 0, len(r.permissions))
+	for _, p := range r.permissions {
+		permissions = append(permissions, p)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i].Name < permissions[j].Name })
+	return permissions, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// PostgresRoleRepository adalah implementasi RoleRepository dengan Postgres.
+type PostgresRoleRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRoleRepository membuat PostgresRoleRepository baru.
+func NewPostgresRoleRepository(db *sql.DB) *PostgresRoleRepository {
+	return &PostgresRoleRepository{db: db}
+}
+
+// roleSelect mengambil role beserta permission-nya dalam satu query
+const roleSelect = `
+        SELECT r.id, r.name, r.description, r.built_in, r.created_at,
+               COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+        FROM roles r
+        LEFT JOIN role_permissions rp ON rp.role_id = r.id`
+
+func scanRole(row rowScanner) (*models.Role, error) {
+	var role models.Role
+	err := row.Scan(&role.ID, &role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, pq.Array(&role.Permissions))
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *PostgresRoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	rows, err := r.db.QueryContext(ctx, roleSelect+" GROUP BY r.id ORDER BY r.id")
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *PostgresRoleRepository) Get(ctx context.Context, name string) (*models.Role, error) {
+	role, err := scanRole(r.db.QueryRowContext(ctx, roleSelect+" WHERE r.name = $1 GROUP BY r.id", name))
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return role, nil
+}
+
+func (r *PostgresRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO roles (name, description) VALUES ($1, $2) RETURNING id, built_in, created_at",
+		role.Name, role.Description,
+	).Scan(&role.ID, &role.BuiltIn, &role.CreatedAt)
+	if err != nil {
+		return mapPostgresError(err)
+	}
+	if err := insertRolePermissions(ctx, tx, role.ID, role.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertRolePermissions menambahkan permissions ke role. Permission yang
+// tidak ada di tabel permissions menghasilkan ErrConflict (foreign key).
+func insertRolePermissions(ctx context.Context, tx *sql.Tx, roleID int, permissions []string) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO role_permissions (role_id, permission) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING",
+		roleID, pq.Array(permissions))
+	return mapPostgresError(err)
+}
+
+func (r *PostgresRoleRepository) Update(ctx context.Context, name string, description *string, permissions []string) (*models.Role, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var roleID int
+	err = tx.QueryRowContext(ctx,
+		"UPDATE roles SET description = COALESCE($2, description) WHERE name = $1 RETURNING id",
+		name, description,
+	).Scan(&roleID)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	if permissions != nil {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id = $1", roleID); err != nil {
+			return nil, err
+		}
+		if err := insertRolePermissions(ctx, tx, roleID, permissions); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, name)
+}
+
+func (r *PostgresRoleRepository) Delete(ctx context.Context, name string) error {
+	// foreign key users.role menolak penghapusan role yang masih dipakai
+	res, err := r.db.ExecContext(ctx, "DELETE FROM roles WHERE name = $1", name)
+	return checkAffected(res, err)
+}
+
+func (r *PostgresRoleRepository) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT name, description FROM permissions ORDER BY name")
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+	for rows.Next() {
+		var p models.Permission
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryTaskCommentRepository adalah implementasi TaskCommentRepository di memori.
+type MemoryTaskCommentRepository struct {
+	mu       sync.RWMutex
+	nextID   int
+	comments map[int]models.TaskComment
+}
+
+// NewMemoryTaskCommentRepository membuat MemoryTaskCommentRepository kosong.
+func NewMemoryTaskCommentRepository() *MemoryTaskCommentRepository {
+	return &MemoryTaskCommentRepository{nextID: 1, comments: map[int]models.TaskComment{}}
+}
+
+func (r *MemoryTaskCommentRepository) Create(ctx context.Context, comment *models.TaskComment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment.ID = r.nextID
+	comment.CreatedAt = time.Now()
+	comment.Mentions = uniqueInts(comment.Mentions)
+	r.nextID++
+	r.comments[comment.ID] = *comment
+	return nil
+}
+
+func (r *MemoryTaskCommentRepository) GetByID(ctx context.Context, id int) (*models.TaskComment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[id]
+	if !ok || comment.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &comment, nil
+}
+
+func (r *MemoryTaskCommentRepository) ListByTask(ctx context.Context, taskID int) ([]models.TaskComment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := []models.TaskComment{}
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID && comment.DeletedAt == nil {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return comments, nil
+}
+
+func (r *MemoryTaskCommentRepository) Update(ctx context.Context, id int, body string, mentions []int) (*models.TaskComment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok || comment.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	comment.Body = body
+	comment.Mentions = uniqueInts(mentions)
+	comment.EditedAt = &now
+	r.comments[id] = comment
+	return &comment, nil
+}
+
+func (r *MemoryTaskCommentRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok || comment.DeletedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now()
+	comment.DeletedAt = &now
+	r.comments[id] = comment
+	return nil
+}
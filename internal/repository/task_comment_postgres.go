@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// PostgresTaskCommentRepository adalah implementasi TaskCommentRepository
+// dengan Postgres.
+type PostgresTaskCommentRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresTaskCommentRepository membuat PostgresTaskCommentRepository baru.
+func NewPostgresTaskCommentRepository(db *sql.DB) *PostgresTaskCommentRepository {
+	return &PostgresTaskCommentRepository{db: db}
+}
+
+const taskCommentColumns = `id, task_id, user_id, body, created_at, edited_at, deleted_at,
+	ARRAY(SELECT user_id FROM task_comment_mentions m WHERE m.comment_id = task_comments.id ORDER BY user_id)`
+
+func scanTaskComment(row rowScanner) (*models.TaskComment, error) {
+	var comment models.TaskComment
+	var mentions pq.Int64Array
+	err := row.Scan(&comment.ID, &comment.TaskID, &comment.UserID, &comment.Body, &comment.CreatedAt, &comment.EditedAt, &comment.DeletedAt, &mentions)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	comment.Mentions = make([]int, len(mentions))
+	for i, id := range mentions {
+		comment.Mentions[i] = int(id)
+	}
+	return &comment, nil
+}
+
+// replaceMentions mengganti semua mention komentar
+func replaceMentions(ctx context.Context, tx *sql.Tx, commentID int, mentions []int) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_comment_mentions WHERE comment_id = $1", commentID); err != nil {
+		return err
+	}
+	for _, userID := range mentions {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO task_comment_mentions (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			commentID, userID); err != nil {
+			return mapPostgresError(err)
+		}
+	}
+	return nil
+}
+
+func (r *PostgresTaskCommentRepository) Create(ctx context.Context, comment *models.TaskComment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO task_comments (task_id, user_id, body) VALUES ($1, $2, $3) RETURNING id, created_at",
+		comment.TaskID, comment.UserID, comment.Body,
+	).Scan(&comment.ID, &comment.CreatedAt)
+	if err != nil {
+		return mapPostgresError(err)
+	}
+	if err := replaceMentions(ctx, tx, comment.ID, comment.Mentions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresTaskCommentRepository) GetByID(ctx context.Context, id int) (*models.TaskComment, error) {
+	return scanTaskComment(r.db.QueryRowContext(ctx,
+		"SELECT "+taskCommentColumns+" FROM task_comments WHERE id = $1 AND deleted_at IS NULL", id))
+}
+
+func (r *PostgresTaskCommentRepository) ListByTask(ctx context.Context, taskID int) ([]models.TaskComment, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+taskCommentColumns+" FROM task_comments WHERE task_id = $1 AND deleted_at IS NULL ORDER BY id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.TaskComment{}
+	for rows.Next() {
+		comment, err := scanTaskComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *PostgresTaskCommentRepository) Update(ctx context.Context, id int, body string, mentions []int) (*models.TaskComment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE task_comments SET body = $2, edited_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id, body)
+	if err := checkAffected(res, err); err != nil {
+		return nil, err
+	}
+	if err := replaceMentions(ctx, tx, id, mentions); err != nil {
+		return nil, err
+	}
+	comment, err := scanTaskComment(tx.QueryRowContext(ctx, "SELECT "+taskCommentColumns+" FROM task_comments WHERE id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *PostgresTaskCommentRepository) Delete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE task_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id)
+	return checkAffected(res, err)
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"time"
+)
+
+// RedactedValue menggantikan security code di riwayat task, sehingga
+// riwayat hanya menunjukkan bahwa security code berubah
+const RedactedValue = "[REDACTED]"
+
+// taskSnapshot mengambil isi task tanpa security code
+func taskSnapshot(task *models.Task) models.TaskSnapshot {
+	return models.TaskSnapshot{
+		UserID:      task.UserID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		DueAt:       task.DueAt,
+		CompletedAt: task.CompletedAt,
+	}
+}
+
+// timeValue mengubah waktu opsional menjadi nilai untuk FieldChange
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// diffTasks membandingkan task sebelum dan sesudah perubahan. before nil
+// berarti task baru dibuat, after nil berarti task dihapus.
+func diffTasks(before, after *models.Task) map[string]models.FieldChange {
+	var b, a models.Task
+	if before != nil {
+		b = *before
+	}
+	if after != nil {
+		a = *after
+	}
+	changes := map[string]models.FieldChange{}
+	text := func(field, from, to string) {
+		if from == to {
+			return
+		}
+		change := models.FieldChange{}
+		if before != nil {
+			change.From = from
+		}
+		if after != nil {
+			change.To = to
+		}
+		changes[field] = change
+	}
+	date := func(field string, from, to *time.Time) {
+		if from == nil && to == nil || from != nil && to != nil && from.Equal(*to) {
+			return
+		}
+		changes[field] = models.FieldChange{From: timeValue(from), To: timeValue(to)}
+	}
+
+	text("title", b.Title, a.Title)
+	text("description", b.Description, a.Description)
+	text("status", b.Status, a.Status)
+	text("priority", b.Priority, a.Priority)
+	date("due_at", b.DueAt, a.DueAt)
+	date("completed_at", b.CompletedAt, a.CompletedAt)
+	if b.SecurityCode != a.SecurityCode {
+		change := models.FieldChange{}
+		if b.SecurityCode != "" {
+			change.From = RedactedValue
+		}
+		if a.SecurityCode != "" {
+			change.To = RedactedValue
+		}
+		changes["security_code"] = change
+	}
+	return changes
+}
+
+// newTaskHistory membuat baris riwayat untuk perubahan before -> after.
+// ID, Version, dan CreatedAt diisi saat disimpan.
+func newTaskHistory(action string, actorID int, before, after *models.Task) *models.TaskHistory {
+	entry := &models.TaskHistory{
+		Action:  action,
+		ActorID: actorID,
+		Changes: diffTasks(before, after),
+	}
+	if after != nil {
+		entry.TaskID = after.ID
+		entry.Snapshot = taskSnapshot(after)
+	} else {
+		entry.TaskID = before.ID
+		entry.Snapshot = taskSnapshot(before)
+	}
+	return entry
+}
+
+// updateHistory membuat riwayat untuk Update, nil jika tidak ada field
+// yang berubah
+func updateHistory(update TaskUpdate, before, after *models.Task) *models.TaskHistory {
+	action := models.TaskActionUpdate
+	if update.RevertTo > 0 {
+		action = models.TaskActionRevert
+	}
+	entry := newTaskHistory(action, update.ActorID, before, after)
+	if len(entry.Changes) == 0 {
+		return nil
+	}
+	if update.RevertTo > 0 {
+		version := update.RevertTo
+		entry.RevertedTo = &version
+	}
+	return entry
+}
@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MemoryTaskRepository adalah implementasi TaskRepository di memori,
+// dipakai untuk test yang tidak membutuhkan Postgres.
+type MemoryTaskRepository struct {
+	mu            sync.RWMutex
+	nextID        int
+	tasks         map[int]models.Task
+	nextHistoryID int
+	history       map[int][]models.TaskHistory
+	// labels[taskID] berisi ID label yang terpasang, diisi oleh
+	// MemoryLabelRepository
+	labels map[int]map[int]bool
+}
+
+// NewMemoryTaskRepository membuat MemoryTaskRepository kosong.
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{nextID: 1, tasks: map[int]models.Task{}, nextHistoryID: 1, history: map[int][]models.TaskHistory{},
+		labels: map[int]map[int]bool{}}
+}
+
+// appendHistory menyimpan baris riwayat dengan versi berikutnya.
+// Pemanggil harus memegang r.mu.
+func (r *MemoryTaskRepository) appendHistory(entry *models.TaskHistory, at time.Time) {
+	entry.ID = r.nextHistoryID
+	entry.Version = len(r.history[entry.TaskID]) + 1
+	entry.CreatedAt = at
+	r.nextHistoryID++
+	r.history[entry.TaskID] = append(r.history[entry.TaskID], *entry)
+}
+
+func (r *MemoryTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	task.ID = r.nextID
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	if task.Priority == "" {
+		task.Priority = models.TaskPriorityMedium
+	}
+	task.CompletedAt = nil
+	if task.Status == models.TaskStatusCompleted {
+		task.CompletedAt = &now
+	}
+	r.nextID++
+	r.tasks[task.ID] = *task
+	r.appendHistory(newTaskHistory(models.TaskActionCreate, task.UserID, nil, task), now)
+	return nil
+}
+
+func (r *MemoryTaskRepository) GetByID(ctx context.Context, id int) (*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &task, nil
+}
+
+// matchTaskFilter mengecek apakah task cocok dengan filter
+func matchTaskFilter(task models.Task, filter TaskFilter) bool {
+	switch {
+	case filter.UserID != nil && task.UserID != *filter.UserID,
+		filter.Status != "" && task.Status != filter.Status,
+		filter.TitleContains != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(filter.TitleContains)),
+		filter.CreatedFrom != nil && task.CreatedAt.Before(*filter.CreatedFrom),
+		filter.CreatedTo != nil && task.CreatedAt.After(*filter.CreatedTo),
+		filter.UpdatedFrom != nil && task.UpdatedAt.Before(*filter.UpdatedFrom),
+		filter.UpdatedTo != nil && task.UpdatedAt.After(*filter.UpdatedTo),
+		filter.Priority != "" && task.Priority != filter.Priority,
+		filter.DueFrom != nil && (task.DueAt == nil || task.DueAt.Before(*filter.DueFrom)),
+		filter.DueTo != nil && (task.DueAt == nil || task.DueAt.After(*filter.DueTo)),
+		filter.OverdueAt != nil && (task.DueAt == nil || !task.DueAt.Before(*filter.OverdueAt) || task.Status == models.TaskStatusCompleted):
+		return false
+	}
+	return true
+}
+
+// matchLabels mengecek filter label task. Pemanggil harus memegang r.mu.
+func (r *MemoryTaskRepository) matchLabels(taskID int, filter TaskFilter) bool {
+	if len(filter.LabelIDs) == 0 {
+		return true
+	}
+	for _, id := range filter.LabelIDs {
+		attached := r.labels[taskID][id]
+		if attached && !filter.AllLabels {
+			return true
+		}
+		if !attached && filter.AllLabels {
+			return false
+		}
+	}
+	return filter.AllLabels
+}
+
+// compareDueAt membandingkan tenggat, task tanpa tenggat dianggap paling akhir
+func compareDueAt(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// compareTasks membandingkan dua task berdasarkan kolom sort lalu ID
+func compareTasks(a, b models.Task, field string) int {
+	var c int
+	switch field {
+	case TaskSortCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case TaskSortUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case TaskSortTitle:
+		c = strings.Compare(a.Title, b.Title)
+	case TaskSortStatus:
+		c = strings.Compare(a.Status, b.Status)
+	case TaskSortDueAt:
+		c = compareDueAt(a.DueAt, b.DueAt)
+	}
+	if c == 0 {
+		c = a.ID - b.ID
+	}
+	return c
+}
+
+// cursorTask membuat task semu dari cursor untuk dibandingkan dengan compareTasks
+func cursorTask(c *TaskCursor) (models.Task, error) {
+	task := models.Task{ID: c.ID, Title: c.Value, Status: c.Value}
+	if c.SortBy == TaskSortCreatedAt || c.SortBy == TaskSortUpdatedAt {
+		t, err := c.timeValue()
+		if err != nil {
+			return task, err
+		}
+		task.CreatedAt, task.UpdatedAt = t, t
+	}
+	if c.SortBy == TaskSortDueAt {
+		due, err := c.dueAtValue()
+		if err != nil {
+			return task, err
+		}
+		task.DueAt = due
+	}
+	return task, nil
+}
+
+func (r *MemoryTaskRepository) List(ctx context.Context, query TaskQuery) (*TaskPage, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	field := query.sortField()
+	less := func(a, b models.Task) bool {
+		if query.Desc {
+			return compareTasks(a, b, field) > 0
+		}
+		return compareTasks(a, b, field) < 0
+	}
+
+	var after *models.Task
+	if query.After != nil {
+		task, err := cursorTask(query.After)
+		if err != nil {
+			return nil, err
+		}
+		after = &task
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	page := &TaskPage{Tasks: []models.Task{}}
+	for _, task := range r.tasks {
+		if !matchTaskFilter(task, query.Filter) || !r.matchLabels(task.ID, query.Filter) {
+			continue
+		}
+		page.Total++
+		if after != nil && !less(*after, task) {
+			continue
+		}
+		page.Tasks = append(page.Tasks, task)
+	}
+	sort.Slice(page.Tasks, func(i, j int) bool { return less(page.Tasks[i], page.Tasks[j]) })
+
+	if query.Limit > 0 && len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.Next = newTaskCursor(query, page.Tasks[query.Limit-1])
+	}
+	return page, nil
+}
+
+// matchPrefix mengecek apakah ada kata di text yang diawali term
+func matchPrefix(text, term string) bool {
+	for _, w := range SearchTerms(text) {
+		if strings.HasPrefix(w, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightPrefix memberi penanda pada setiap kata yang diawali salah satu term
+func highlightPrefix(text string, terms []string) string {
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		lower := strings.ToLower(string(word))
+		matched := false
+		for _, t := range terms {
+			if len(word) > 0 && strings.HasPrefix(lower, t) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			b.WriteString(HighlightStart + string(word) + HighlightStop)
+		} else {
+			b.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// Search meniru full-text search Postgres secara sederhana: semua term harus
+// cocok sebagai prefix kata, dan kecocokan di judul bernilai lebih tinggi.
+func (r *MemoryTaskRepository) Search(ctx context.Context, search TaskSearch) ([]TaskSearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := []TaskSearchResult{}
+	if len(search.Terms) == 0 {
+		return results, nil
+	}
+	for _, task := range r.tasks {
+		if !matchTaskFilter(task, TaskFilter{UserID: search.UserID, Status: search.Status}) {
+			continue
+		}
+		rank, all := 0.0, true
+		for _, term := range search.Terms {
+			inTitle, inDesc := matchPrefix(task.Title, term), matchPrefix(task.Description, term)
+			if !inTitle && !inDesc {
+				all = false
+				break
+			}
+			if inTitle {
+				rank += 1.0
+			}
+			if inDesc {
+				rank += 0.4
+			}
+		}
+		if !all {
+			continue
+		}
+		results = append(results, TaskSearchResult{
+			Task:           task,
+			Rank:           rank,
+			TitleHighlight: highlightPrefix(task.Title, search.Terms),
+			Snippet:        highlightPrefix(task.Description, search.Terms),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Task.ID < results[j].Task.ID
+	})
+	if search.Limit > 0 && len(results) > search.Limit {
+		results = results[:search.Limit]
+	}
+	return results, nil
+}
+
+func (r *MemoryTaskRepository) Update(ctx context.Context, id int, update TaskUpdate) (*models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if update.ExpectedStatus != "" && task.Status != update.ExpectedStatus {
+		return nil, ErrStatusChanged
+	}
+	before := task
+	if v := nonEmpty(update.Title); v != "" {
+		task.Title = v
+	}
+	if v := nonEmpty(update.Description); v != "" || update.SetDescription {
+		task.Description = v
+	}
+	now := time.Now()
+	if v := nonEmpty(update.Status); v != "" && v != task.Status {
+		task.Status = v
+		task.CompletedAt = nil
+		if v == models.TaskStatusCompleted {
+			task.CompletedAt = &now
+		}
+	}
+	if v := nonEmpty(update.SecurityCode); v != "" {
+		task.SecurityCode = v
+	}
+	if v := nonEmpty(update.Priority); v != "" {
+		task.Priority = v
+	}
+	if update.ClearDueAt {
+		task.DueAt = nil
+	} else if update.DueAt != nil {
+		due := *update.DueAt
+		task.DueAt = &due
+	}
+	task.UpdatedAt = now
+	r.tasks[id] = task
+	if entry := updateHistory(update, &before, &task); entry != nil {
+		r.appendHistory(entry, now)
+	}
+	return &task, nil
+}
+
+func (r *MemoryTaskRepository) Delete(ctx context.Context, id int, actorID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.tasks, id)
+	delete(r.labels, id)
+	r.appendHistory(newTaskHistory(models.TaskActionDelete, actorID, &task, nil), time.Now())
+	return nil
+}
+
+func (r *MemoryTaskRepository) History(ctx context.Context, taskID int) ([]models.TaskHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.TaskHistory{}, r.history[taskID]...), nil
+}
+
+func (r *MemoryTaskRepository) HistoryVersion(ctx context.Context, taskID, version int) (*models.TaskHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := r.history[taskID]
+	if version < 1 || version > len(history) {
+		return nil, ErrNotFound
+	}
+	entry := history[version-1]
+	return &entry, nil
+}
+
+func (r *MemoryTaskRepository) ReencryptSecurityCodes(ctx context.Context, afterID, limit int, rotate RotateFunc) (ReencryptBatch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := []int{}
+	for id := range r.tasks {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	// hasil disimpan setelah semua berhasil, seperti transaksi di Postgres
+	batch := ReencryptBatch{LastID: afterID}
+	rotated := map[int]string{}
+	for _, id := range ids {
+		batch.Scanned++
+		batch.LastID = id
+		code := r.tasks[id].SecurityCode
+		if code == "" {
+			continue
+		}
+		newCode, changed, err := rotateSecurityCode(rotate, id, code)
+		if err != nil {
+			return ReencryptBatch{LastID: afterID}, err
+		}
+		if changed {
+			rotated[id] = newCode
+		}
+	}
+	for id, code := range rotated {
+		task := r.tasks[id]
+		task.SecurityCode = code
+		r.tasks[id] = task
+	}
+	batch.Updated = len(rotated)
+	return batch, nil
+}
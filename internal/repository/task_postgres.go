@@ -0,0 +1,480 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const taskColumns = "id, user_id, title, COALESCE(description, ''), status, priority, COALESCE(security_code, ''), due_at, completed_at, created_at, updated_at"
+
+// taskSortExpressions adalah ekspresi ORDER BY untuk kolom sort yang tidak
+// bisa dipakai langsung. Task tanpa tenggat diurutkan paling akhir, sama
+// dengan index tasks_due_at_id_idx.
+var taskSortExpressions = map[string]string{
+	TaskSortDueAt: "COALESCE(due_at, 'infinity'::timestamp)",
+}
+
+// PostgresTaskRepository adalah implementasi TaskRepository dengan Postgres.
+type PostgresTaskRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresTaskRepository membuat PostgresTaskRepository baru.
+func NewPostgresTaskRepository(db *sql.DB) *PostgresTaskRepository {
+	return &PostgresTaskRepository{db: db}
+}
+
+// taskFields mengembalikan tujuan Scan untuk taskColumns
+func taskFields(task *models.Task) []interface{} {
+	return []interface{}{&task.ID, &task.UserID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.SecurityCode,
+		&task.DueAt, &task.CompletedAt, &task.CreatedAt, &task.UpdatedAt}
+}
+
+func scanTask(row rowScanner) (*models.Task, error) {
+	var task models.Task
+	err := row.Scan(taskFields(&task)...)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return &task, nil
+}
+
+func (r *PostgresTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// task yang langsung dibuat dengan status completed dianggap selesai saat itu juga
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO tasks (user_id, title, description, status, security_code, priority, due_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), $7), $8, CASE WHEN $4 = $9 THEN CURRENT_TIMESTAMP END)
+		RETURNING id, priority, completed_at, created_at, updated_at`,
+		task.UserID, task.Title, task.Description, task.Status, task.SecurityCode,
+		task.Priority, models.TaskPriorityMedium, task.DueAt, models.TaskStatusCompleted,
+	).Scan(&task.ID, &task.Priority, &task.CompletedAt, &task.CreatedAt, &task.UpdatedAt)
+	if err != nil {
+		return mapPostgresError(err)
+	}
+	if err := insertTaskHistory(ctx, tx, newTaskHistory(models.TaskActionCreate, task.UserID, nil, task)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresTaskRepository) GetByID(ctx context.Context, id int) (*models.Task, error) {
+	return scanTask(r.db.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1", id))
+}
+
+// sqlArgs mengumpulkan argumen query dan mengembalikan placeholder-nya
+type sqlArgs []interface{}
+
+func (a *sqlArgs) add(v interface{}) string {
+	*a = append(*a, v)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// likePattern meng-escape karakter wildcard LIKE pada input user
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// taskFilterConditions mengubah TaskFilter menjadi kondisi WHERE
+func taskFilterConditions(filter TaskFilter, args *sqlArgs) []string {
+	var conds []string
+	if filter.UserID != nil {
+		conds = append(conds, "user_id = "+args.add(*filter.UserID))
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status = "+args.add(filter.Status))
+	}
+	if filter.TitleContains != "" {
+		conds = append(conds, "title ILIKE "+args.add(likePattern(filter.TitleContains)))
+	}
+	if filter.CreatedFrom != nil {
+		conds = append(conds, "created_at >= "+args.add(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conds = append(conds, "created_at <= "+args.add(*filter.CreatedTo))
+	}
+	if filter.UpdatedFrom != nil {
+		conds = append(conds, "updated_at >= "+args.add(*filter.UpdatedFrom))
+	}
+	if filter.UpdatedTo != nil {
+		conds = append(conds, "updated_at <= "+args.add(*filter.UpdatedTo))
+	}
+	if filter.Priority != "" {
+		conds = append(conds, "priority = "+args.add(filter.Priority))
+	}
+	if filter.DueFrom != nil {
+		conds = append(conds, "due_at >= "+args.add(*filter.DueFrom))
+	}
+	if filter.DueTo != nil {
+		conds = append(conds, "due_at <= "+args.add(*filter.DueTo))
+	}
+	if filter.OverdueAt != nil {
+		conds = append(conds, "due_at < "+args.add(*filter.OverdueAt), "status <> "+args.add(models.TaskStatusCompleted))
+	}
+	if len(filter.LabelIDs) > 0 {
+		labelIDs := uniqueInts(filter.LabelIDs)
+		ids := make(pq.Int64Array, len(labelIDs))
+		for i, id := range labelIDs {
+			ids[i] = int64(id)
+		}
+		labels := "SELECT task_id FROM task_labels WHERE label_id = ANY(" + args.add(ids) + ")"
+		if filter.AllLabels {
+			labels += " GROUP BY task_id HAVING COUNT(*) = " + args.add(len(labelIDs))
+		}
+		conds = append(conds, "id IN ("+labels+")")
+	}
+	return conds
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+func (r *PostgresTaskRepository) List(ctx context.Context, query TaskQuery) (*TaskPage, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	var args sqlArgs
+	conds := taskFilterConditions(query.Filter, &args)
+
+	// total dihitung tanpa cursor agar sama untuk setiap halaman
+	page := &TaskPage{Tasks: []models.Task{}}
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks"+whereClause(conds), args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	// kolom sort sudah divalidasi dengan whitelist sehingga aman disisipkan
+	column := query.sortField()
+	if expr, ok := taskSortExpressions[column]; ok {
+		column = expr
+	}
+	direction, cmp := "ASC", ">"
+	if query.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if c := query.After; c != nil {
+		var value interface{} = c.Value
+		switch c.SortBy {
+		case TaskSortCreatedAt, TaskSortUpdatedAt:
+			t, err := c.timeValue()
+			if err != nil {
+				return nil, err
+			}
+			value = t
+		case TaskSortDueAt:
+			due, err := c.dueAtValue()
+			if err != nil {
+				return nil, err
+			}
+			value = "infinity"
+			if due != nil {
+				value = *due
+			}
+		}
+		if column == TaskSortID {
+			conds = append(conds, "id "+cmp+" "+args.add(c.ID))
+		} else {
+			conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, args.add(value), args.add(c.ID)))
+		}
+	}
+
+	sqlQuery := "SELECT " + taskColumns + " FROM tasks" + whereClause(conds) + " ORDER BY "
+	if column != TaskSortID {
+		sqlQuery += column + " " + direction + ", "
+	}
+	sqlQuery += "id " + direction
+	if query.Limit > 0 {
+		// ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+		sqlQuery += " LIMIT " + args.add(query.Limit+1)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Tasks = append(page.Tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.Next = newTaskCursor(query, page.Tasks[query.Limit-1])
+	}
+	return page, nil
+}
+
+// opsi ts_headline untuk judul (seluruh teks) dan description (potongan)
+const (
+	titleHeadlineOptions   = `HighlightAll=true, StartSel="` + HighlightStart + `", StopSel="` + HighlightStop + `"`
+	snippetHeadlineOptions = `MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" ... ", StartSel="` + HighlightStart + `", StopSel="` + HighlightStop + `"`
+)
+
+func (r *PostgresTaskRepository) Search(ctx context.Context, search TaskSearch) ([]TaskSearchResult, error) {
+	results := []TaskSearchResult{}
+	if len(search.Terms) == 0 {
+		return results, nil
+	}
+
+	var args sqlArgs
+	tsquery := args.add(prefixQuery(search.Terms))
+	conds := []string{"search_vector @@ q"}
+	if search.UserID != nil {
+		conds = append(conds, "user_id = "+args.add(*search.UserID))
+	}
+	if search.Status != "" {
+		conds = append(conds, "status = "+args.add(search.Status))
+	}
+
+	sqlQuery := `SELECT ` + taskColumns + `,
+			ts_rank_cd(search_vector, q) AS rank,
+			ts_headline('simple', title, q, ` + args.add(titleHeadlineOptions) + `),
+			ts_headline('simple', COALESCE(description, ''), q, ` + args.add(snippetHeadlineOptions) + `)
+		FROM tasks, to_tsquery('simple', ` + tsquery + `) q` +
+		whereClause(conds) + `
+		ORDER BY rank DESC, id`
+	if search.Limit > 0 {
+		sqlQuery += " LIMIT " + args.add(search.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res TaskSearchResult
+		task := &res.Task
+		if err := rows.Scan(append(taskFields(task), &res.Rank, &res.TitleHighlight, &res.Snippet)...); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// lockTask membaca task dan menguncinya sampai transaksi selesai, sehingga
+// versi riwayat dan nilai "sebelum" tidak bentrok dengan request lain
+func lockTask(ctx context.Context, tx *sql.Tx, id int) (*models.Task, error) {
+	return scanTask(tx.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1 FOR UPDATE", id))
+}
+
+func (r *PostgresTaskRepository) Update(ctx context.Context, id int, update TaskUpdate) (*models.Task, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.ExpectedStatus != "" && before.Status != update.ExpectedStatus {
+		return nil, ErrStatusChanged
+	}
+
+	// ekspresi di SET membaca nilai lama baris, sehingga completed_at hanya
+	// berubah jika status benar-benar berubah
+	task, err := scanTask(tx.QueryRowContext(ctx, `
+		UPDATE tasks
+		SET title = COALESCE(NULLIF($1, ''), title),
+			description = CASE WHEN $10 THEN $2 ELSE COALESCE(NULLIF($2, ''), description) END,
+			status = COALESCE(NULLIF($3, ''), status),
+			completed_at = CASE
+				WHEN $3 = '' OR $3 = status THEN completed_at
+				WHEN $3 = $9 THEN CURRENT_TIMESTAMP
+				ELSE NULL
+			END,
+			security_code = COALESCE(NULLIF($4, ''), security_code),
+			priority = COALESCE(NULLIF($5, ''), priority),
+			due_at = CASE WHEN $6 THEN NULL ELSE COALESCE($7, due_at) END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
+		RETURNING `+taskColumns,
+		nonEmpty(update.Title), nonEmpty(update.Description), nonEmpty(update.Status), nonEmpty(update.SecurityCode),
+		nonEmpty(update.Priority), update.ClearDueAt, update.DueAt, id, models.TaskStatusCompleted, update.SetDescription,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if entry := updateHistory(update, before, task); entry != nil {
+		if err := insertTaskHistory(ctx, tx, entry); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *PostgresTaskRepository) Delete(ctx context.Context, id int, actorID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id); err != nil {
+		return err
+	}
+	if err := insertTaskHistory(ctx, tx, newTaskHistory(models.TaskActionDelete, actorID, before, nil)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertTaskHistory menyimpan satu baris riwayat dengan versi berikutnya
+// dari task tersebut dan mengisi ID, Version, dan CreatedAt
+func insertTaskHistory(ctx context.Context, tx *sql.Tx, entry *models.TaskHistory) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+	snapshot, err := json.Marshal(entry.Snapshot)
+	if err != nil {
+		return err
+	}
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO task_history (task_id, version, action, actor_id, reverted_to, changes, snapshot)
+		VALUES ($1, (SELECT COALESCE(MAX(version), 0) + 1 FROM task_history WHERE task_id = $1), $2, $3, $4, $5, $6)
+		RETURNING id, version, created_at`,
+		entry.TaskID, entry.Action, entry.ActorID, entry.RevertedTo, changes, snapshot,
+	).Scan(&entry.ID, &entry.Version, &entry.CreatedAt)
+}
+
+const taskHistoryColumns = "id, task_id, version, action, actor_id, reverted_to, changes, snapshot, created_at"
+
+func scanTaskHistory(row rowScanner) (*models.TaskHistory, error) {
+	var entry models.TaskHistory
+	var changes, snapshot []byte
+	err := row.Scan(&entry.ID, &entry.TaskID, &entry.Version, &entry.Action, &entry.ActorID, &entry.RevertedTo, &changes, &snapshot, &entry.CreatedAt)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &entry.Snapshot); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *PostgresTaskRepository) History(ctx context.Context, taskID int) ([]models.TaskHistory, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+taskHistoryColumns+" FROM task_history WHERE task_id = $1 ORDER BY version", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.TaskHistory{}
+	for rows.Next() {
+		entry, err := scanTaskHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, *entry)
+	}
+	return history, rows.Err()
+}
+
+func (r *PostgresTaskRepository) HistoryVersion(ctx context.Context, taskID, version int) (*models.TaskHistory, error) {
+	return scanTaskHistory(r.db.QueryRowContext(ctx,
+		"SELECT "+taskHistoryColumns+" FROM task_history WHERE task_id = $1 AND version = $2", taskID, version))
+}
+
+func (r *PostgresTaskRepository) ReencryptSecurityCodes(ctx context.Context, afterID, limit int, rotate RotateFunc) (ReencryptBatch, error) {
+	// jika gagal, posisi tetap di afterID karena transaksi dibatalkan
+	failed := ReencryptBatch{LastID: afterID}
+
+	// satu batch = satu transaksi, baris dikunci agar tidak bentrok
+	// dengan UpdateTask yang berjalan bersamaan
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return failed, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, COALESCE(security_code, '') FROM tasks
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE`, afterID, limit)
+	if err != nil {
+		return failed, err
+	}
+	type row struct {
+		id   int
+		code string
+	}
+	var pending []row
+	for rows.Next() {
+		var rw row
+		if err := rows.Scan(&rw.id, &rw.code); err != nil {
+			rows.Close()
+			return failed, err
+		}
+		pending = append(pending, rw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return failed, err
+	}
+
+	batch := ReencryptBatch{LastID: afterID}
+	for _, rw := range pending {
+		batch.Scanned++
+		batch.LastID = rw.id
+		if rw.code == "" {
+			continue
+		}
+		rotated, changed, err := rotateSecurityCode(rotate, rw.id, rw.code)
+		if err != nil {
+			return failed, err
+		}
+		if !changed {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET security_code = $1 WHERE id = $2", rotated, rw.id); err != nil {
+			return failed, err
+		}
+		batch.Updated++
+	}
+	if err := tx.Commit(); err != nil {
+		return failed, err
+	}
+	return batch, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidCursor dikembalikan jika cursor tidak bisa dibaca atau tidak
+// cocok dengan urutan yang diminta
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TaskFilter membatasi task yang dikembalikan oleh List.
+// Field kosong atau nil berarti tidak difilter.
+type TaskFilter struct {
+	// UserID nil berarti semua task (untuk admin)
+	UserID *int
+	Status string
+	// TitleContains mencari substring judul tanpa membedakan huruf besar/kecil
+	TitleContains string
+	// rentang waktu bersifat inklusif
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Priority    string
+	// rentang tenggat juga inklusif; task tanpa tenggat tidak ikut
+	DueFrom *time.Time
+	DueTo   *time.Time
+	// OverdueAt hanya mengambil task yang belum selesai dengan tenggat
+	// sebelum waktu ini
+	OverdueAt *time.Time
+	// LabelIDs hanya mengambil task yang memasang salah satu label ini,
+	// atau semua label ini jika AllLabels true
+	LabelIDs  []int
+	AllLabels bool
+}
+
+// kolom yang boleh dipakai untuk mengurutkan task
+const (
+	TaskSortID        = "id"
+	TaskSortCreatedAt = "created_at"
+	TaskSortUpdatedAt = "updated_at"
+	TaskSortTitle     = "title"
+	TaskSortStatus    = "status"
+	// task tanpa tenggat dianggap bertenggat paling akhir
+	TaskSortDueAt = "due_at"
+)
+
+// TaskSortFields adalah whitelist kolom untuk sorting.
+var TaskSortFields = map[string]bool{
+	TaskSortID:        true,
+	TaskSortCreatedAt: true,
+	TaskSortUpdatedAt: true,
+	TaskSortTitle:     true,
+	TaskSortStatus:    true,
+	TaskSortDueAt:     true,
+}
+
+// TaskQuery adalah parameter List: filter, urutan, dan halaman.
+type TaskQuery struct {
+	Filter TaskFilter
+	// SortBy harus ada di TaskSortFields, kosong berarti id.
+	// ID selalu dipakai sebagai urutan kedua agar urutan stabil.
+	SortBy string
+	Desc   bool
+	// Limit <= 0 berarti tanpa batas
+	Limit int
+	// After berisi posisi task terakhir dari halaman sebelumnya
+	After *TaskCursor
+}
+
+// TaskPage adalah hasil List.
+type TaskPage struct {
+	Tasks []models.Task
+	// Total adalah jumlah semua task yang cocok dengan filter (tanpa cursor)
+	Total int
+	// Next nil berarti tidak ada halaman berikutnya
+	Next *TaskCursor
+}
+
+// TaskCursor menyimpan posisi keyset: nilai kolom sort dan ID task terakhir.
+type TaskCursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Value  string `json:"v,omitempty"`
+	ID     int    `json:"id"`
+}
+
+// sortField mengembalikan kolom sort, default id
+func (q TaskQuery) sortField() string {
+	if q.SortBy == "" {
+		return TaskSortID
+	}
+	return q.SortBy
+}
+
+// newTaskCursor membuat cursor yang menunjuk ke task
+func newTaskCursor(q TaskQuery, task models.Task) *TaskCursor {
+	field := q.sortField()
+	cursor := &TaskCursor{SortBy: field, Desc: q.Desc, ID: task.ID}
+	switch field {
+	case TaskSortCreatedAt:
+		cursor.Value = task.CreatedAt.Format(time.RFC3339Nano)
+	case TaskSortUpdatedAt:
+		cursor.Value = task.UpdatedAt.Format(time.RFC3339Nano)
+	case TaskSortTitle:
+		cursor.Value = task.Title
+	case TaskSortStatus:
+		cursor.Value = task.Status
+	case TaskSortDueAt:
+		// nilai kosong berarti task tanpa tenggat
+		if task.DueAt != nil {
+			cursor.Value = task.DueAt.Format(time.RFC3339Nano)
+		}
+	}
+	return cursor
+}
+
+// dueAtValue membaca nilai cursor untuk due_at, nil berarti tanpa tenggat
+func (c *TaskCursor) dueAtValue() (*time.Time, error) {
+	if c.Value == "" {
+		return nil, nil
+	}
+	t, err := c.timeValue()
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// timeValue membaca nilai cursor untuk kolom waktu
+func (c *TaskCursor) timeValue() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+// Encode mengubah cursor menjadi string yang aman untuk query string.
+func (c *TaskCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTaskCursor membaca cursor hasil Encode.
+func DecodeTaskCursor(s string) (*TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c TaskCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 || !TaskSortFields[c.SortBy] {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy == TaskSortCreatedAt || c.SortBy == TaskSortUpdatedAt {
+		if _, err := c.timeValue(); err != nil {
+			return nil, err
+		}
+	}
+	if c.SortBy == TaskSortDueAt {
+		if _, err := c.dueAtValue(); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// validate memastikan sort dan cursor cocok satu sama lain
+func (q TaskQuery) validate() error {
+	if !TaskSortFields[q.sortField()] {
+		return errors.New("invalid sort field " + strconv.Quote(q.SortBy))
+	}
+	if q.After != nil && (q.After.SortBy != q.sortField() || q.After.Desc != q.Desc) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import "fmt"
+
+// RotateFunc mengenkripsi ulang satu ciphertext. Nilai bool false berarti
+// ciphertext sudah memakai key terbaru dan tidak perlu diubah.
+type RotateFunc func(ciphertext string) (string, bool, error)
+
+// ReencryptBatch adalah hasil satu batch ReencryptSecurityCodes.
+type ReencryptBatch struct {
+	// LastID adalah ID task terakhir yang diproses, dipakai sebagai
+	// afterID untuk batch berikutnya
+	LastID  int
+	Scanned int
+	Updated int
+}
+
+// rotateSecurityCode membungkus error rotasi dengan ID task
+func rotateSecurityCode(rotate RotateFunc, id int, code string) (string, bool, error) {
+	rotated, changed, err := rotate(code)
+	if err != nil {
+		return "", false, fmt.Errorf("task %d: %w", id, err)
+	}
+	return rotated, changed, nil
+}
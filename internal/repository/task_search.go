@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"strings"
+	"unicode"
+)
+
+// penanda highlight pada hasil search. Handler mengganti penanda ini
+// dengan tag HTML setelah teks di-escape.
+const (
+	HighlightStart = "[[mark]]"
+	HighlightStop  = "[[/mark]]"
+)
+
+// batas jumlah dan panjang kata pencarian
+const (
+	maxSearchTerms      = 10
+	maxSearchTermLength = 64
+)
+
+// TaskSearch adalah parameter Search.
+type TaskSearch struct {
+	// Terms adalah kata pencarian hasil SearchTerms, dicocokkan sebagai
+	// prefix dan semuanya harus ada (AND)
+	Terms []string
+	// UserID nil berarti semua task (untuk admin)
+	UserID *int
+	Status string
+	Limit  int
+}
+
+// TaskSearchResult adalah satu task hasil Search beserta ranking dan highlight.
+type TaskSearchResult struct {
+	Task models.Task
+	Rank float64
+	// TitleHighlight dan Snippet berisi penanda HighlightStart/HighlightStop
+	TitleHighlight string
+	Snippet        string
+}
+
+// SearchTerms memecah input user menjadi kata-kata berhuruf kecil yang
+// hanya berisi huruf dan angka, sehingga aman dipakai di tsquery.
+func SearchTerms(q string) []string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := []string{}
+	for _, w := range words {
+		if len([]rune(w)) > maxSearchTermLength {
+			w = string([]rune(w)[:maxSearchTermLength])
+		}
+		terms = append(terms, w)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// prefixQuery membuat tsquery prefix, misalnya "rep:* & mil:*"
+func prefixQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
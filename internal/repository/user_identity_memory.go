@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryUserIdentityRepository adalah implementasi UserIdentityRepository di memori.
+type MemoryUserIdentityRepository struct {
+	mu         sync.Mutex
+	nextID     int
+	identities map[int]models.UserIdentity
+}
+
+// NewMemoryUserIdentityRepository membuat MemoryUserIdentityRepository kosong.
+func NewMemoryUserIdentityRepository() *MemoryUserIdentityRepository {
+	return &MemoryUserIdentityRepository{nextID: 1, identities: map[int]models.UserIdentity{}}
+}
+
+func (r *MemoryUserIdentityRepository) Get(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryUserIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return ErrDuplicate
+		}
+	}
+	identity.ID = r.nextID
+	identity.CreatedAt = time.Now()
+	r.nextID++
+	r.identities[identity.ID] = *identity
+	return nil
+}
+
+func (r *MemoryUserIdentityRepository) TouchLastLogin(ctx context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identity, ok := r.identities[id]
+	if !ok {
+		return nil
+	}
+	identity.LastLoginAt = &at
+	r.identities[id] = identity
+	return nil
+}
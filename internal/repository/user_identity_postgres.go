@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresUserIdentityRepository adalah implementasi UserIdentityRepository
+// dengan Postgres.
+type PostgresUserIdentityRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresUserIdentityRepository membuat PostgresUserIdentityRepository baru.
+func NewPostgresUserIdentityRepository(db *sql.DB) *PostgresUserIdentityRepository {
+	return &PostgresUserIdentityRepository{db: db}
+}
+
+func (r *PostgresUserIdentityRepository) Get(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities WHERE provider = $1 AND subject = $2",
+		provider, subject,
+	).Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.LastLoginAt, &identity.CreatedAt)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return &identity, nil
+}
+
+func (r *PostgresUserIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		identity.UserID, identity.Provider, identity.Subject, identity.Email,
+	).Scan(&identity.ID, &identity.CreatedAt)
+	return mapPostgresError(err)
+}
+
+func (r *PostgresUserIdentityRepository) TouchLastLogin(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE user_identities SET last_login_at = $2 WHERE id = $1", id, at.UTC())
+	return mapPostgresError(err)
+}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository adalah implementasi UserRepository di memori,
+// dipakai untuk test yang tidak membutuhkan Postgres.
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	users  map[int]models.User
+}
+
+// NewMemoryUserRepository membuat MemoryUserRepository kosong.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{nextID: 1, users: map[int]models.User{}}
+}
+
+// isTaken mengecek unique constraint username dan email, kecuali untuk user exceptID
+func (r *MemoryUserRepository) isTaken(username, email string, exceptID int) bool {
+	for id, u := range r.users {
+		if id == exceptID {
+			continue
+		}
+		if (username != "" && u.Username == username) || (email != "" && u.Email == email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isTaken(user.Username, user.Email, 0) {
+		return ErrDuplicate
+	}
+	now := time.Now()
+	user.ID = r.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.nextID++
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	user.Password = ""
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			user.Password = ""
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryUserRepository) List(ctx context.Context) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]models.User, 0, len(r.users))
+	for _, user := range r.users {
+		user.Password = ""
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, id int, update UserUpdate) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if r.isTaken(nonEmpty(update.Username), nonEmpty(update.Email), id) {
+		return nil, ErrDuplicate
+	}
+	if v := nonEmpty(update.Username); v != "" {
+		user.Username = v
+	}
+	if v := nonEmpty(update.Email); v != "" {
+		if v != user.Email {
+			user.EmailVerifiedAt = nil
+		}
+		user.Email = v
+	}
+	if v := nonEmpty(update.Password); v != "" {
+		user.Password = v
+	}
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+
+	user.Password = ""
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) UpdateProfilePicture(ctx context.Context, id int, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.ProfilePicture.String = url
+	user.ProfilePicture.Valid = true
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) SetRole(ctx context.Context, id int, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+
+// hasRole mengecek apakah ada user dengan role, pengganti foreign key
+// users.role di Postgres
+func (r *MemoryUserRepository) hasRole(role string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryUserRepository) SetLockedUntil(ctx context.Context, id int, until *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if until != nil {
+		t := *until
+		until = &t
+	}
+	user.LockedUntil = until
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, id int, email string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Email != email {
+		return ErrNotFound
+	}
+	user.EmailVerifiedAt = &at
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+const userColumns = "id, username, email, role, profile_picture, locked_until, email_verified_at, created_at, updated_at"
+
+// PostgresUserRepository adalah implementasi UserRepository dengan Postgres.
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresUserRepository membuat PostgresUserRepository baru.
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.ProfilePicture, &user.LockedUntil, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return &user, nil
+}
+
+func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO users (username, email, password, role) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at",
+		user.Username, user.Email, user.Password, user.Role,
+	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	return mapPostgresError(err)
+}
+
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+", password FROM users WHERE username = $1", username,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.ProfilePicture, &user.LockedUntil, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt, &user.Password)
+	if err != nil {
+		return nil, mapPostgresError(err)
+	}
+	return &user, nil
+}
+
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE LOWER(email) = LOWER($1)", email))
+}
+
+func (r *PostgresUserRepository) List(ctx context.Context) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func (r *PostgresUserRepository) Update(ctx context.Context, id int, update UserUpdate) (*models.User, error) {
+	// Update hanya field yang dikirim (gunakan COALESCE di SQL)
+	return scanUser(r.db.QueryRowContext(ctx, `
+        UPDATE users
+        SET username = COALESCE(NULLIF($1, ''), username),
+			email = COALESCE(NULLIF($2, ''), email),
+			-- email baru harus diverifikasi ulang
+			email_verified_at = CASE WHEN NULLIF($2, '') IS NOT NULL AND $2 <> email THEN NULL ELSE email_verified_at END,
+			password = COALESCE(NULLIF($3, ''), password),
+			updated_at = CURRENT_TIMESTAMP
+        WHERE id = $4
+        RETURNING `+userColumns,
+		nonEmpty(update.Username), nonEmpty(update.Email), nonEmpty(update.Password), id,
+	))
+}
+
+func (r *PostgresUserRepository) UpdateProfilePicture(ctx context.Context, id int, url string) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE users SET profile_picture = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", url, id)
+	return checkAffected(res, err)
+}
+
+func (r *PostgresUserRepository) SetLockedUntil(ctx context.Context, id int, until *time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE users SET locked_until = $1 WHERE id = $2", until, id)
+	return checkAffected(res, err)
+}
+
+func (r *PostgresUserRepository) MarkEmailVerified(ctx context.Context, id int, email string, at time.Time) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE users SET email_verified_at = $1 WHERE id = $2 AND email = $3", at.UTC(), id, email)
+	return checkAffected(res, err)
+}
+
+func (r *PostgresUserRepository) SetRole(ctx context.Context, id int, role string) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", role, id)
+	return checkAffected(res, err)
+}
+
+func (r *PostgresUserRepository) Delete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+	return checkAffected(res, err)
+}
+
+// checkAffected mengembalikan ErrNotFound jika tidak ada baris yang berubah
+func checkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return mapPostgresError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix membedakan personal access token dari JWT di header
+// Authorization, dan memudahkan secret scanner mengenali token yang bocor.
+const AccessTokenPrefix = "pat_"
+
+// Scope yang bisa diberikan ke personal access token.
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeFilesRead  = "files:read"
+	ScopeFilesWrite = "files:write"
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+)
+
+// Scopes adalah semua scope yang valid, urut seperti di dokumentasi.
+var Scopes = []string{
+	ScopeTasksRead, ScopeTasksWrite,
+	ScopeFilesRead, ScopeFilesWrite,
+	ScopeUsersRead, ScopeUsersWrite,
+}
+
+// ErrAccessTokenInvalid dikembalikan jika personal access token tidak
+// dikenal, sudah dicabut, atau sudah kadaluarsa.
+var ErrAccessTokenInvalid = errors.New("personal access token is invalid or expired")
+
+// last_used_at hanya ditulis ulang jika lebih lama dari ini, agar script
+// yang sering memanggil API tidak menulis ke database di setiap request
+const lastUsedPrecision = time.Minute
+
+// AccessTokenService membuat dan memverifikasi personal access token.
+type AccessTokenService struct {
+	repo repository.PersonalAccessTokenRepository
+}
+
+// NewAccessTokenService membuat AccessTokenService.
+func NewAccessTokenService(repo repository.PersonalAccessTokenRepository) *AccessTokenService {
+	return &AccessTokenService{repo: repo}
+}
+
+// Create membuat token baru untuk userID dan mengembalikan token aslinya.
+// Token asli tidak disimpan dan tidak bisa diambil lagi.
+func (s *AccessTokenService) Create(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (string, *models.PersonalAccessToken, error) {
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := AccessTokenPrefix + raw
+	record := &models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    normalizeScopes(scopes),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		return "", nil, err
+	}
+	return token, record, nil
+}
+
+// Authenticate mencari token dan mencatat waktu pemakaiannya.
+func (s *AccessTokenService) Authenticate(ctx context.Context, token string) (*models.PersonalAccessToken, error) {
+	record, err := s.repo.GetByHash(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAccessTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.ExpiresAt != nil && !record.ExpiresAt.After(now) {
+		return nil, ErrAccessTokenInvalid
+	}
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= lastUsedPrecision {
+		if err := s.repo.TouchLastUsed(ctx, record.ID, now); err != nil {
+			return nil, err
+		}
+		record.LastUsedAt = &now
+	}
+	return record, nil
+}
+
+// List mengembalikan semua token milik userID.
+func (s *AccessTokenService) List(ctx context.Context, userID int) ([]models.PersonalAccessToken, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Revoke menghapus token id milik userID.
+func (s *AccessTokenService) Revoke(ctx context.Context, userID, id int) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// ValidScope mengecek apakah scope dikenal.
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope mengecek apakah scopes berisi scope. Scope ":write" juga
+// memberi akses ":read" untuk resource yang sama.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+		if resource, ok := strings.CutSuffix(scope, ":read"); ok && s == resource+":write" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeScopes menghapus scope duplikat dan mengurutkannya seperti Scopes
+func normalizeScopes(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range Scopes {
+		for _, s := range scopes {
+			if s == scope {
+				normalized = append(normalized, scope)
+				break
+			}
+		}
+	}
+	return normalized
+}
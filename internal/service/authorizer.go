@@ -0,0 +1,70 @@
+package service
+
+import (
+	"belajar-go/internal/repository"
+	"context"
+	"sync"
+	"time"
+)
+
+// permission role di-cache selama ini. Perubahan role lewat API langsung
+// menghapus cache, batas ini untuk perubahan dari instance lain.
+const permissionCacheTTL = 30 * time.Second
+
+// PermissionSet adalah kumpulan permission milik satu role.
+type PermissionSet map[string]bool
+
+// Has mengecek apakah set berisi permission.
+func (p PermissionSet) Has(permission string) bool {
+	return p[permission]
+}
+
+// Authorizer memetakan role ke permission-nya. Semua role dibaca sekaligus
+// dan disimpan di memori agar middleware tidak query ke database di setiap
+// request.
+type Authorizer struct {
+	roles repository.RoleRepository
+
+	mu       sync.Mutex
+	cache    map[string]PermissionSet
+	loadedAt time.Time
+}
+
+// NewAuthorizer membuat Authorizer yang membaca role dari roles.
+func NewAuthorizer(roles repository.RoleRepository) *Authorizer {
+	return &Authorizer{roles: roles}
+}
+
+// Permissions mengembalikan permission milik role. Role yang tidak dikenal
+// mendapat set kosong.
+func (a *Authorizer) Permissions(ctx context.Context, role string) (PermissionSet, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cache == nil || time.Since(a.loadedAt) > permissionCacheTTL {
+		roles, err := a.roles.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		a.cache = make(map[string]PermissionSet, len(roles))
+		for _, r := range roles {
+			set := make(PermissionSet, len(r.Permissions))
+			for _, p := range r.Permissions {
+				set[p] = true
+			}
+			a.cache[r.Name] = set
+		}
+		a.loadedAt = time.Now()
+	}
+	if set, ok := a.cache[role]; ok {
+		return set, nil
+	}
+	return PermissionSet{}, nil
+}
+
+// Invalidate menghapus cache, dipanggil setelah role atau permission berubah.
+func (a *Authorizer) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache = nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TokenDenylist menyimpan jti access token yang sudah dicabut sebelum waktunya
+// kadaluarsa (misalnya karena logout).
+type TokenDenylist struct {
+	rdb *redis.Client
+}
+
+// NewTokenDenylist membuat TokenDenylist baru.
+func NewTokenDenylist(rdb *redis.Client) *TokenDenylist {
+	return &TokenDenylist{rdb: rdb}
+}
+
+func denylistKey(jti string) string { return "jwt_denylist:" + jti }
+
+// Add mencabut jti sampai expiresAt. Setelah itu token sudah kadaluarsa
+// dengan sendirinya sehingga key tidak perlu disimpan lebih lama.
+func (d *TokenDenylist) Add(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return d.rdb.Set(ctx, denylistKey(jti), 1, ttl).Err()
+}
+
+// Contains mengecek apakah jti sudah dicabut.
+func (d *TokenDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+	n, err := d.rdb.Exists(ctx, denylistKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func userGenerationKey(userID int) string { return fmt.Sprintf("jwt_user_gen:%d", userID) }
+
+// UserGeneration mengembalikan generasi token userID saat ini. Nilainya
+// disimpan di claim "gen" setiap access token.
+func (d *TokenDenylist) UserGeneration(ctx context.Context, userID int) (int64, error) {
+	gen, err := d.rdb.Get(ctx, userGenerationKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return gen, err
+}
+
+// RevokeUser mencabut semua access token userID yang sudah diterbitkan
+// dengan menaikkan generasi token user. Key tidak diberi TTL agar generasi
+// tidak pernah kembali ke nilai lama.
+func (d *TokenDenylist) RevokeUser(ctx context.Context, userID int) error {
+	return d.rdb.Incr(ctx, userGenerationKey(userID)).Err()
+}
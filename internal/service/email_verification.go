@@ -0,0 +1,67 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrVerificationInvalid dikembalikan jika tanda tangan link verifikasi
+	// tidak cocok, misalnya karena link diubah atau email user sudah berganti.
+	ErrVerificationInvalid = errors.New("email verification link is invalid")
+	// ErrVerificationExpired dikembalikan jika link verifikasi sudah kadaluarsa.
+	ErrVerificationExpired = errors.New("email verification link has expired")
+)
+
+// EmailVerifier membuat dan memeriksa link verifikasi email yang ditandatangani
+// dengan HMAC-SHA256. Tanda tangan mencakup ID user, email, dan waktu
+// kadaluarsa, sehingga link tidak perlu disimpan di database dan otomatis
+// tidak berlaku jika email user berubah.
+type EmailVerifier struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewEmailVerifier membuat EmailVerifier. Key tanda tangan diturunkan dari
+// secret agar tidak sama dengan key yang dipakai untuk JWT.
+func NewEmailVerifier(secret []byte, ttl time.Duration) *EmailVerifier {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("email-verification"))
+	return &EmailVerifier{key: mac.Sum(nil), ttl: ttl}
+}
+
+func (v *EmailVerifier) sign(userID int, email string, expires int64) string {
+	mac := hmac.New(sha256.New, v.key)
+	fmt.Fprintf(mac, "%d\n%s\n%d", userID, strings.ToLower(email), expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Link membuat link verifikasi untuk userID dan email. baseURL adalah URL
+// endpoint verifikasi, parameter uid, exp, dan sig ditambahkan ke query.
+func (v *EmailVerifier) Link(baseURL string, userID int, email string) (string, time.Time) {
+	expiresAt := time.Now().Add(v.ttl)
+	query := url.Values{}
+	query.Set("uid", strconv.Itoa(userID))
+	query.Set("exp", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("sig", v.sign(userID, email, expiresAt.Unix()))
+	return baseURL + "?" + query.Encode(), expiresAt
+}
+
+// Verify memeriksa tanda tangan link terhadap email user saat ini.
+func (v *EmailVerifier) Verify(userID int, email string, expires int64, sig string) error {
+	expected := v.sign(userID, email, expires)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return ErrVerificationInvalid
+	}
+	if time.Now().Unix() > expires {
+		return ErrVerificationExpired
+	}
+	return nil
+}
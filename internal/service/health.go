@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck mengecek satu dependency, misalnya ping ke Postgres.
+type HealthCheck func(ctx context.Context) error
+
+// DependencyStatus adalah hasil pengecekan satu dependency.
+type DependencyStatus struct {
+	Status    string `json:"status"` // "up" atau "down"
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	err       error
+}
+
+// Err mengembalikan error asli dari pengecekan (untuk logging).
+func (s DependencyStatus) Err() error { return s.err }
+
+// HealthReport adalah hasil pengecekan readiness.
+type HealthReport struct {
+	Ready        bool
+	Draining     bool
+	Dependencies map[string]DependencyStatus
+}
+
+// Health menyimpan daftar pengecekan readiness dan status draining server.
+type Health struct {
+	timeout  time.Duration
+	names    []string
+	checks   map[string]HealthCheck
+	draining atomic.Bool
+}
+
+// NewHealth membuat Health baru. Setiap pengecekan dibatasi oleh timeout.
+func NewHealth(timeout time.Duration) *Health {
+	return &Health{timeout: timeout, checks: map[string]HealthCheck{}}
+}
+
+// Register menambahkan pengecekan dependency dengan nama tertentu.
+func (h *Health) Register(name string, check HealthCheck) {
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+		sort.Strings(h.names)
+	}
+	h.checks[name] = check
+}
+
+// SetDraining menandai server sedang berhenti, sehingga readiness
+// selalu gagal agar load balancer berhenti mengirim request baru.
+func (h *Health) SetDraining() { h.draining.Store(true) }
+
+// Draining mengecek apakah server sedang berhenti.
+func (h *Health) Draining() bool { return h.draining.Load() }
+
+// Check menjalankan semua pengecekan secara paralel.
+func (h *Health) Check(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Ready:        !h.Draining(),
+		Draining:     h.Draining(),
+		Dependencies: make(map[string]DependencyStatus, len(h.names)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, name := range h.names {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+			status := h.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[name] = status
+			if status.Status != "up" {
+				report.Ready = false
+			}
+		}(name, h.checks[name])
+	}
+	wg.Wait()
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check HealthCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	status := DependencyStatus{Status: "up", LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		// detail error hanya untuk log, respons cukup berisi penyebab umum
+		status.Status = "down"
+		status.err = err
+		status.Error = "unavailable"
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			status.Error = "timeout"
+		}
+	}
+	return status
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LoginGuardConfig mengatur batas percobaan login yang gagal.
+type LoginGuardConfig struct {
+	// MaxFailures adalah jumlah gagal per username sebelum akun dikunci
+	MaxFailures int
+	// IPMaxFailures adalah jumlah gagal per IP sebelum IP diblokir
+	IPMaxFailures int
+	// Window adalah rentang waktu penghitungan kegagalan
+	Window time.Duration
+	// LockoutDuration adalah lama akun dikunci atau IP diblokir
+	LockoutDuration time.Duration
+	// BackoffBase adalah jeda setelah kegagalan kedua, lalu berlipat dua
+	// setiap kegagalan berikutnya. Nol berarti tanpa jeda.
+	BackoffBase time.Duration
+	// BackoffMax adalah batas atas jeda
+	BackoffMax time.Duration
+}
+
+// LoginFailure adalah hasil pencatatan satu percobaan login yang gagal.
+type LoginFailure struct {
+	UserFailures int64
+	IPFailures   int64
+	// Backoff adalah jeda sebelum percobaan berikutnya boleh dilakukan
+	Backoff time.Duration
+	// LockUser bernilai true jika akun harus dikunci selama LockoutDuration
+	LockUser bool
+	// IPBlocked bernilai true jika IP baru saja diblokir
+	IPBlocked bool
+}
+
+// LoginGuard menghitung percobaan login yang gagal per username dan per IP
+// di Redis, lalu menentukan jeda (exponential backoff) dan lockout.
+//
+// Waktu akhir blokir disimpan sebagai nilai key (unix milidetik), TTL hanya
+// dipakai untuk membersihkan key lama.
+type LoginGuard struct {
+	rdb *redis.Client
+	cfg LoginGuardConfig
+}
+
+// NewLoginGuard membuat LoginGuard baru.
+func NewLoginGuard(rdb *redis.Client, cfg LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{rdb: rdb, cfg: cfg}
+}
+
+// username tidak case-sensitive agar "Admin" dan "admin" berbagi hitungan
+func loginUserKey(username string) string { return "login_fail:user:" + strings.ToLower(username) }
+func loginIPKey(ip string) string         { return "login_fail:ip:" + ip }
+func loginUserBlockKey(username string) string {
+	return "login_block:user:" + strings.ToLower(username)
+}
+func loginIPBlockKey(ip string) string { return "login_block:ip:" + ip }
+
+// RetryAfter mengembalikan sisa waktu blokir untuk username atau IP,
+// atau nol jika login boleh dicoba.
+func (g *LoginGuard) RetryAfter(ctx context.Context, username, ip string) (time.Duration, error) {
+	values, err := g.rdb.MGet(ctx, loginUserBlockKey(username), loginIPBlockKey(ip)).Result()
+	if err != nil {
+		return 0, err
+	}
+	var wait time.Duration
+	now := time.Now()
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		ms, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			continue
+		}
+		if d := time.UnixMilli(ms).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure mencatat satu percobaan login yang gagal.
+func (g *LoginGuard) RecordFailure(ctx context.Context, username, ip string) (LoginFailure, error) {
+	pipe := g.rdb.TxPipeline()
+	userCount := pipe.Incr(ctx, loginUserKey(username))
+	pipe.Expire(ctx, loginUserKey(username), g.cfg.Window)
+	ipCount := pipe.Incr(ctx, loginIPKey(ip))
+	pipe.Expire(ctx, loginIPKey(ip), g.cfg.Window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return LoginFailure{}, err
+	}
+
+	result := LoginFailure{UserFailures: userCount.Val(), IPFailures: ipCount.Val()}
+	result.LockUser = result.UserFailures >= int64(g.cfg.MaxFailures)
+	result.IPBlocked = result.IPFailures == int64(g.cfg.IPMaxFailures)
+
+	userWait := g.backoff(result.UserFailures)
+	ipWait := g.backoff(result.IPFailures)
+	if result.IPFailures >= int64(g.cfg.IPMaxFailures) {
+		ipWait = g.cfg.LockoutDuration
+	}
+	result.Backoff = max(userWait, ipWait)
+
+	pipe = g.rdb.TxPipeline()
+	if result.LockUser {
+		// lockout akun disimpan di database; hitungan dimulai lagi dari nol
+		// setelah lockout berakhir
+		pipe.Del(ctx, loginUserKey(username), loginUserBlockKey(username))
+	} else if userWait > 0 {
+		g.block(ctx, pipe, loginUserBlockKey(username), userWait)
+	}
+	if ipWait > 0 {
+		g.block(ctx, pipe, loginIPBlockKey(ip), ipWait)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// RecordSuccess menghapus hitungan gagal untuk username. Hitungan per IP
+// tidak dihapus agar satu akun yang valid tidak bisa dipakai untuk
+// mereset batas percobaan dari IP yang sama.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, username string) error {
+	return g.Reset(ctx, username)
+}
+
+// Reset menghapus hitungan gagal dan blokir untuk username, misalnya
+// saat admin membuka kunci akun.
+func (g *LoginGuard) Reset(ctx context.Context, username string) error {
+	return g.rdb.Del(ctx, loginUserKey(username), loginUserBlockKey(username)).Err()
+}
+
+// backoff menghitung jeda setelah kegagalan ke-n. Kegagalan pertama tidak
+// diberi jeda agar salah ketik sekali tidak menghambat user.
+func (g *LoginGuard) backoff(failures int64) time.Duration {
+	if g.cfg.BackoffBase <= 0 || failures < 2 {
+		return 0
+	}
+	wait := g.cfg.BackoffBase
+	for i := int64(2); i < failures; i++ {
+		wait *= 2
+		if wait >= g.cfg.BackoffMax {
+			return g.cfg.BackoffMax
+		}
+	}
+	return min(wait, g.cfg.BackoffMax)
+}
+
+func (g *LoginGuard) block(ctx context.Context, pipe redis.Pipeliner, key string, wait time.Duration) {
+	until := time.Now().Add(wait)
+	pipe.Set(ctx, key, until.UnixMilli(), wait)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"belajar-go/internal/repository"
+	"belajar-go/pkg/crypto"
+	"belajar-go/pkg/totp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMFAAlreadyEnabled dikembalikan saat mendaftar TOTP yang sudah aktif
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrMFANotEnrolled dikembalikan jika user belum mendaftar TOTP
+	ErrMFANotEnrolled = errors.New("two-factor authentication is not enrolled")
+	// ErrMFAInvalidCode dikembalikan jika kode TOTP atau recovery code salah
+	// atau sudah pernah dipakai
+	ErrMFAInvalidCode = errors.New("invalid two-factor code")
+)
+
+const (
+	// jumlah recovery code yang dibuat saat TOTP diaktifkan
+	recoveryCodeCount = 10
+	// toleransi satu periode (30 detik) sebelum dan sesudah waktu server
+	totpSkew = 1
+)
+
+// MFAService mengelola TOTP (RFC 6238) dan recovery code user. Secret TOTP
+// dienkripsi dengan keyring, recovery code hanya disimpan hash-nya.
+type MFAService struct {
+	repo    repository.MFARepository
+	keyring *crypto.Keyring
+	issuer  string
+}
+
+// NewMFAService membuat MFAService. issuer ditampilkan di aplikasi authenticator.
+func NewMFAService(repo repository.MFARepository, keyring *crypto.Keyring, issuer string) *MFAService {
+	return &MFAService{repo: repo, keyring: keyring, issuer: issuer}
+}
+
+// Enabled mengecek apakah user sudah mengaktifkan TOTP.
+func (s *MFAService) Enabled(ctx context.Context, userID int) (bool, error) {
+	mfa, err := s.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return mfa.EnabledAt != nil, nil
+}
+
+// Enroll membuat secret baru yang belum aktif sampai dikonfirmasi dengan
+// Confirm, lalu mengembalikan secret dan URI otpauth:// untuk QR code.
+func (s *MFAService) Enroll(ctx context.Context, userID int, account string) (string, string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	encrypted, err := s.keyring.Encrypt(secret)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.repo.SavePending(ctx, userID, encrypted); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return "", "", ErrMFAAlreadyEnabled
+		}
+		return "", "", err
+	}
+	return secret, totp.URI(s.issuer, account, secret), nil
+}
+
+// Confirm mengaktifkan TOTP yang sedang didaftarkan jika code benar, lalu
+// mengembalikan recovery code baru. Recovery code hanya bisa dilihat sekali.
+func (s *MFAService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	mfa, err := s.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mfa.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := s.keyring.Decrypt(mfa.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	// kode konfirmasi langsung dianggap terpakai
+	if err := s.repo.Enable(ctx, userID, time.Now(), step, hashes); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify mengecek kode TOTP, atau recovery code jika recoveryCode diisi.
+// Setiap kode hanya bisa dipakai sekali. Nilai int adalah sisa recovery
+// code, atau -1 jika yang dipakai adalah kode TOTP.
+func (s *MFAService) Verify(ctx context.Context, userID int, code, recoveryCode string) (int, error) {
+	mfa, err := s.repo.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return 0, err
+	}
+	if mfa.EnabledAt == nil {
+		return 0, ErrMFANotEnrolled
+	}
+
+	if recoveryCode != "" {
+		remaining, err := s.repo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(recoveryCode)), time.Now())
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, ErrMFAInvalidCode
+		}
+		return remaining, err
+	}
+
+	secret, err := s.keyring.Decrypt(mfa.Secret)
+	if err != nil {
+		return 0, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return 0, ErrMFAInvalidCode
+	}
+	// kode yang sama (atau dari periode lebih lama) tidak bisa dipakai ulang
+	if err := s.repo.UseStep(ctx, userID, step); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, ErrMFAInvalidCode
+		}
+		return 0, err
+	}
+	return -1, nil
+}
+
+// RemainingRecoveryCodes mengembalikan jumlah recovery code yang belum dipakai.
+func (s *MFAService) RemainingRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	return s.repo.RemainingRecoveryCodes(ctx, userID)
+}
+
+// Disable menghapus TOTP dan recovery code user.
+func (s *MFAService) Disable(ctx context.Context, userID int) error {
+	err := s.repo.Delete(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrMFANotEnrolled
+	}
+	return err
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes membuat recovery code berformat "xxxxx-xxxxx" (50 bit)
+// beserta hash-nya
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode mengabaikan huruf besar, spasi, dan tanda minus
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrMFAChallengeInvalid dikembalikan jika challenge token tidak dikenal,
+// sudah kadaluarsa, sudah ditukar, atau sudah terlalu banyak dicoba.
+var ErrMFAChallengeInvalid = errors.New("mfa challenge is invalid or expired")
+
+// jumlah percobaan kode sebelum challenge dihapus dan user harus login ulang
+const maxMFAChallengeAttempts = 5
+
+// MFAChallenge adalah login yang passwordnya sudah benar tetapi masih
+// menunggu kode TOTP.
+type MFAChallenge struct {
+	UserID     int    `json:"user_id"`
+	DeviceID   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+}
+
+// MFAChallengeService menyimpan challenge token "mfa_required" di Redis.
+// Seperti refresh token, yang disimpan hanya hash token.
+type MFAChallengeService struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewMFAChallengeService membuat MFAChallengeService dengan masa berlaku ttl.
+func NewMFAChallengeService(rdb *redis.Client, ttl time.Duration) *MFAChallengeService {
+	return &MFAChallengeService{rdb: rdb, ttl: ttl}
+}
+
+func mfaChallengeKey(token string) string { return "mfa_challenge:" + hashToken(token) }
+func mfaAttemptsKey(token string) string  { return "mfa_attempts:" + hashToken(token) }
+
+// TTL mengembalikan masa berlaku challenge token.
+func (s *MFAChallengeService) TTL() time.Duration { return s.ttl }
+
+// Issue membuat challenge token untuk userID di device.
+func (s *MFAChallengeService) Issue(ctx context.Context, userID int, device Device) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(MFAChallenge{UserID: userID, DeviceID: device.ID, DeviceName: device.Name})
+	if err != nil {
+		return "", err
+	}
+	if err := s.rdb.Set(ctx, mfaChallengeKey(token), data, s.ttl).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Attempt mengambil challenge dan mencatat satu percobaan kode. Percobaan
+// dihitung dengan INCR sebelum kode diperiksa, sehingga request paralel
+// dengan challenge yang sama tetap dibatasi maxMFAChallengeAttempts.
+func (s *MFAChallengeService) Attempt(ctx context.Context, token string) (*MFAChallenge, error) {
+	pipe := s.rdb.TxPipeline()
+	get := pipe.Get(ctx, mfaChallengeKey(token))
+	attempts := pipe.Incr(ctx, mfaAttemptsKey(token))
+	pipe.Expire(ctx, mfaAttemptsKey(token), s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	data, err := get.Bytes()
+	if err == redis.Nil {
+		return nil, ErrMFAChallengeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attempts.Val() > maxMFAChallengeAttempts {
+		if err := s.Delete(ctx, token); err != nil {
+			return nil, err
+		}
+		return nil, ErrMFAChallengeInvalid
+	}
+	var challenge MFAChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// Consume menghapus challenge setelah kodenya benar. GETDEL memastikan
+// challenge hanya bisa ditukar sekali, request lain mendapat
+// ErrMFAChallengeInvalid.
+func (s *MFAChallengeService) Consume(ctx context.Context, token string) error {
+	err := s.rdb.GetDel(ctx, mfaChallengeKey(token)).Err()
+	if err == redis.Nil {
+		return ErrMFAChallengeInvalid
+	}
+	if err != nil {
+		return err
+	}
+	return s.rdb.Del(ctx, mfaAttemptsKey(token)).Err()
+}
+
+// Delete menghapus challenge beserta hitungan percobaannya.
+func (s *MFAChallengeService) Delete(ctx context.Context, token string) error {
+	return s.rdb.Del(ctx, mfaChallengeKey(token), mfaAttemptsKey(token)).Err()
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrOIDCStateInvalid dikembalikan jika state dari callback tidak dikenal,
+// sudah kadaluarsa, atau sudah dipakai.
+var ErrOIDCStateInvalid = errors.New("oidc state is invalid or expired")
+
+// OIDCLogin adalah login OpenID Connect yang sedang menunggu callback dari
+// identity provider.
+type OIDCLogin struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	// Verifier adalah code_verifier PKCE, hanya dikirim saat menukar kode
+	Verifier   string `json:"verifier"`
+	DeviceID   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+}
+
+// OIDCStateService menyimpan parameter login OpenID Connect (nonce dan
+// code_verifier) di Redis dengan key dari parameter state, sehingga
+// callback hanya bisa dipakai sekali dan hanya untuk login yang dimulai
+// aplikasi ini.
+type OIDCStateService struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewOIDCStateService membuat OIDCStateService dengan masa berlaku ttl.
+func NewOIDCStateService(rdb *redis.Client, ttl time.Duration) *OIDCStateService {
+	return &OIDCStateService{rdb: rdb, ttl: ttl}
+}
+
+func oidcStateKey(state string) string { return "oidc_state:" + hashToken(state) }
+
+// TTL mengembalikan masa berlaku state.
+func (s *OIDCStateService) TTL() time.Duration { return s.ttl }
+
+// Start membuat state, nonce, dan code_verifier baru untuk login ke
+// provider dari device.
+func (s *OIDCStateService) Start(ctx context.Context, provider string, device Device) (string, *OIDCLogin, error) {
+	state, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	nonce, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	// 32 byte acak dalam base64url menghasilkan 43 karakter, panjang
+	// minimal code_verifier menurut RFC 7636
+	verifier, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	login := &OIDCLogin{Provider: provider, Nonce: nonce, Verifier: verifier, DeviceID: device.ID, DeviceName: device.Name}
+	data, err := json.Marshal(login)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.rdb.Set(ctx, oidcStateKey(state), data, s.ttl).Err(); err != nil {
+		return "", nil, err
+	}
+	return state, login, nil
+}
+
+// Consume mengambil dan menghapus login untuk state.
+func (s *OIDCStateService) Consume(ctx context.Context, state string) (*OIDCLogin, error) {
+	data, err := s.rdb.GetDel(ctx, oidcStateKey(state)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrOIDCStateInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	var login OIDCLogin
+	if err := json.Unmarshal(data, &login); err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
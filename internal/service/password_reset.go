@@ -0,0 +1,62 @@
+package service
+
+import (
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrResetTokenInvalid dikembalikan jika token reset password tidak dikenal,
+// sudah dipakai, atau sudah kadaluarsa.
+var ErrResetTokenInvalid = errors.New("password reset token is invalid or expired")
+
+// PasswordResetService menerbitkan dan memakai token reset password.
+// Token hanya berlaku sekali dan yang disimpan hanya hash-nya.
+type PasswordResetService struct {
+	repo repository.PasswordResetRepository
+	ttl  time.Duration
+}
+
+// NewPasswordResetService membuat PasswordResetService dengan masa berlaku ttl.
+func NewPasswordResetService(repo repository.PasswordResetRepository, ttl time.Duration) *PasswordResetService {
+	return &PasswordResetService{repo: repo, ttl: ttl}
+}
+
+// Issue membuat token baru untuk userID. Token lama milik user dihapus
+// sehingga hanya email terakhir yang berlaku.
+func (s *PasswordResetService) Issue(ctx context.Context, userID int) (string, time.Time, error) {
+	if err := s.repo.DeleteByUser(ctx, userID); err != nil {
+		return "", time.Time{}, err
+	}
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	rec := &models.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Create(ctx, rec); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, rec.ExpiresAt, nil
+}
+
+// Consume memakai token dan mengembalikan ID user pemiliknya. Semua token
+// lain milik user ikut dihapus.
+func (s *PasswordResetService) Consume(ctx context.Context, token string) (int, error) {
+	rec, err := s.repo.Consume(ctx, hashToken(token), time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.DeleteByUser(ctx, rec.UserID); err != nil {
+		return 0, err
+	}
+	return rec.UserID, nil
+}
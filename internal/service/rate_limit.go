@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RateLimiter membatasi jumlah aksi per key dalam satu window (fixed window)
+// dengan counter di Redis, misalnya untuk endpoint yang mengirim email.
+type RateLimiter struct {
+	rdb    *redis.Client
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewRateLimiter membuat RateLimiter yang mengizinkan limit aksi per window.
+// prefix membedakan key Redis antar limiter.
+func NewRateLimiter(rdb *redis.Client, prefix string, limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{rdb: rdb, prefix: prefix, limit: int64(limit), window: window}
+}
+
+// Allow mencatat satu aksi untuk key. Jika batas sudah tercapai, Allow
+// mengembalikan false beserta sisa waktu sampai window berikutnya.
+func (l *RateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	redisKey := "rate_limit:" + l.prefix + ":" + key
+	count, err := l.rdb.Incr(ctx, redisKey).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	ttl, err := l.rdb.PTTL(ctx, redisKey).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	// window dimulai dari aksi pertama dan tidak diperpanjang oleh aksi
+	// berikutnya; key tanpa TTL (misalnya Expire sebelumnya gagal) diberi
+	// TTL baru agar tidak memblokir selamanya
+	if count == 1 || ttl < 0 {
+		if err := l.rdb.PExpire(ctx, redisKey, l.window).Err(); err != nil {
+			return false, 0, err
+		}
+		ttl = l.window
+	}
+	if count > l.limit {
+		return false, ttl, nil
+	}
+	return true, 0, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"belajar-go/internal/repository"
+	"belajar-go/pkg/crypto"
+	"context"
+)
+
+// ReencryptResult adalah ringkasan ReencryptTasks.
+type ReencryptResult struct {
+	LastID  int
+	Scanned int
+	Updated int
+}
+
+// ReencryptTasks mengenkripsi ulang security code semua task dengan ID >
+// afterID memakai primary key keyring, per batch berisi batchSize task.
+// Setiap batch disimpan sendiri, sehingga jika proses berhenti di tengah
+// jalan bisa dilanjutkan dengan afterID = LastID terakhir. Menjalankan ulang
+// dari awal juga aman karena task yang sudah memakai primary key dilewati.
+func ReencryptTasks(ctx context.Context, tasks repository.TaskRepository, keyring *crypto.Keyring, afterID, batchSize int, progress func(repository.ReencryptBatch)) (ReencryptResult, error) {
+	result := ReencryptResult{LastID: afterID}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		batch, err := tasks.ReencryptSecurityCodes(ctx, result.LastID, batchSize, keyring.Rotate)
+		if err != nil {
+			return result, err
+		}
+		if batch.Scanned == 0 {
+			return result, nil
+		}
+		result.LastID = batch.LastID
+		result.Scanned += batch.Scanned
+		result.Updated += batch.Updated
+		if progress != nil {
+			progress(batch)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrRefreshTokenInvalid dikembalikan jika refresh token tidak dikenal,
+	// sudah kadaluarsa, atau family-nya sudah dicabut.
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	// ErrRefreshTokenReused dikembalikan jika refresh token yang sudah pernah
+	// dirotasi dipakai lagi. Seluruh family token akan dicabut.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrDeviceMismatch dikembalikan jika refresh token dipakai dari device
+	// yang berbeda dengan device saat login.
+	ErrDeviceMismatch = errors.New("refresh token does not belong to this device")
+)
+
+// RefreshToken adalah data refresh token yang disimpan di Redis.
+// Token aslinya tidak pernah disimpan, hanya hash SHA-256 sebagai key.
+type RefreshToken struct {
+	UserID    int       `json:"user_id"`
+	FamilyID  string    `json:"family_id"`
+	DeviceID  string    `json:"device_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// tokenFamily merepresentasikan satu rantai rotasi refresh token
+// yang dimulai dari satu kali login di satu device, yaitu satu sesi.
+type tokenFamily struct {
+	UserID     int       `json:"user_id"`
+	DeviceID   string    `json:"device_id"`
+	DeviceName string    `json:"device_name"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RefreshTokenService mengelola penerbitan, rotasi, dan pencabutan refresh token.
+type RefreshTokenService struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewRefreshTokenService membuat RefreshTokenService dengan masa berlaku ttl.
+func NewRefreshTokenService(rdb *redis.Client, ttl time.Duration) *RefreshTokenService {
+	return &RefreshTokenService{rdb: rdb, ttl: ttl}
+}
+
+func tokenKey(hash string) string      { return "refresh_token:" + hash }
+func usedKey(hash string) string       { return "refresh_used:" + hash }
+func familyKey(familyID string) string { return "refresh_family:" + familyID }
+func seenKey(familyID string) string   { return "refresh_seen:" + familyID }
+func userFamiliesKey(userID int) string {
+	return fmt.Sprintf("refresh_user:%d", userID)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Issue membuat family (sesi) baru untuk userID di device dan
+// mengembalikan refresh token pertamanya. ip dan userAgent dicatat untuk
+// daftar sesi.
+func (s *RefreshTokenService) Issue(ctx context.Context, userID int, device Device, ip, userAgent string) (string, *RefreshToken, error) {
+	familyID := uuid.NewString()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := time.Now()
+	family := tokenFamily{
+		UserID:     userID,
+		DeviceID:   device.ID,
+		DeviceName: device.Name,
+		IPAddress:  ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+	}
+	familyJSON, err := json.Marshal(family)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, familyKey(familyID), familyJSON, s.ttl)
+	pipe.Set(ctx, seenKey(familyID), now.Unix(), s.ttl)
+	s.trackFamily(ctx, pipe, userID, familyID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", nil, err
+	}
+
+	return s.issueInFamily(ctx, userID, familyID, device.ID)
+}
+
+// trackFamily mencatat family di set sesi user dan memperpanjang set itu
+// selama ttl. Set harus diperpanjang setiap kali family diperpanjang, jika
+// tidak family yang terus dirotasi hilang dari set dan tidak ikut dicabut
+// oleh RevokeAllForUser.
+func (s *RefreshTokenService) trackFamily(ctx context.Context, pipe redis.Pipeliner, userID int, familyID string) {
+	pipe.SAdd(ctx, userFamiliesKey(userID), familyID)
+	pipe.Expire(ctx, userFamiliesKey(userID), s.ttl)
+}
+
+func (s *RefreshTokenService) issueInFamily(ctx context.Context, userID int, familyID, deviceID string) (string, *RefreshToken, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	rec := &RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		DeviceID:  deviceID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	recJSON, err := json.Marshal(rec)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.rdb.Set(ctx, tokenKey(hashToken(token)), recJSON, s.ttl).Err(); err != nil {
+		return "", nil, err
+	}
+	return token, rec, nil
+}
+
+// Lookup mengambil data refresh token tanpa merotasinya.
+func (s *RefreshTokenService) Lookup(ctx context.Context, token string) (*RefreshToken, error) {
+	raw, err := s.rdb.Get(ctx, tokenKey(hashToken(token))).Bytes()
+	if err == redis.Nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec RefreshToken
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Rotate menukar refresh token lama dengan yang baru di family yang sama.
+// Token lama ditandai sudah dipakai; jika token yang sudah dipakai muncul lagi,
+// seluruh family dicabut dan ErrRefreshTokenReused dikembalikan.
+func (s *RefreshTokenService) Rotate(ctx context.Context, token, deviceID string) (string, *RefreshToken, error) {
+	rec, err := s.Lookup(ctx, token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Family yang sudah dicabut membuat semua token di dalamnya tidak berlaku
+	exists, err := s.rdb.Exists(ctx, familyKey(rec.FamilyID)).Result()
+	if err != nil {
+		return "", nil, err
+	}
+	if exists == 0 {
+		return "", nil, ErrRefreshTokenInvalid
+	}
+
+	if rec.DeviceID != deviceID {
+		if err := s.RevokeFamily(ctx, rec.UserID, rec.FamilyID); err != nil {
+			return "", nil, err
+		}
+		return "", rec, ErrDeviceMismatch
+	}
+
+	// SETNX memastikan hanya satu request yang bisa merotasi token ini
+	hash := hashToken(token)
+	first, err := s.rdb.SetNX(ctx, usedKey(hash), 1, time.Until(rec.ExpiresAt)).Result()
+	if err != nil {
+		return "", nil, err
+	}
+	if !first {
+		if err := s.RevokeFamily(ctx, rec.UserID, rec.FamilyID); err != nil {
+			return "", nil, err
+		}
+		return "", rec, ErrRefreshTokenReused
+	}
+
+	if err := s.rdb.Expire(ctx, familyKey(rec.FamilyID), s.ttl).Err(); err != nil {
+		return "", nil, err
+	}
+	if err := s.touch(ctx, rec.UserID, rec.FamilyID, time.Now()); err != nil {
+		return "", nil, err
+	}
+	return s.issueInFamily(ctx, rec.UserID, rec.FamilyID, rec.DeviceID)
+}
+
+// RevokeFamily mencabut seluruh refresh token dalam satu family.
+func (s *RefreshTokenService) RevokeFamily(ctx context.Context, userID int, familyID string) error {
+	pipe := s.rdb.TxPipeline()
+	pipe.Del(ctx, familyKey(familyID), seenKey(familyID))
+	pipe.SRem(ctx, userFamiliesKey(userID), familyID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeAllForUser mencabut semua family refresh token milik userID
+// (logout dari semua device).
+func (s *RefreshTokenService) RevokeAllForUser(ctx context.Context, userID int) error {
+	familyIDs, err := s.rdb.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+	pipe := s.rdb.TxPipeline()
+	for _, familyID := range familyIDs {
+		pipe.Del(ctx, familyKey(familyID), seenKey(familyID))
+	}
+	pipe.Del(ctx, userFamiliesKey(userID))
+	_, err = pipe.Exec(ctx)
+	return err
+}
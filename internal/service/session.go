@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrSessionNotFound dikembalikan jika sesi tidak ada, sudah berakhir,
+// atau milik user lain.
+var ErrSessionNotFound = errors.New("session not found")
+
+const (
+	// sessionTouchInterval adalah jarak minimal antara dua pembaruan
+	// last_seen_at satu sesi, agar setiap request tidak menulis ke Redis
+	sessionTouchInterval = time.Minute
+	// maxUserAgentLength membatasi User-Agent yang disimpan per sesi
+	maxUserAgentLength = 512
+)
+
+// Device adalah device tempat user login. ID mengikat refresh token ke
+// device, Name hanya untuk ditampilkan di daftar sesi.
+type Device struct {
+	ID   string
+	Name string
+}
+
+// Session adalah satu login yang masih aktif. ID-nya adalah ID family
+// refresh token, yang juga dibawa access token di claim sid.
+type Session struct {
+	ID         string    `json:"id"`
+	DeviceID   string    `json:"device_id"`
+	DeviceName string    `json:"device_name"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// Sessions mengembalikan sesi aktif userID, yang terakhir dipakai lebih dulu.
+func (s *RefreshTokenService) Sessions(ctx context.Context, userID int) ([]Session, error) {
+	familyIDs, err := s.rdb.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := []Session{}
+	if len(familyIDs) == 0 {
+		return sessions, nil
+	}
+
+	keys := make([]string, 0, 2*len(familyIDs))
+	for _, id := range familyIDs {
+		keys = append(keys, familyKey(id), seenKey(id))
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var ended []interface{}
+	for i, id := range familyIDs {
+		raw, ok := values[2*i].(string)
+		if !ok {
+			// family sudah kadaluarsa tetapi masih tercatat di set user
+			ended = append(ended, id)
+			continue
+		}
+		var family tokenFamily
+		if err := json.Unmarshal([]byte(raw), &family); err != nil {
+			return nil, err
+		}
+		session := Session{
+			ID:         id,
+			DeviceID:   family.DeviceID,
+			DeviceName: family.DeviceName,
+			IPAddress:  family.IPAddress,
+			UserAgent:  family.UserAgent,
+			CreatedAt:  family.CreatedAt,
+			LastSeenAt: family.CreatedAt,
+		}
+		if seen, ok := values[2*i+1].(string); ok {
+			if unix, err := strconv.ParseInt(seen, 10, 64); err == nil {
+				session.LastSeenAt = time.Unix(unix, 0)
+			}
+		}
+		sessions = append(sessions, session)
+	}
+	if len(ended) > 0 {
+		s.rdb.SRem(ctx, userFamiliesKey(userID), ended...)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession mencabut satu sesi milik userID beserta semua refresh
+// token dan access token-nya.
+func (s *RefreshTokenService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	raw, err := s.rdb.Get(ctx, familyKey(sessionID)).Bytes()
+	if err == redis.Nil {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var family tokenFamily
+	if err := json.Unmarshal(raw, &family); err != nil {
+		return err
+	}
+	if family.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.RevokeFamily(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions mencabut semua sesi userID kecuali keepID dan
+// mengembalikan jumlah sesi yang dicabut.
+func (s *RefreshTokenService) RevokeOtherSessions(ctx context.Context, userID int, keepID string) (int, error) {
+	familyIDs, err := s.rdb.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, id := range familyIDs {
+		if id == keepID {
+			continue
+		}
+		if err := s.RevokeFamily(ctx, userID, id); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// TouchSession mengecek apakah sesi masih aktif dan mencatat waktu sesi
+// terakhir dipakai, paling sering sekali per sessionTouchInterval.
+func (s *RefreshTokenService) TouchSession(ctx context.Context, userID int, sessionID string) (bool, error) {
+	pipe := s.rdb.Pipeline()
+	exists := pipe.Exists(ctx, familyKey(sessionID))
+	seen := pipe.Get(ctx, seenKey(sessionID))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return false, err
+	}
+	if exists.Val() == 0 {
+		return false, nil
+	}
+	now := time.Now()
+	if last, err := seen.Int64(); err == nil && now.Sub(time.Unix(last, 0)) < sessionTouchInterval {
+		return true, nil
+	}
+	return true, s.touch(ctx, userID, sessionID, now)
+}
+
+// touch mencatat waktu terakhir sesi dipakai dan memastikan sesi masih
+// tercatat di set sesi user
+func (s *RefreshTokenService) touch(ctx context.Context, userID int, familyID string, at time.Time) error {
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, seenKey(familyID), at.Unix(), s.ttl)
+	s.trackFamily(ctx, pipe, userID, familyID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownStatus dikembalikan jika status tidak ada di workflow
+	ErrUnknownStatus = errors.New("unknown status")
+	// ErrInitialStatus dikembalikan jika task dibuat dengan status yang
+	// bukan status awal
+	ErrInitialStatus = errors.New("status is not an initial status")
+	// ErrTransitionNotAllowed dikembalikan jika workflow tidak punya
+	// perubahan dari status lama ke status baru
+	ErrTransitionNotAllowed = errors.New("status transition is not allowed")
+	// ErrTransitionForbidden dikembalikan jika perubahan status ada di
+	// workflow tetapi role user tidak boleh melakukannya
+	ErrTransitionForbidden = errors.New("role may not perform this status transition")
+)
+
+// TaskTransition adalah satu perubahan status yang diizinkan workflow.
+type TaskTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Roles kosong berarti semua role
+	Roles []string `json:"roles"`
+}
+
+// TaskWorkflow memeriksa status task dan perubahannya sesuai alur yang
+// dikonfigurasi (TASK_WORKFLOW_FILE).
+type TaskWorkflow struct {
+	statuses    []string
+	initial     []string
+	transitions []TaskTransition
+	known       map[string]bool
+	// rules[from][to] berisi role yang boleh, nil berarti semua role
+	rules map[string]map[string][]string
+}
+
+// NewTaskWorkflow membuat TaskWorkflow. Input dianggap sudah divalidasi
+// oleh configs.Config.TaskWorkflow.
+func NewTaskWorkflow(statuses, initial []string, transitions []TaskTransition) *TaskWorkflow {
+	w := &TaskWorkflow{
+		statuses:    statuses,
+		initial:     initial,
+		transitions: transitions,
+		known:       map[string]bool{},
+		rules:       map[string]map[string][]string{},
+	}
+	for _, s := range statuses {
+		w.known[s] = true
+	}
+	for _, t := range transitions {
+		if w.rules[t.From] == nil {
+			w.rules[t.From] = map[string][]string{}
+		}
+		w.rules[t.From][t.To] = t.Roles
+	}
+	return w
+}
+
+// Statuses mengembalikan semua status yang dikenal
+func (w *TaskWorkflow) Statuses() []string { return w.statuses }
+
+// Initial mengembalikan status yang boleh dipakai saat membuat task
+func (w *TaskWorkflow) Initial() []string { return w.initial }
+
+// Transitions mengembalikan semua perubahan status yang diizinkan
+func (w *TaskWorkflow) Transitions() []TaskTransition { return w.transitions }
+
+// Valid mengecek apakah status ada di workflow
+func (w *TaskWorkflow) Valid(status string) bool {
+	return w.known[status]
+}
+
+// CheckCreate memastikan task boleh dibuat dengan status ini.
+func (w *TaskWorkflow) CheckCreate(status string) error {
+	if !w.known[status] {
+		return w.unknown(status)
+	}
+	for _, s := range w.initial {
+		if s == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: tasks cannot be created as %q, use one of: %s", ErrInitialStatus, status, strings.Join(w.initial, ", "))
+}
+
+// CheckTransition memastikan user dengan role ini boleh mengubah status
+// from menjadi to. Status yang tidak berubah selalu boleh. Task dengan
+// status lama yang sudah tidak ada di workflow boleh dipindah ke status awal.
+func (w *TaskWorkflow) CheckTransition(from, to, role string) error {
+	if !w.known[to] {
+		return w.unknown(to)
+	}
+	if from == to {
+		return nil
+	}
+	if !w.known[from] {
+		if err := w.CheckCreate(to); err != nil {
+			return fmt.Errorf("%w: %q is no longer in the workflow, move the task to one of: %s",
+				ErrTransitionNotAllowed, from, strings.Join(w.initial, ", "))
+		}
+		return nil
+	}
+	roles, ok := w.rules[from][to]
+	if !ok {
+		return fmt.Errorf("%w: cannot change status from %q to %q, allowed next statuses: %s",
+			ErrTransitionNotAllowed, from, to, listOrNone(w.Next(from, role)))
+	}
+	if !allowsRole(roles, role) {
+		return fmt.Errorf("%w: role %q cannot change status from %q to %q", ErrTransitionForbidden, role, from, to)
+	}
+	return nil
+}
+
+// Next mengembalikan status yang bisa dituju dari from oleh role ini,
+// urut sesuai Statuses
+func (w *TaskWorkflow) Next(from, role string) []string {
+	next := []string{}
+	for _, s := range w.statuses {
+		if roles, ok := w.rules[from][s]; ok && allowsRole(roles, role) {
+			next = append(next, s)
+		}
+	}
+	return next
+}
+
+// unknown membuat error ErrUnknownStatus beserta daftar status yang valid
+func (w *TaskWorkflow) unknown(status string) error {
+	return fmt.Errorf("%w %q, use one of: %s", ErrUnknownStatus, status, strings.Join(w.statuses, ", "))
+}
+
+func allowsRole(roles []string, role string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ", ")
+}
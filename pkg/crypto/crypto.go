@@ -12,6 +12,9 @@ import (
 )
 
 // Encrypt mengenskripsi data dengan key yang diberikan.
+//
+// Deprecated: AES-CFB tanpa autentikasi. Gunakan Keyring.Encrypt; fungsi ini
+// hanya dipertahankan untuk membuat data lama (misalnya di test).
 func Encrypt(data, key string) (string, error) {
 	key = FixEncryptionKey(key)
 
@@ -35,6 +38,8 @@ func Encrypt(data, key string) (string, error) {
 }
 
 // Decrypt mendekripsi data dengan key yang diberikan.
+//
+// Deprecated: hanya untuk membaca ciphertext lama, gunakan Keyring.Decrypt.
 func Decrypt(data, key string) (string, error) {
 	key = FixEncryptionKey(key)
 
@@ -43,7 +48,10 @@ func Decrypt(data, key string) (string, error) {
 		return "", err
 	}
 
-	ciphertext, _ := base64.StdEncoding.DecodeString(data)
+	ciphertext, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
 	if len(ciphertext) < aes.BlockSize {
 		return "", errors.New("ciphertext too short")
 	}
@@ -0,0 +1,178 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// format ciphertext: "v1:<key id>:<base64(nonce || ciphertext || tag)>"
+const versionGCM = "v1"
+
+// KeySize adalah panjang key AES-256 dalam byte.
+const KeySize = 32
+
+var (
+	// ErrInvalidCiphertext dikembalikan jika format ciphertext tidak dikenal
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	// ErrUnknownKey dikembalikan jika key ID di ciphertext tidak ada di keyring
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrDecrypt dikembalikan jika ciphertext sudah diubah atau key salah
+	ErrDecrypt = errors.New("message authentication failed")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Keyring berisi key AES-GCM yang dikenali aplikasi. Data baru selalu
+// dienkripsi dengan primary key, sedangkan semua key di keyring bisa
+// dipakai untuk dekripsi sehingga key lama tetap bisa dibaca saat rotasi.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+	// legacy adalah passphrase lama untuk ciphertext AES-CFB tanpa versi
+	legacy string
+}
+
+// NewKeyring membuat Keyring dari key mentah (masing-masing 32 byte).
+// legacyKey boleh kosong; jika diisi, ciphertext lama tanpa prefix versi
+// didekripsi dengan Decrypt (AES-CFB).
+func NewKeyring(primaryID string, keys map[string][]byte, legacyKey string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring needs at least one key")
+	}
+	k := &Keyring{primary: primaryID, keys: map[string]cipher.AEAD{}, legacy: legacyKey}
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key ID %q (use 1-32 letters, digits, '-' or '_')", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[primaryID]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the keyring", primaryID)
+	}
+	return k, nil
+}
+
+// ParseKeyring membaca keyring dari format konfigurasi
+// "id1:base64key1,id2:base64key2". primaryID kosong berarti key pertama.
+func ParseKeyring(spec, primaryID, legacyKey string) (*Keyring, error) {
+	keys := map[string][]byte{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry %q, expected id:base64key", id)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("duplicate key ID %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64", id)
+		}
+		keys[id] = key
+		if primaryID == "" {
+			primaryID = id
+		}
+	}
+	return NewKeyring(primaryID, keys, legacyKey)
+}
+
+// PrimaryKeyID mengembalikan ID key yang dipakai untuk enkripsi.
+func (k *Keyring) PrimaryKeyID() string { return k.primary }
+
+// Encrypt mengenkripsi plaintext dengan primary key.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	prefix := versionGCM + ":" + k.primary
+	// prefix ikut diautentikasi agar key ID tidak bisa ditukar
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(prefix))
+	return prefix + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt mendekripsi ciphertext yang dibuat oleh Encrypt dengan key mana
+// pun di keyring, atau ciphertext lama jika legacy key dikonfigurasi.
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	version, keyID, payload, ok := splitCiphertext(ciphertext)
+	if !ok {
+		if k.legacy == "" {
+			return "", ErrInvalidCiphertext
+		}
+		return Decrypt(ciphertext, k.legacy)
+	}
+	if version != versionGCM {
+		return "", ErrInvalidCiphertext
+	}
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(data) < aead.NonceSize()+aead.Overhead() {
+		return "", ErrInvalidCiphertext
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(version+":"+keyID))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation mengecek apakah ciphertext belum memakai primary key.
+func (k *Keyring) NeedsRotation(ciphertext string) bool {
+	version, keyID, _, ok := splitCiphertext(ciphertext)
+	return !ok || version != versionGCM || keyID != k.primary
+}
+
+// Rotate mengenkripsi ulang ciphertext dengan primary key. Nilai bool
+// bernilai false jika ciphertext sudah memakai primary key.
+func (k *Keyring) Rotate(ciphertext string) (string, bool, error) {
+	if !k.NeedsRotation(ciphertext) {
+		return ciphertext, false, nil
+	}
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return rotated, true, nil
+}
+
+// splitCiphertext memecah "version:keyID:payload". Base64 standar tidak
+// memakai ':' sehingga ciphertext lama tidak akan cocok dengan format ini.
+func splitCiphertext(s string) (version, keyID, payload string, ok bool) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "v") || !keyIDPattern.MatchString(parts[1]) {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
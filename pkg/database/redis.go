@@ -2,24 +2,21 @@ package database
 
 import (
 	"belajar-go/configs"
-	"belajar-go/internal/config"
-	"belajar-go/pkg/logger"
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/go-redis/redis/v8"
-	"go.uber.org/zap"
 )
 
-func ConnectRedis(cfg configs.Config) *redis.Client {
+func ConnectRedis(cfg configs.Config) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.RedisHost, cfg.RedisPort),
-		Password: "",
+		Password: cfg.RedisPassword,
 		DB:       0,
 	})
-	if err := client.Ping(config.Ctx).Err(); err != nil {
-		logger.ErrorLogger.Error("Redis connection error", zap.Error(err))
-		log.Fatalf("Could not connect to Redis: %v", err)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("could not connect to Redis: %w", err)
 	}
-	return client
+	return client, nil
 }
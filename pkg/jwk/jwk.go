@@ -0,0 +1,272 @@
+// Package jwk memuat key untuk menandatangani access token dari file PEM
+// (RSA untuk RS256 atau Ed25519 untuk EdDSA) dan menampilkan bagian
+// publiknya sebagai JSON Web Key Set (RFC 7517) agar service lain bisa
+// memverifikasi token tanpa mengetahui private key.
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// AlgRS256 adalah algoritma JWT untuk key RSA
+	AlgRS256 = "RS256"
+	// AlgEdDSA adalah algoritma JWT untuk key Ed25519
+	AlgEdDSA = "EdDSA"
+	// minRSABits adalah ukuran minimal key RSA
+	minRSABits = 2048
+)
+
+// ErrUnknownKey dikembalikan jika kid tidak ada di key set.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Key adalah satu key penandatangan beserta kid dan algoritmanya.
+type Key struct {
+	ID        string
+	Algorithm string
+	// Private bernilai nil untuk key lama yang hanya dipakai verifikasi
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// ParsePEM membaca private key (PKCS#8 atau PKCS#1) atau public key (PKIX)
+// dari blok PEM. Public key saja cukup untuk key lama yang tidak lagi
+// dipakai menandatangani token baru.
+func ParsePEM(id string, data []byte) (*Key, error) {
+	if !keyIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid key ID %q (use 1-32 letters, digits, '-' or '_')", id)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM block found", id)
+	}
+
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	key := &Key{ID: id}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.Private = signer
+		key.Public = signer.Public()
+	} else {
+		key.Public = parsed
+	}
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("key %q: RSA key must be at least %d bits", id, minRSABits)
+		}
+		key.Algorithm = AlgRS256
+	case ed25519.PublicKey:
+		key.Algorithm = AlgEdDSA
+	default:
+		return nil, fmt.Errorf("key %q: unsupported key type %T (use RSA or Ed25519)", id, pub)
+	}
+	return key, nil
+}
+
+// LoadFile membaca key dari file PEM.
+func LoadFile(id, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+	return ParsePEM(id, data)
+}
+
+// KeySet berisi semua key yang dikenali aplikasi. Token baru selalu
+// ditandatangani dengan key aktif, sedangkan semua key di set diterima
+// saat verifikasi sehingga token lama tetap berlaku selama rotasi.
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+	// order menjaga urutan key di JWKS sesuai konfigurasi
+	order []string
+}
+
+// NewKeySet membuat KeySet. activeID kosong berarti private key pertama.
+func NewKeySet(activeID string, keys ...*Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("key set needs at least one key")
+	}
+	s := &KeySet{keys: map[string]*Key{}}
+	for _, key := range keys {
+		if _, dup := s.keys[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		s.keys[key.ID] = key
+		s.order = append(s.order, key.ID)
+		if activeID == "" && s.active == nil && key.Private != nil {
+			s.active = key
+		}
+	}
+	if activeID != "" {
+		s.active = s.keys[activeID]
+		if s.active == nil {
+			return nil, fmt.Errorf("active key %q is not in the key set", activeID)
+		}
+	}
+	if s.active == nil || s.active.Private == nil {
+		return nil, errors.New("key set needs a private key to sign tokens")
+	}
+	return s, nil
+}
+
+// ParseKeySet membaca key set dari format konfigurasi
+// "kid1:/path/key1.pem,kid2:/path/key2.pem".
+func ParseKeySet(spec, activeID string) (*KeySet, error) {
+	var keys []*Key
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, path, ok := strings.Cut(entry, ":")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid entry %q (expected kid:path)", entry)
+		}
+		key, err := LoadFile(strings.TrimSpace(id), strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(activeID, keys...)
+}
+
+// Signing mengembalikan key yang dipakai untuk token baru.
+func (s *KeySet) Signing() *Key {
+	return s.active
+}
+
+// Lookup mencari key berdasarkan kid di header token.
+func (s *KeySet) Lookup(id string) (*Key, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// JWK adalah public key dalam format JSON Web Key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC dan Ed25519 (OKP)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// PublicKey mengubah JWK menjadi public key, misalnya untuk key dari JWKS
+// identity provider. Key RSA, EC (P-256, P-384, P-521), dan Ed25519 didukung.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.KeyType {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, fmt.Errorf("key %q: invalid RSA modulus", k.KeyID)
+		}
+		e, err := decode(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %q: invalid RSA exponent", k.KeyID)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("key %q: unsupported curve %q", k.KeyID, k.Curve)
+		}
+		x, errX := decode(k.X)
+		y, errY := decode(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("key %q: invalid EC point", k.KeyID)
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("key %q: invalid EC point", k.KeyID)
+		}
+		return pub, nil
+	case "OKP":
+		x, err := decode(k.X)
+		if k.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %q: invalid Ed25519 key", k.KeyID)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("key %q: unsupported key type %q", k.KeyID, k.KeyType)
+}
+
+// Set adalah isi endpoint /.well-known/jwks.json.
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan public key dari semua key di set.
+func (s *KeySet) JWKS() Set {
+	set := Set{Keys: make([]JWK, 0, len(s.order))}
+	for _, id := range s.order {
+		set.Keys = append(set.Keys, s.keys[id].JWK())
+	}
+	return set
+}
+
+// JWK mengembalikan public key dalam format JSON Web Key.
+func (k *Key) JWK() JWK {
+	b64 := base64.RawURLEncoding.EncodeToString
+	out := JWK{Use: "sig", Algorithm: k.Algorithm, KeyID: k.ID}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		out.KeyType = "RSA"
+		out.N = b64(pub.N.Bytes())
+		out.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		out.KeyType = "OKP"
+		out.Curve = "Ed25519"
+		out.X = b64(pub)
+	}
+	return out
+}
@@ -1,22 +1,22 @@
 package logger
 
 import (
-	"log"
 	"os"
+	"path/filepath"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// LoggerInstance menyimpan logger yang sudah diinisialisasi.
-var (
+// Loggers menyimpan logger yang sudah diinisialisasi.
+type Loggers struct {
 	ErrorLogger    *zap.Logger
 	AuditLogger    *zap.Logger
 	RequestLogger  *zap.Logger
 	SecurityLogger *zap.Logger
 	SystemLogger   *zap.Logger
 	ContextLogger  *zap.Logger
-)
+}
 
 func newLogger(filePath string, level zapcore.Level) (*zap.Logger, error) {
 	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -37,39 +37,55 @@ func newLogger(filePath string, level zapcore.Level) (*zap.Logger, error) {
 	return zap.New(core), nil
 }
 
-func InitLoggers() {
-	var err error
-	ErrorLogger, err = newLogger("logs/errors.log", zapcore.ErrorLevel)
-	if err != nil {
-		log.Fatalf("Cannot create error logger: %v", err)
-	}
-	AuditLogger, err = newLogger("logs/audit.log", zapcore.InfoLevel)
-	if err != nil {
-		log.Fatalf("Cannot create audit logger: %v", err)
-	}
-	RequestLogger, err = newLogger("logs/request.log", zapcore.InfoLevel)
-	if err != nil {
-		log.Fatalf("Cannot create request logger: %v", err)
+// New membuat set logger yang menulis ke file-file di folder dir.
+func New(dir string) (*Loggers, error) {
+	// Pastikan folder logs sudah ada
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
 	}
-	SecurityLogger, err = newLogger("logs/security.log", zapcore.WarnLevel)
-	if err != nil {
-		log.Fatalf("Cannot create security logger: %v", err)
+
+	l := &Loggers{}
+	files := []struct {
+		target **zap.Logger
+		name   string
+		level  zapcore.Level
+	}{
+		{&l.ErrorLogger, "errors.log", zapcore.ErrorLevel},
+		{&l.AuditLogger, "audit.log", zapcore.InfoLevel},
+		{&l.RequestLogger, "request.log", zapcore.InfoLevel},
+		{&l.SecurityLogger, "security.log", zapcore.WarnLevel},
+		{&l.SystemLogger, "system.log", zapcore.InfoLevel},
+		{&l.ContextLogger, "context.log", zapcore.DebugLevel},
 	}
-	SystemLogger, err = newLogger("logs/system.log", zapcore.InfoLevel)
-	if err != nil {
-		log.Fatalf("Cannot create system logger: %v", err)
+	for _, f := range files {
+		zl, err := newLogger(filepath.Join(dir, f.name), f.level)
+		if err != nil {
+			return nil, err
+		}
+		*f.target = zl
 	}
-	ContextLogger, err = newLogger("logs/context.log", zapcore.DebugLevel)
-	if err != nil {
-		log.Fatalf("Cannot create context logger: %v", err)
+	return l, nil
+}
+
+// NewNop membuat set logger yang tidak menulis ke mana pun.
+func NewNop() *Loggers {
+	nop := zap.NewNop()
+	return &Loggers{
+		ErrorLogger:    nop,
+		AuditLogger:    nop,
+		RequestLogger:  nop,
+		SecurityLogger: nop,
+		SystemLogger:   nop,
+		ContextLogger:  nop,
 	}
 }
 
-func SyncLoggers() {
-	_ = ErrorLogger.Sync()
-	_ = AuditLogger.Sync()
-	_ = RequestLogger.Sync()
-	_ = SecurityLogger.Sync()
-	_ = SystemLogger.Sync()
-	_ = ContextLogger.Sync()
+// Sync menulis semua log yang masih di-buffer.
+func (l *Loggers) Sync() {
+	_ = l.ErrorLogger.Sync()
+	_ = l.AuditLogger.Sync()
+	_ = l.RequestLogger.Sync()
+	_ = l.SecurityLogger.Sync()
+	_ = l.SystemLogger.Sync()
+	_ = l.ContextLogger.Sync()
 }
@@ -0,0 +1,106 @@
+// Package mail mengirim email dari aplikasi lewat interface Mailer, dengan
+// implementasi SMTP untuk production serta file dan log untuk development
+// dan test.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message adalah email teks biasa.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Bytes menyusun message sebagai email RFC 5322 dari alamat from.
+func (m Message) Bytes(from string) []byte {
+	var buf bytes.Buffer
+	// baris baru di header bisa dipakai untuk menyisipkan header lain
+	clean := strings.NewReplacer("\r", "", "\n", "").Replace
+	fmt.Fprintf(&buf, "From: %s\r\n", clean(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", clean(m.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", clean(m.Subject)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+// SMTPMailer mengirim email lewat server SMTP. STARTTLS dipakai otomatis
+// jika didukung server.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer membuat SMTPMailer. username kosong berarti tanpa autentikasi.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{addr: fmt.Sprintf("%s:%d", host, port), auth: auth, from: from}
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, msg.Bytes(s.from))
+}
+
+// FileMailer menyimpan setiap email sebagai file .eml di satu folder,
+// berguna untuk development dan test.
+type FileMailer struct {
+	dir  string
+	from string
+	seq  atomic.Int64
+}
+
+// NewFileMailer membuat FileMailer yang menulis ke dir.
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (f *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+	// nama file diurutkan berdasarkan waktu kirim
+	name := fmt.Sprintf("%d-%03d.eml", time.Now().UnixNano(), f.seq.Add(1)%1000)
+	return os.WriteFile(filepath.Join(f.dir, name), msg.Bytes(f.from), 0600)
+}
+
+// LogMailer menulis email ke logger alih-alih mengirimnya.
+type LogMailer struct {
+	log *zap.Logger
+}
+
+// NewLogMailer membuat LogMailer.
+func NewLogMailer(log *zap.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+func (l *LogMailer) Send(ctx context.Context, msg Message) error {
+	l.log.Info("Email not sent (log mailer)",
+		zap.String("to", msg.To), zap.String("subject", msg.Subject), zap.String("body", msg.Body))
+	return nil
+}
@@ -0,0 +1,302 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// lockID adalah key advisory lock Postgres yang dipakai selama migrasi,
+// sehingga dua instance aplikasi tidak bisa menjalankan migrasi bersamaan.
+const lockID int64 = 7340188163621120513
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrChecksumMismatch dikembalikan jika isi file migrasi yang sudah dijalankan
+// berubah setelah diterapkan ke database.
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// Migration adalah satu versi skema dengan script up dan down.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status menggambarkan keadaan satu migrasi di database.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified bernilai true jika checksum di database berbeda dengan file
+	Modified bool
+}
+
+// Migrator menjalankan migrasi berurutan terhadap database Postgres.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// New membaca semua file migrasi dari fsys dengan format
+// <versi>_<nama>.up.sql dan <versi>_<nama>.down.sql.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations mengembalikan daftar migrasi yang terbaca, urut berdasarkan versi.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// withLock menjalankan fn di satu koneksi yang memegang advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	if _, err := conn.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up menjalankan semua migrasi yang belum diterapkan secara berurutan.
+// Setiap migrasi dijalankan dalam transaksi sendiri.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) (err error) {
+		done, err = m.up(ctx, conn, 0)
+		return err
+	})
+	return done, err
+}
+
+// Down membatalkan steps migrasi terakhir yang sudah diterapkan.
+// Jika steps <= 0, semua migrasi dibatalkan.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) (err error) {
+		done, err = m.down(ctx, conn, steps)
+		return err
+	})
+	return done, err
+}
+
+// Redo membatalkan migrasi terakhir lalu menjalankannya lagi. Migrasi lain
+// yang belum diterapkan tidak ikut dijalankan, dan lock dipegang selama
+// kedua langkah agar instance lain tidak bisa bermigrasi di antaranya.
+func (m *Migrator) Redo(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		reverted, err := m.down(ctx, conn, 1)
+		if err != nil || len(reverted) == 0 {
+			return err
+		}
+		done, err = m.up(ctx, conn, reverted[0].Version)
+		return err
+	})
+	return done, err
+}
+
+// up menerapkan migrasi yang belum diterapkan, atau hanya versi only jika
+// only > 0. Pemanggil harus memegang lock.
+func (m *Migrator) up(ctx context.Context, conn *sql.Conn, only int64) ([]Migration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mig := range m.migrations {
+		if only > 0 && mig.Version > only {
+			break
+		}
+		if a, ok := applied[mig.Version]; ok {
+			if a.checksum != mig.Checksum {
+				return done, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+			}
+			continue
+		}
+		if only > 0 && mig.Version != only {
+			continue
+		}
+		mig := mig
+		err := m.run(ctx, conn, mig.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+				mig.Version, mig.Name, mig.Checksum)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("apply %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// down membatalkan steps migrasi terakhir (semua jika steps <= 0).
+// Pemanggil harus memegang lock.
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == "" {
+			return done, fmt.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+		}
+		err := m.run(ctx, conn, mig.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("revert %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Status mengembalikan keadaan setiap migrasi.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			s := Status{Version: mig.Version, Name: mig.Name}
+			if a, ok := applied[mig.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = a.appliedAt
+				s.Modified = a.checksum != mig.Checksum
+			}
+			statuses = append(statuses, s)
+		}
+		return nil
+	})
+	return statuses, err
+}
@@ -0,0 +1,160 @@
+// Package password membuat dan memverifikasi hash password.
+//
+// Hash disimpan dalam format PHC string sehingga setiap hash menyimpan
+// algoritma dan parameternya sendiri, misalnya:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+//
+// Hash bcrypt lama ($2a$, $2b$, $2y$) tetap bisa diverifikasi, dan
+// NeedsRehash memberi tahu kapan hash perlu dibuat ulang.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrUnknownHash dikembalikan jika format hash tidak dikenal
+	ErrUnknownHash = errors.New("unknown password hash format")
+	// ErrInvalidHash dikembalikan jika hash dikenal tapi rusak
+	ErrInvalidHash = errors.New("invalid password hash")
+)
+
+// Hasher membuat dan memverifikasi hash password.
+type Hasher interface {
+	// Hash membuat hash baru dengan algoritma dan parameter saat ini
+	Hash(password string) (string, error)
+	// Verify mengecek password terhadap hash yang tersimpan
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash bernilai true jika hash memakai algoritma atau
+	// parameter yang berbeda dari saat ini
+	NeedsRehash(encoded string) bool
+}
+
+// Params adalah parameter Argon2id.
+type Params struct {
+	Memory      uint32 // dalam KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams mengikuti rekomendasi OWASP untuk Argon2id.
+func DefaultParams() Params {
+	return Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Argon2id adalah Hasher default. Verify juga menerima hash bcrypt lama,
+// dan NeedsRehash selalu bernilai true untuk hash tersebut.
+type Argon2id struct {
+	params Params
+}
+
+// New membuat Hasher Argon2id dengan parameter p.
+func New(p Params) *Argon2id {
+	return &Argon2id{params: p}
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+	return encodeArgon2id(a.params, salt, key), nil
+}
+
+func (a *Argon2id) Verify(password, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		p, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		}
+		return true, nil
+	default:
+		return false, ErrUnknownHash
+	}
+}
+
+func (a *Argon2id) NeedsRehash(encoded string) bool {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.Memory != a.params.Memory ||
+		p.Iterations != a.params.Iterations ||
+		p.Parallelism != a.params.Parallelism ||
+		uint32(len(salt)) != a.params.SaltLength ||
+		uint32(len(key)) != a.params.KeyLength
+}
+
+func isBcrypt(encoded string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encoded, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeArgon2id membuat PHC string, salt dan hash memakai base64 tanpa padding
+func encodeArgon2id(p Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	var p Params
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil || p.Iterations == 0 || p.Parallelism == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createAccessToken membuat personal access token dan mengembalikan token dan ID-nya
+func createAccessToken(t *testing.T, app *TestApp, token string, body map[string]interface{}) (string, int) {
+	t.Helper()
+	resp, result := doRequest(t, app, "POST", "/tokens", token, body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 for create token but got %d: %v", resp.StatusCode, result)
+	}
+	data := result["data"].(map[string]interface{})
+	return data["token"].(string), int(data["id"].(float64))
+}
+
+// TestAccessTokenScopes: personal access token hanya bisa mengakses route sesuai scope
+func TestAccessTokenScopes(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "pat")
+	session := user["token"].(string)
+	createTestTask(t, app, session, map[string]string{"title": "Task for token", "description": "d", "status": "pending"})
+
+	pat, _ := createAccessToken(t, app, session, map[string]interface{}{"name": "ci", "scopes": []string{"tasks:read"}})
+	if !strings.HasPrefix(pat, "pat_") {
+		t.Errorf("Expected token with pat_ prefix, got %q", pat)
+	}
+
+	resp, result := doRequest(t, app, "GET", "/tasks", pat, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for tasks:read but got %d: %v", resp.StatusCode, result)
+	}
+	resp, _ = doRequest(t, app, "POST", "/tasks", pat, map[string]string{"title": "x", "description": "d", "status": "pending"})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 without tasks:write but got %d", resp.StatusCode)
+	}
+	resp, _ = doRequest(t, app, "GET", fmt.Sprintf("/users/%d", int(user["user_id"].(float64))), pat, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 without users:read but got %d", resp.StatusCode)
+	}
+
+	// token tidak bisa membuat token lain atau logout
+	resp, _ = doRequest(t, app, "POST", "/tokens", pat, map[string]interface{}{"name": "x", "scopes": []string{"tasks:write"}})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 when creating token with token but got %d", resp.StatusCode)
+	}
+	resp, _ = doRequest(t, app, "POST", "/logout", pat, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for logout with token but got %d", resp.StatusCode)
+	}
+
+	// tasks:write juga memberi akses baca
+	writer, _ := createAccessToken(t, app, session, map[string]interface{}{"name": "writer", "scopes": []string{"tasks:write"}})
+	resp, _ = doRequest(t, app, "POST", "/tasks", writer, map[string]string{"title": "From token", "description": "d", "status": "pending"})
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected task to be created with tasks:write but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", writer, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for tasks:write reading tasks but got %d", resp.StatusCode)
+	}
+}
+
+// TestAccessTokenLifecycle: token tampil di daftar dengan last_used_at dan bisa dicabut
+func TestAccessTokenLifecycle(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "patlife")
+	other := CreateTestUser(app, t, "patother")
+	session := user["token"].(string)
+
+	pat, id := createAccessToken(t, app, session, map[string]interface{}{"name": "script", "scopes": []string{"tasks:read", "tasks:read"}})
+
+	resp, result := doRequest(t, app, "GET", "/tokens", session, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for list but got %d", resp.StatusCode)
+	}
+	tokens := result["data"].([]interface{})
+	if len(tokens) != 1 {
+		t.Fatalf("Expected 1 token, got %d", len(tokens))
+	}
+	listed := tokens[0].(map[string]interface{})
+	if listed["token"] != nil || listed["token_hash"] != nil {
+		t.Errorf("Expected token secret not to be listed, got %v", listed)
+	}
+	if listed["last_used_at"] != nil {
+		t.Errorf("Expected last_used_at to be empty before use")
+	}
+	if scopes := listed["scopes"].([]interface{}); len(scopes) != 1 {
+		t.Errorf("Expected duplicate scopes to be removed, got %v", scopes)
+	}
+
+	doRequest(t, app, "GET", "/tasks", pat, nil)
+	_, result = doRequest(t, app, "GET", "/tokens", session, nil)
+	if result["data"].([]interface{})[0].(map[string]interface{})["last_used_at"] == nil {
+		t.Errorf("Expected last_used_at to be set after use")
+	}
+
+	// user lain tidak bisa mencabut token ini
+	resp, _ = doRequest(t, app, "DELETE", fmt.Sprintf("/tokens/%d", id), other["token"].(string), nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for other user's token but got %d", resp.StatusCode)
+	}
+	resp, _ = doRequest(t, app, "DELETE", fmt.Sprintf("/tokens/%d", id), session, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for revoke but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", pat, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for revoked token but got %d", resp.StatusCode)
+	}
+}
+
+// TestAccessTokenValidation: scope tidak dikenal dan token kadaluarsa ditolak
+func TestAccessTokenValidation(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "patvalid")
+	session := user["token"].(string)
+
+	for _, body := range []map[string]interface{}{
+		{"name": "bad", "scopes": []string{"tasks:admin"}},
+		{"name": "none", "scopes": []string{}},
+		{"name": "past", "scopes": []string{"tasks:read"}, "expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	} {
+		if resp, _ := doRequest(t, app, "POST", "/tokens", session, body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %v but got %d", body, resp.StatusCode)
+		}
+	}
+
+	// token kadaluarsa dibuat langsung lewat service karena API menolak expires_at di masa lalu
+	expired := time.Now().Add(-time.Minute)
+	pat, _, err := app.Deps.AccessTokens.Create(context.Background(), int(user["user_id"].(float64)), "expired", []string{"tasks:read"}, &expired)
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", pat, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for expired token but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", "pat_unknown", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for unknown token but got %d", resp.StatusCode)
+	}
+}
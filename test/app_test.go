@@ -0,0 +1,46 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestIsolatedApps: setiap TestApp memiliki dependency sendiri sehingga
+// bisa berjalan paralel tanpa saling mempengaruhi
+func TestIsolatedApps(t *testing.T) {
+	if testDB != nil {
+		t.Skip("apps share the same database when TEST_DB=postgres")
+	}
+
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			app := CreateTestApp(t)
+
+			// username yang sama bisa didaftarkan di kedua aplikasi
+			body, _ := json.Marshal(map[string]string{
+				"username": "isolated",
+				"email":    "isolated@example.com",
+				"password": "secret123",
+			})
+			req := httptest.NewRequest("POST", "/register", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Register request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200 in isolated app, got %d", resp.StatusCode)
+			}
+
+			users, err := app.Deps.Users.List(req.Context())
+			if err != nil || len(users) != 1 {
+				t.Errorf("Expected exactly one user in isolated app, got %d (%v)", len(users), err)
+			}
+		})
+	}
+}
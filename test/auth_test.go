@@ -11,7 +11,7 @@ import (
 )
 
 func TestRegister(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	uniqueUsername := fmt.Sprintf("testuser_%d", time.Now().UnixNano())
 	reqBody := map[string]string{
@@ -45,7 +45,7 @@ func TestRegister(t *testing.T) {
 }
 
 func TestLogin(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	// Pastikan user yang akan di-login sudah terdaftar.
 	// Jika perlu, register terlebih dahulu
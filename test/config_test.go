@@ -0,0 +1,185 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	validEmailLinkSecret = "config-test-jwt-secret-0123456789abcdef"
+	validEncryptionKey   = testEncryptionKeys
+)
+
+// writeConfigFile menulis file konfigurasi sementara dan mengembalikan path-nya
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Cannot write config file: %v", err)
+	}
+	return path
+}
+
+// expectProblems memastikan error validasi berisi semua pesan yang diharapkan
+func expectProblems(t *testing.T, err error, problems ...string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Expected configuration error")
+	}
+	for _, p := range problems {
+		if !strings.Contains(err.Error(), p) {
+			t.Errorf("Expected %q in error, got:\n%v", p, err)
+		}
+	}
+}
+
+func TestLoadConfigRequiresSecrets(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", "")
+	t.Setenv("JWT_KEY_FILES", "")
+	t.Setenv("ENCRYPTION_KEYS", "")
+
+	_, err := configs.Load(nil)
+	expectProblems(t, err, "EMAIL_LINK_SECRET is required", "JWT_KEY_FILES is required", "ENCRYPTION_KEYS is required")
+
+	var verr *configs.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *configs.ValidationError but got %T", err)
+	}
+	if len(verr.Problems) != 3 {
+		t.Errorf("Expected 3 problems but got %d: %v", len(verr.Problems), verr.Problems)
+	}
+}
+
+func TestLoadConfigRejectsInsecureDefaults(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", "secret")
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+	t.Setenv("ENCRYPTION_KEYS", validEncryptionKey)
+
+	_, err := configs.Load(nil)
+	expectProblems(t, err, "EMAIL_LINK_SECRET is set to an insecure default value")
+
+	t.Setenv("EMAIL_LINK_SECRET", "too-short")
+	_, err = configs.Load(nil)
+	expectProblems(t, err, "EMAIL_LINK_SECRET must be at least")
+}
+
+func TestLoadConfigInvalidKeyring(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", validEmailLinkSecret)
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+
+	for keys, problem := range map[string]string{
+		"MySecretEncryptionKey!":          "expected id:base64key",
+		"k1:c2hvcnQ=":                     "must be 32 bytes",
+		"k1:not base64":                   "not valid base64",
+		validEncryptionKey + ",test:abcd": "duplicate key ID",
+	} {
+		t.Setenv("ENCRYPTION_KEYS", keys)
+		_, err := configs.Load(nil)
+		expectProblems(t, err, problem)
+	}
+
+	t.Setenv("ENCRYPTION_KEYS", validEncryptionKey)
+	t.Setenv("ENCRYPTION_KEY_ID", "missing")
+	_, err := configs.Load(nil)
+	expectProblems(t, err, `primary key "missing" is not in the keyring`)
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", validEmailLinkSecret)
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+	t.Setenv("ENCRYPTION_KEYS", validEncryptionKey)
+
+	cfg, err := configs.Load(nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Port != 3004 || cfg.CORSAllowOrigins != "*" {
+		t.Errorf("Unexpected port/CORS defaults: %d %q", cfg.Port, cfg.CORSAllowOrigins)
+	}
+	if cfg.RateLimitMax != 100 || cfg.RateLimitWindow != time.Minute {
+		t.Errorf("Unexpected limiter defaults: %d %v", cfg.RateLimitMax, cfg.RateLimitWindow)
+	}
+	if cfg.AccessTokenTTL != time.Hour || cfg.UploadMaxSize != 5<<20 {
+		t.Errorf("Unexpected token/upload defaults: %v %d", cfg.AccessTokenTTL, cfg.UploadMaxSize)
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	file := writeConfigFile(t, "config.yaml", `
+port: 8000
+rate_limit_max: 10
+upload_dir: /tmp/from-file
+email_link_secret: `+validEmailLinkSecret+`
+encryption_keys: `+validEncryptionKey+`
+`)
+	t.Setenv("EMAIL_LINK_SECRET", "")
+	t.Setenv("ENCRYPTION_KEYS", "")
+	t.Setenv("RATE_LIMIT_MAX", "")
+	t.Setenv("UPLOAD_DIR", "")
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+	t.Setenv("PORT", "9000")
+
+	cfg, err := configs.Load([]string{"-config", file, "-port", "9100", "-access-token-ttl", "15m"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// flag menimpa env, env menimpa file, file menimpa default
+	if cfg.Port != 9100 {
+		t.Errorf("Expected port from flag 9100 but got %d", cfg.Port)
+	}
+	if cfg.RateLimitMax != 10 || cfg.UploadDir != "/tmp/from-file" || cfg.EmailLinkSecret != validEmailLinkSecret {
+		t.Errorf("Expected values from config file, got %d %q", cfg.RateLimitMax, cfg.UploadDir)
+	}
+	if cfg.AccessTokenTTL != 15*time.Minute {
+		t.Errorf("Expected access token TTL from flag 15m but got %v", cfg.AccessTokenTTL)
+	}
+
+	t.Setenv("PORT", "9000")
+	cfg, err = configs.Load([]string{"-config", file})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Port != 9000 {
+		t.Errorf("Expected port from env 9000 but got %d", cfg.Port)
+	}
+}
+
+func TestLoadConfigTOMLFile(t *testing.T) {
+	file := writeConfigFile(t, "config.toml", `
+port = 7000
+cors_allow_origins = "https://app.example.com"
+refresh_token_ttl = "48h"
+email_link_secret = "`+validEmailLinkSecret+`"
+encryption_keys = "`+validEncryptionKey+`"
+`)
+	t.Setenv("EMAIL_LINK_SECRET", "")
+	t.Setenv("ENCRYPTION_KEYS", "")
+	t.Setenv("PORT", "")
+	t.Setenv("CORS_ALLOW_ORIGINS", "")
+	t.Setenv("REFRESH_TOKEN_TTL", "")
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+	t.Setenv("CONFIG_FILE", file)
+
+	cfg, err := configs.Load(nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Port != 7000 || cfg.CORSAllowOrigins != "https://app.example.com" || cfg.RefreshTokenTTL != 48*time.Hour {
+		t.Errorf("Unexpected values from TOML file: %+v", cfg)
+	}
+}
+
+func TestLoadConfigInvalidValue(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", validEmailLinkSecret)
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+	t.Setenv("ENCRYPTION_KEYS", validEncryptionKey)
+	t.Setenv("ACCESS_TOKEN_TTL", "one hour")
+
+	_, err := configs.Load(nil)
+	expectProblems(t, err, "ACCESS_TOKEN_TTL")
+}
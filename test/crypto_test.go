@@ -0,0 +1,152 @@
+package test
+
+import (
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"belajar-go/internal/service"
+	"belajar-go/pkg/crypto"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	oldKeyring = "old:b2xkLWtleS0wMTIzNDU2Nzg5YWJjZGVmMDEyMzQ1Njc="
+	newKeyring = "new:bmV3LWtleS0wMTIzNDU2Nzg5YWJjZGVmMDEyMzQ1Njc=," + oldKeyring
+)
+
+func mustKeyring(t *testing.T, spec, primary, legacy string) *crypto.Keyring {
+	t.Helper()
+	k, err := crypto.ParseKeyring(spec, primary, legacy)
+	if err != nil {
+		t.Fatalf("ParseKeyring error: %v", err)
+	}
+	return k
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	k := mustKeyring(t, newKeyring, "", "")
+
+	ciphertext, err := k.Encrypt("12345")
+	if err != nil {
+		t.Fatalf("Encrypt error: %v", err)
+	}
+	if !strings.HasPrefix(ciphertext, "v1:new:") {
+		t.Errorf("Expected versioned ciphertext with key ID, got %q", ciphertext)
+	}
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil || plaintext != "12345" {
+		t.Errorf("Expected 12345 but got %q (%v)", plaintext, err)
+	}
+}
+
+func TestKeyringRejectsTampering(t *testing.T) {
+	k := mustKeyring(t, newKeyring, "", "")
+	ciphertext, _ := k.Encrypt("12345")
+
+	// ubah satu karakter payload
+	tampered := []byte(ciphertext)
+	i := len(tampered) - 5
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	if _, err := k.Decrypt(string(tampered)); !errors.Is(err, crypto.ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for tampered ciphertext, got %v", err)
+	}
+
+	// key ID ikut diautentikasi, menukar key ID membuat dekripsi gagal
+	swapped := strings.Replace(ciphertext, "v1:new:", "v1:old:", 1)
+	if _, err := k.Decrypt(swapped); !errors.Is(err, crypto.ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for swapped key ID, got %v", err)
+	}
+
+	if _, err := k.Decrypt("v1:other:" + strings.SplitN(ciphertext, ":", 3)[2]); !errors.Is(err, crypto.ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+	if _, err := k.Decrypt("not-base64!"); !errors.Is(err, crypto.ErrInvalidCiphertext) {
+		t.Errorf("Expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	legacyKey := "MySecretEncryptionKey!"
+	before := mustKeyring(t, oldKeyring, "", "")
+	after := mustKeyring(t, newKeyring, "", legacyKey)
+
+	oldCiphertext, _ := before.Encrypt("from-old-key")
+	legacyCiphertext, _ := crypto.Encrypt("from-legacy", legacyKey)
+
+	// semua key di keyring dan legacy key tetap bisa didekripsi
+	for ciphertext, want := range map[string]string{oldCiphertext: "from-old-key", legacyCiphertext: "from-legacy"} {
+		got, err := after.Decrypt(ciphertext)
+		if err != nil || got != want {
+			t.Errorf("Expected %q but got %q (%v)", want, got, err)
+		}
+		if !after.NeedsRotation(ciphertext) {
+			t.Errorf("Expected %q to need rotation", ciphertext)
+		}
+		rotated, changed, err := after.Rotate(ciphertext)
+		if err != nil || !changed || !strings.HasPrefix(rotated, "v1:new:") {
+			t.Errorf("Expected rotation to new key, got %q %v %v", rotated, changed, err)
+		}
+		if _, changed, _ := after.Rotate(rotated); changed {
+			t.Errorf("Expected already rotated ciphertext to be left unchanged")
+		}
+	}
+}
+
+func TestReencryptTasks(t *testing.T) {
+	ctx := context.Background()
+	legacyKey := "MySecretEncryptionKey!"
+	before := mustKeyring(t, oldKeyring, "", "")
+	after := mustKeyring(t, newKeyring, "", legacyKey)
+	repo := repository.NewMemoryTaskRepository()
+
+	var ids []int
+	for i := 0; i < 5; i++ {
+		code, _ := before.Encrypt("code")
+		if i%2 == 0 {
+			code, _ = crypto.Encrypt("code", legacyKey)
+		}
+		task := models.Task{UserID: 1, Title: "t", Status: "pending", SecurityCode: code}
+		if err := repo.Create(ctx, &task); err != nil {
+			t.Fatalf("Create error: %v", err)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	// proses sebagian (resume setelah task kedua), lalu jalankan ulang dari awal
+	result, err := service.ReencryptTasks(ctx, repo, after, ids[1], 2, nil)
+	if err != nil || result.Updated != 3 || result.LastID != ids[4] {
+		t.Fatalf("Unexpected result %+v (%v)", result, err)
+	}
+	batches := 0
+	result, err = service.ReencryptTasks(ctx, repo, after, 0, 2, func(repository.ReencryptBatch) { batches++ })
+	if err != nil || result.Scanned != 5 || result.Updated != 2 || batches != 3 {
+		t.Fatalf("Unexpected result %+v after %d batches (%v)", result, batches, err)
+	}
+
+	for _, id := range ids {
+		task, _ := repo.GetByID(ctx, id)
+		if after.NeedsRotation(task.SecurityCode) {
+			t.Errorf("Task %d was not re-encrypted: %q", id, task.SecurityCode)
+		}
+		if code, err := after.Decrypt(task.SecurityCode); err != nil || code != "code" {
+			t.Errorf("Task %d decrypts to %q (%v)", id, code, err)
+		}
+	}
+
+	// ciphertext yang rusak menghentikan proses tanpa mengubah batch tersebut
+	broken := models.Task{UserID: 1, Title: "t", Status: "pending", SecurityCode: "v1:new:broken"}
+	repo.Create(ctx, &broken)
+	if _, err := service.ReencryptTasks(ctx, repo, mustKeyring(t, oldKeyring+",new:bmV3LWtleS0wMTIzNDU2Nzg5YWJjZGVmMDEyMzQ1Njc=", "", ""), 0, 10, nil); err == nil {
+		t.Errorf("Expected error for broken ciphertext")
+	}
+	task, _ := repo.GetByID(ctx, ids[0])
+	if !strings.HasPrefix(task.SecurityCode, "v1:new:") {
+		t.Errorf("Expected failed batch to be rolled back, got %q", task.SecurityCode)
+	}
+}
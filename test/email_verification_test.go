@@ -0,0 +1,187 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var verifyLinkPattern = regexp.MustCompile(`/api/v1(/email/verify\?\S+)`)
+
+func requireEmailVerification(cfg *configs.Config) {
+	cfg.RequireEmailVerification = true
+}
+
+// lastVerifyPath mengambil path link verifikasi (tanpa prefix /api/v1) dari email terakhir
+func lastVerifyPath(t *testing.T, dir string) string {
+	t.Helper()
+	mails := readMails(t, dir)
+	if len(mails) == 0 {
+		t.Fatalf("Expected a verification email")
+	}
+	match := verifyLinkPattern.FindStringSubmatch(mails[len(mails)-1])
+	if match == nil {
+		t.Fatalf("Expected verification link in email, got:\n%s", mails[len(mails)-1])
+	}
+	return match[1]
+}
+
+// TestEmailVerification: member yang belum verifikasi ditolak sampai membuka link
+func TestEmailVerification(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir), requireEmailVerification)
+	user := CreateTestUser(app, t, "verify")
+	token := user["token"].(string)
+
+	resp, _ := doRequest(t, app, "GET", "/tasks", token, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for unverified member but got %d", resp.StatusCode)
+	}
+
+	path := lastVerifyPath(t, mailDir)
+
+	// link yang diubah ditolak
+	tampered := strings.Replace(path, "sig=", "sig=x", 1)
+	if resp, _ := doRequest(t, app, "GET", tampered, "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for tampered link but got %d", resp.StatusCode)
+	}
+
+	resp, result := doRequest(t, app, "GET", path, "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for verification but got %d: %v", resp.StatusCode, result)
+	}
+
+	// token lama langsung berlaku setelah verifikasi
+	resp, _ = doRequest(t, app, "GET", "/tasks", token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 after verification but got %d", resp.StatusCode)
+	}
+
+	resp, result = doRequest(t, app, "GET", path, "", nil)
+	if resp.StatusCode != http.StatusOK || result["message"] != "Email already verified" {
+		t.Errorf("Expected already verified response, got %d: %v", resp.StatusCode, result)
+	}
+}
+
+// TestEmailVerificationBypass: role dengan email:verification:bypass tidak
+// perlu verifikasi, termasuk admin dan role custom
+func TestEmailVerificationBypass(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir), requireEmailVerification)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+	if resp, _ := doRequest(t, app, "GET", "/tasks", adminToken, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for unverified admin but got %d", resp.StatusCode)
+	}
+
+	user := CreateTestUser(app, t, "bypass")
+	roleName := fmt.Sprintf("reviewer_%d", time.Now().UnixNano())
+	resp, result := doRequest(t, app, "POST", "/roles", adminToken, map[string]interface{}{
+		"name":        roleName,
+		"permissions": []string{"tasks:read:own", "email:verification:bypass"},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating role but got %d: %v", resp.StatusCode, result)
+	}
+	if resp, result := doRequest(t, app, "PUT", fmt.Sprintf("/users/%d/role", int(user["user_id"].(float64))), adminToken, map[string]string{"role": roleName}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 assigning role but got %d: %v", resp.StatusCode, result)
+	}
+	token := loginToken(t, app, user["username"].(string))
+	if resp, _ := doRequest(t, app, "GET", "/tasks", token, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for role with email:verification:bypass but got %d", resp.StatusCode)
+	}
+}
+
+// TestEmailVerificationNotRequired: tanpa REQUIRE_EMAIL_VERIFICATION member tetap bisa mengakses API
+func TestEmailVerificationNotRequired(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir))
+	user := CreateTestUser(app, t, "optional")
+
+	resp, _ := doRequest(t, app, "GET", "/tasks", user["token"].(string), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 but got %d", resp.StatusCode)
+	}
+	// email verifikasi tetap dikirim saat registrasi
+	lastVerifyPath(t, mailDir)
+}
+
+// TestEmailVerificationAfterEmailChange: link untuk email lama tidak berlaku setelah email diganti
+func TestEmailVerificationAfterEmailChange(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir))
+	user := CreateTestUser(app, t, "change")
+	userID := int(user["user_id"].(float64))
+	path := lastVerifyPath(t, mailDir)
+
+	if resp, _ := doRequest(t, app, "GET", path, "", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for verification but got %d", resp.StatusCode)
+	}
+
+	newEmail := user["username"].(string) + "@new.example.com"
+	resp, _ := doRequest(t, app, "PUT", fmt.Sprintf("/users/%d", userID), user["token"].(string), map[string]string{"email": newEmail})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for email change but got %d", resp.StatusCode)
+	}
+	stored, _ := app.Deps.Users.GetByID(context.Background(), userID)
+	if stored.EmailVerifiedAt != nil {
+		t.Errorf("Expected email_verified_at to be cleared after email change")
+	}
+
+	// link lama ditandatangani untuk email lama
+	if resp, _ := doRequest(t, app, "GET", path, "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for link of old email but got %d", resp.StatusCode)
+	}
+}
+
+// TestResendVerificationEmail: kirim ulang dibatasi per alamat email
+func TestResendVerificationEmail(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir))
+	user := CreateTestUser(app, t, "resend")
+	email := user["username"].(string) + "@example.com"
+	before := len(readMails(t, mailDir))
+
+	resp, _ := doRequest(t, app, "POST", "/email/verify/resend", "", map[string]string{"email": email})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for resend but got %d", resp.StatusCode)
+	}
+	if got := len(readMails(t, mailDir)); got != before+1 {
+		t.Errorf("Expected one more email, got %d (was %d)", got, before)
+	}
+
+	resp, _ = doRequest(t, app, "POST", "/email/verify/resend", "", map[string]string{"email": strings.ToUpper(email)})
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 for second resend but got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected Retry-After header")
+	}
+
+	// alamat yang tidak terdaftar mendapat respons yang sama
+	unknown := fmt.Sprintf("nobody_%d@example.com", time.Now().UnixNano())
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp, _ = doRequest(t, app, "POST", "/email/verify/resend", "", map[string]string{"email": unknown})
+		if resp.StatusCode != want {
+			t.Errorf("Request %d for unknown email: expected status %d but got %d", i+1, want, resp.StatusCode)
+		}
+	}
+}
+
+// TestEmailVerificationExpired: link yang sudah kadaluarsa ditolak
+func TestEmailVerificationExpired(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir), func(cfg *configs.Config) {
+		cfg.EmailVerificationTTL = -time.Minute
+	})
+	CreateTestUser(app, t, "expiredverify")
+
+	resp, result := doRequest(t, app, "GET", lastVerifyPath(t, mailDir), "", nil)
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(result["message"].(string), "expired") {
+		t.Errorf("Expected expired link response, got %d: %v", resp.StatusCode, result)
+	}
+}
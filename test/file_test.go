@@ -14,7 +14,7 @@ import (
 )
 
 func TestUploadProfilePicture(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	// Pertama, register dan login agar mendapatkan token
 	{
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// getReadyz memanggil /readyz dan mengembalikan status code beserta body
+func getReadyz(t *testing.T, app *TestApp) (int, map[string]interface{}) {
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Readyz request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Error decoding readyz response: %v", err)
+	}
+	return resp.StatusCode, result
+}
+
+func TestHealthz(t *testing.T) {
+	app := CreateTestApp(t)
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Healthz request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d but got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	app := CreateTestApp(t)
+
+	status, result := getReadyz(t, app)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d but got %d: %v", http.StatusOK, status, result)
+	}
+	if result["status"] != "ready" {
+		t.Errorf("Expected status ready but got %v", result["status"])
+	}
+	deps, ok := result["dependencies"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected dependencies in readyz response")
+	}
+	redisStatus, ok := deps["redis"].(map[string]interface{})
+	if !ok || redisStatus["status"] != "up" {
+		t.Errorf("Expected redis to be up but got %v", deps["redis"])
+	}
+	if testDB != nil {
+		if pg, ok := deps["postgres"].(map[string]interface{}); !ok || pg["status"] != "up" {
+			t.Errorf("Expected postgres to be up but got %v", deps["postgres"])
+		}
+	}
+}
+
+func TestReadyzDependencyDown(t *testing.T) {
+	if testDB != nil {
+		t.Skip("Redis client is shared in postgres mode")
+	}
+	app := CreateTestApp(t)
+	app.Deps.Redis.Close()
+
+	status, result := getReadyz(t, app)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d but got %d", http.StatusServiceUnavailable, status)
+	}
+	if result["status"] != "not_ready" {
+		t.Errorf("Expected status not_ready but got %v", result["status"])
+	}
+	deps := result["dependencies"].(map[string]interface{})
+	redisStatus := deps["redis"].(map[string]interface{})
+	if redisStatus["status"] != "down" {
+		t.Errorf("Expected redis to be down but got %v", redisStatus)
+	}
+}
+
+func TestReadyzWhileDraining(t *testing.T) {
+	app := CreateTestApp(t)
+	app.Deps.Health.SetDraining()
+
+	status, result := getReadyz(t, app)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d but got %d", http.StatusServiceUnavailable, status)
+	}
+	if result["status"] != "draining" {
+		t.Errorf("Expected status draining but got %v", result["status"])
+	}
+
+	// liveness tetap OK selama draining
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Healthz request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected healthz status %d but got %d", http.StatusOK, resp.StatusCode)
+	}
+}
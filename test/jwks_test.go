@@ -0,0 +1,246 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"belajar-go/pkg/jwk"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writePrivateKey menyimpan private key sebagai PEM PKCS#8
+func writePrivateKey(path string, key interface{}) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+}
+
+// writeTestJWTKey membuat key Ed25519 di dir dan mengembalikan entry
+// JWT_KEY_FILES-nya ("kid:path")
+func writeTestJWTKey(dir, kid string) (string, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, kid+".pem")
+	return kid + ":" + path, writePrivateKey(path, key)
+}
+
+// signTestToken menandatangani claims dengan key dan kid tertentu
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Cannot sign token: %v", err)
+	}
+	return signed
+}
+
+// TestJWKS: access token ditandatangani EdDSA dengan kid dan claim standar,
+// dan public key-nya tersedia di /.well-known/jwks.json
+func TestJWKS(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "jwks")
+
+	resp, result := doRequest(t, app, "GET", "/.well-known/jwks.json", "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for JWKS but got %d", resp.StatusCode)
+	}
+	keys, _ := result["keys"].([]interface{})
+	if len(keys) != 1 {
+		t.Fatalf("Expected 1 key in JWKS, got %v", result)
+	}
+	key := keys[0].(map[string]interface{})
+	if key["kid"] != "test" || key["kty"] != "OKP" || key["crv"] != "Ed25519" || key["alg"] != "EdDSA" || key["x"] == "" {
+		t.Errorf("Unexpected JWK: %v", key)
+	}
+	if _, ok := key["d"]; ok {
+		t.Errorf("JWKS must not contain private key material")
+	}
+
+	claims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(user["token"].(string), claims)
+	if err != nil {
+		t.Fatalf("Cannot parse token: %v", err)
+	}
+	if token.Header["kid"] != "test" || token.Method.Alg() != "EdDSA" {
+		t.Errorf("Unexpected token header: %v", token.Header)
+	}
+	sub := strconv.Itoa(int(user["user_id"].(float64)))
+	if claims["iss"] != "belajar-go" || !claims.VerifyAudience("belajar-go-api", true) || claims["sub"] != sub {
+		t.Errorf("Unexpected standard claims: %v", claims)
+	}
+	if claims["nbf"] == nil || claims["iat"] == nil {
+		t.Errorf("Expected nbf and iat claims: %v", claims)
+	}
+	if claims["typ"] != "access" || claims["sid"] == nil || claims["sid"] == "" {
+		t.Errorf("Expected token type and session ID claims: %v", claims)
+	}
+}
+
+// TestJWTKeyRotation: token baru memakai key aktif (RS256), token dari key
+// lama yang masih ada di key set tetap diterima, dan token lain ditolak
+func TestJWTKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Cannot generate RSA key: %v", err)
+	}
+	if err := writePrivateKey(filepath.Join(dir, "new.pem"), rsaKey); err != nil {
+		t.Fatalf("Cannot write RSA key: %v", err)
+	}
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	if err := writePrivateKey(filepath.Join(dir, "old.pem"), oldKey); err != nil {
+		t.Fatalf("Cannot write Ed25519 key: %v", err)
+	}
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	app := CreateTestApp(t, func(cfg *configs.Config) {
+		cfg.JWTKeyFiles = "old:" + filepath.Join(dir, "old.pem") + ",new:" + filepath.Join(dir, "new.pem")
+		cfg.JWTKeyID = "new"
+	})
+	user := CreateTestUser(app, t, "rotation")
+	userID := int(user["user_id"].(float64))
+
+	token, _, err := jwt.NewParser().ParseUnverified(user["token"].(string), jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("Cannot parse token: %v", err)
+	}
+	if token.Header["kid"] != "new" || token.Method.Alg() != "RS256" {
+		t.Errorf("Expected token signed with the active RS256 key, got %v", token.Header)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", user["token"].(string), nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for RS256 token but got %d", resp.StatusCode)
+	}
+
+	_, result := doRequest(t, app, "GET", "/.well-known/jwks.json", "", nil)
+	if keys, _ := result["keys"].([]interface{}); len(keys) != 2 {
+		t.Errorf("Expected old and new key in JWKS, got %v", result)
+	}
+
+	claims := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		now := time.Now()
+		c := jwt.MapClaims{
+			"iss":     "belajar-go",
+			"aud":     "belajar-go-api",
+			"sub":     strconv.Itoa(userID),
+			"user_id": userID,
+			"role":    "member",
+			"typ":     "access",
+			"jti":     "jti-" + strconv.FormatInt(now.UnixNano(), 10),
+			"iat":     now.Unix(),
+			"nbf":     now.Unix(),
+			"exp":     now.Add(time.Minute).Unix(),
+		}
+		if change != nil {
+			change(c)
+		}
+		return c
+	}
+	hmacKey, _ := os.ReadFile(filepath.Join(dir, "old.pem"))
+
+	for name, tc := range map[string]struct {
+		token string
+		want  int
+	}{
+		"old key":        {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(nil)), http.StatusOK},
+		"unknown kid":    {signTestToken(t, jwt.SigningMethodEdDSA, "other", otherKey, claims(nil)), http.StatusUnauthorized},
+		"wrong key":      {signTestToken(t, jwt.SigningMethodEdDSA, "old", otherKey, claims(nil)), http.StatusUnauthorized},
+		"HS256 with kid": {signTestToken(t, jwt.SigningMethodHS256, "old", hmacKey, claims(nil)), http.StatusUnauthorized},
+		"wrong issuer": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["iss"] = "someone-else"
+		})), http.StatusUnauthorized},
+		"wrong audience": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["aud"] = "other-api"
+		})), http.StatusUnauthorized},
+		"not yet valid": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["nbf"] = time.Now().Add(time.Hour).Unix()
+		})), http.StatusUnauthorized},
+		"missing nbf": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			delete(c, "nbf")
+		})), http.StatusUnauthorized},
+		"refresh token type": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["typ"] = "refresh"
+		})), http.StatusUnauthorized},
+		"nbf within leeway": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["nbf"] = time.Now().Add(10 * time.Second).Unix()
+			c["iat"] = c["nbf"]
+		})), http.StatusOK},
+		"expired within leeway": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["exp"] = time.Now().Add(-10 * time.Second).Unix()
+		})), http.StatusOK},
+		"expired": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["exp"] = time.Now().Add(-time.Minute).Unix()
+		})), http.StatusUnauthorized},
+		"other subject": {signTestToken(t, jwt.SigningMethodEdDSA, "old", oldKey, claims(func(c jwt.MapClaims) {
+			c["sub"] = "0"
+		})), http.StatusUnauthorized},
+	} {
+		if resp, _ := doRequest(t, app, "GET", "/tasks", tc.token, nil); resp.StatusCode != tc.want {
+			t.Errorf("%s: expected status %d but got %d", name, tc.want, resp.StatusCode)
+		}
+	}
+}
+
+// TestParseKeySet: konfigurasi JWT_KEY_FILES yang salah ditolak
+func TestParseKeySet(t *testing.T) {
+	dir := t.TempDir()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	if err := writePrivateKey(filepath.Join(dir, "a.pem"), key); err != nil {
+		t.Fatalf("Cannot write key: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(key.Public())
+	if err := os.WriteFile(filepath.Join(dir, "a.pub.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Cannot write public key: %v", err)
+	}
+	small, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if err := writePrivateKey(filepath.Join(dir, "small.pem"), small); err != nil {
+		t.Fatalf("Cannot write RSA key: %v", err)
+	}
+	a, pub, smallPath := filepath.Join(dir, "a.pem"), filepath.Join(dir, "a.pub.pem"), filepath.Join(dir, "small.pem")
+
+	// public key saja boleh untuk key lama, asal ada private key aktif
+	set, err := jwk.ParseKeySet("old:"+pub+", new:"+a, "")
+	if err != nil {
+		t.Fatalf("ParseKeySet error: %v", err)
+	}
+	if set.Signing().ID != "new" {
+		t.Errorf("Expected first private key to be active, got %q", set.Signing().ID)
+	}
+
+	for spec, activeID := range map[string]string{
+		a:                         "",
+		"a:" + dir + "/missing":   "",
+		"a:" + pub:                "",
+		"a:" + a + ",a:" + a:      "",
+		"a:" + a:                  "b",
+		"a:" + smallPath:          "",
+		"a b:" + a:                "",
+		"old:" + pub + ",a:" + a:  "old",
+		"a:" + filepath.Join(dir): "",
+	} {
+		if _, err := jwk.ParseKeySet(spec, activeID); err == nil {
+			t.Errorf("Expected error for %q (active %q)", spec, activeID)
+		}
+	}
+
+	t.Setenv("EMAIL_LINK_SECRET", validEmailLinkSecret)
+	t.Setenv("ENCRYPTION_KEYS", validEncryptionKey)
+	t.Setenv("JWT_KEY_FILES", "a:"+pub)
+	_, err = configs.Load(nil)
+	expectProblems(t, err, "JWT_KEY_FILES: key set needs a private key to sign tokens")
+}
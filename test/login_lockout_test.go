@@ -0,0 +1,142 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// tanpa backoff agar percobaan gagal bisa dikirim berturut-turut
+func withoutLoginBackoff(cfg *configs.Config) {
+	cfg.LoginBackoffBase = 0
+	cfg.LoginMaxFailures = 3
+}
+
+func login(t *testing.T, app *TestApp, username, password string) *http.Response {
+	t.Helper()
+	resp, _ := doRequest(t, app, "POST", "/login", "", map[string]string{"username": username, "password": password})
+	return resp
+}
+
+// TestLoginBackoff: setelah kegagalan kedua login ditolak sementara, termasuk dengan password benar
+func TestLoginBackoff(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "backoff")
+	username := user["username"].(string)
+
+	// kegagalan pertama tidak diberi jeda
+	if resp := login(t, app, username, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 but got %d", resp.StatusCode)
+	}
+	if resp := login(t, app, username, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 but got %d", resp.StatusCode)
+	}
+
+	resp := login(t, app, username, "password123")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 during backoff but got %d", resp.StatusCode)
+	}
+	if retry, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || retry < 1 {
+		t.Errorf("Expected Retry-After header, got %q", resp.Header.Get("Retry-After"))
+	}
+}
+
+// TestLoginLockout: akun dikunci setelah LOGIN_MAX_FAILURES dan dibuka oleh admin
+func TestLoginLockout(t *testing.T) {
+	app := CreateTestApp(t, withoutLoginBackoff)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+	user := CreateTestUser(app, t, "lockout")
+	username := user["username"].(string)
+	userID := int(user["user_id"].(float64))
+	memberToken := user["token"].(string)
+
+	for i := 0; i < 3; i++ {
+		if resp := login(t, app, username, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status 401 but got %d", i+1, resp.StatusCode)
+		}
+	}
+
+	stored, err := app.Deps.Users.GetByID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetByID error: %v", err)
+	}
+	if stored.LockedUntil == nil || !stored.LockedUntil.After(time.Now()) {
+		t.Fatalf("Expected locked_until in the future, got %v", stored.LockedUntil)
+	}
+
+	// password benar tetap ditolak selama akun dikunci
+	resp := login(t, app, username, "password123")
+	if resp.StatusCode != http.StatusLocked {
+		t.Fatalf("Expected status 423 for locked account but got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected Retry-After header for locked account")
+	}
+
+	// hanya admin yang boleh membuka kunci
+	unlockPath := fmt.Sprintf("/users/%d/unlock", userID)
+	if resp, _ := doRequest(t, app, "POST", unlockPath, memberToken, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for member unlock but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "POST", "/users/999999/unlock", adminToken, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown user but got %d", resp.StatusCode)
+	}
+	if resp, result := doRequest(t, app, "POST", unlockPath, adminToken, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for admin unlock but got %d: %v", resp.StatusCode, result)
+	}
+
+	if resp := login(t, app, username, "password123"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 after unlock but got %d", resp.StatusCode)
+	}
+}
+
+// TestLoginExpiredLockout: lockout yang sudah lewat tidak menghalangi login dan dihapus
+func TestLoginExpiredLockout(t *testing.T) {
+	app := CreateTestApp(t)
+	ctx := context.Background()
+	user := CreateTestUser(app, t, "expired")
+	userID := int(user["user_id"].(float64))
+
+	past := time.Now().Add(-time.Minute)
+	if err := app.Deps.Users.SetLockedUntil(ctx, userID, &past); err != nil {
+		t.Fatalf("SetLockedUntil error: %v", err)
+	}
+	if resp := login(t, app, user["username"].(string), "password123"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 after lockout expired but got %d", resp.StatusCode)
+	}
+	stored, _ := app.Deps.Users.GetByID(ctx, userID)
+	if stored.LockedUntil != nil {
+		t.Errorf("Expected locked_until to be cleared, got %v", stored.LockedUntil)
+	}
+}
+
+// TestLoginIPBlock: terlalu banyak kegagalan dari satu IP memblokir semua username dari IP itu
+func TestLoginIPBlock(t *testing.T) {
+	app := CreateTestApp(t, withoutLoginBackoff, func(cfg *configs.Config) {
+		cfg.LoginIPMaxFailures = 4
+	})
+	user := CreateTestUser(app, t, "ipblock")
+	// Redis dipakai bersama saat TEST_DB=postgres, jadi blokir IP dihapus lagi
+	t.Cleanup(func() {
+		ctx := context.Background()
+		keys, _ := app.Deps.Redis.Keys(ctx, "login_*:ip:*").Result()
+		if len(keys) > 0 {
+			app.Deps.Redis.Del(ctx, keys...)
+		}
+	})
+
+	// username berbeda agar tidak ada akun yang terkunci
+	for i := 0; i < 4; i++ {
+		if resp := login(t, app, fmt.Sprintf("nobody_%d", i), "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status 401 but got %d", i+1, resp.StatusCode)
+		}
+	}
+
+	if resp := login(t, app, user["username"].(string), "password123"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 for blocked IP but got %d", resp.StatusCode)
+	}
+}
@@ -2,25 +2,30 @@ package test
 
 import (
 	"belajar-go/configs"
-	"belajar-go/internal/api/v1/handlers"
+	v1 "belajar-go/internal/api/v1"
 	"belajar-go/internal/config"
 	"belajar-go/internal/middleware"
+	"belajar-go/internal/models"
 	"belajar-go/internal/repository"
 	"belajar-go/pkg/database"
 	"belajar-go/pkg/logger"
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func connectDBTest(cfg configs.Config) *sql.DB {
@@ -36,103 +41,176 @@ func connectDBTest(cfg configs.Config) *sql.DB {
 	return db
 }
 
+var (
+	// dependency yang dipakai bersama oleh semua test
+	testLog *logger.Loggers
+	// hanya terisi jika TEST_DB=postgres
+	testDB    *sql.DB
+	testRedis *redis.Client
+	// JWT_KEY_FILES untuk test, dibuat di TestMain
+	testJWTKeyFiles string
+)
+
+// TestMain menjalankan test dengan repository in-memory dan miniredis
+// secara default, sehingga tidak membutuhkan Postgres maupun Redis.
+// Set TEST_DB=postgres untuk menjalankan test terhadap database dan Redis
+// asli yang dikonfigurasi di .env.
 func TestMain(m *testing.M) {
 	// Initialize logger for testing
-	logger.InitLoggers()
+	var err error
+	testLog, err = logger.New("logs")
+	if err != nil {
+		log.Fatalf("Cannot create loggers: %v", err)
+	}
 	// Ensure all loggers are synced at the end
-	defer func() {
-		_ = logger.ErrorLogger.Sync()
-		_ = logger.AuditLogger.Sync()
-		_ = logger.RequestLogger.Sync()
-		_ = logger.SecurityLogger.Sync()
-		_ = logger.SystemLogger.Sync()
-		_ = logger.ContextLogger.Sync()
-	}()
+	defer testLog.Sync()
 
 	// Set GO_ENV to "test" so LoadConfig does not print .env logs
 	os.Setenv("GO_ENV", "test")
 
+	// key Ed25519 untuk access token, dipakai bersama oleh semua test
+	keyDir, err := os.MkdirTemp("", "belajar-go-jwt")
+	if err != nil {
+		log.Fatalf("Cannot create JWT key directory: %v", err)
+	}
+	testJWTKeyFiles, err = writeTestJWTKey(keyDir, "test")
+	if err != nil {
+		log.Fatalf("Cannot create JWT key: %v", err)
+	}
+
+	var code int
+	if os.Getenv("TEST_DB") == "postgres" {
+		code = runWithPostgres(m)
+	} else {
+		code = m.Run()
+	}
+	os.RemoveAll(keyDir)
+	os.Exit(code)
+}
+
+// runWithPostgres menyiapkan database dan Redis asli untuk test
+func runWithPostgres(m *testing.M) int {
 	// Try to load .env (if exists)
 	if err := godotenv.Load(); err != nil {
 		if err := godotenv.Load("../.env"); err != nil {
-			logger.SystemLogger.Info("No .env file found, using default values")
+			testLog.SystemLogger.Info("No .env file found, using default values")
 		} else {
-			logger.SystemLogger.Info(".env file loaded from parent directory")
+			testLog.SystemLogger.Info(".env file loaded from parent directory")
 		}
 	} else {
-		logger.SystemLogger.Info(".env file loaded successfully")
+		testLog.SystemLogger.Info(".env file loaded successfully")
 	}
 
 	// Initialize database for testing
 	cfg := configs.LoadConfig()
-	config.DB = connectDBTest(cfg)
+	testDB = connectDBTest(cfg)
 	// Make sure to defer DB close
-	defer config.DB.Close()
+	defer testDB.Close()
 
-	logger.SystemLogger.Info("Database Connected")
+	testLog.SystemLogger.Info("Database Connected")
 
-	// Create tables if they don't exist (or reset tables for testing)
-	repository.CreateTableIfNotExists(config.DB)
+	// Run migrations so the schema is up to date
+	if _, err := repository.RunMigrations(context.Background(), testDB); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
 
 	// Initialize Redis client
-	config.RedisClient = database.ConnectRedis(cfg)
-	defer config.RedisClient.Close()
+	var err error
+	testRedis, err = database.ConnectRedis(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	defer testRedis.Close()
 
 	// Run all tests
 	code := m.Run()
 
 	// Clean up: delete all tables so the database is empty after tests
-	repository.DeleteAllTable(config.DB)
+	repository.DeleteAllTable(testDB)
 
-	// Exit with the test code
-	os.Exit(code)
+	return code
+}
+
+// TestApp adalah aplikasi Fiber untuk test beserta dependency-nya
+type TestApp struct {
+	*fiber.App
+	Deps *config.App
 }
 
-// createTestApp menginisialisasi aplikasi Fiber dengan route yang akan di-test
-func CreateTestApp() *fiber.App {
+// key AES-256 khusus test (base64 dari 32 byte)
+const testEncryptionKeys = "test:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+// testConfig mengembalikan konfigurasi default dengan secret khusus test
+func testConfig() configs.Config {
+	cfg := configs.Default()
+	cfg.EmailLinkSecret = "test-jwt-secret-0123456789abcdefghijkl"
+	cfg.JWTKeyFiles = testJWTKeyFiles
+	cfg.EncryptionKeys = testEncryptionKeys
+	// parameter Argon2id kecil agar test cepat
+	cfg.Argon2Memory = 1024
+	cfg.Argon2Iterations = 1
+	cfg.Argon2Parallelism = 1
+	return cfg
+}
+
+// newTestDeps membuat dependency baru untuk satu test. Dalam mode in-memory
+// setiap test mendapat repository dan Redis sendiri.
+func newTestDeps(t *testing.T, cfg configs.Config) *config.App {
+	var (
+		db    *sql.DB
+		rdb   *redis.Client
+		repos repository.Repositories
+	)
+	if testDB != nil {
+		db, rdb, repos = testDB, testRedis, repository.NewPostgresRepositories(testDB)
+	} else {
+		redisServer := miniredis.RunT(t)
+		rdb = redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+		t.Cleanup(func() { rdb.Close() })
+		repos = repository.NewMemoryRepositories()
+	}
+
+	deps, err := config.New(cfg, db, rdb, testLog, repos)
+	if err != nil {
+		t.Fatalf("Cannot create app: %v", err)
+	}
+	return deps
+}
+
+// CreateTestApp menginisialisasi aplikasi Fiber dengan route API v1.
+// opts boleh mengubah konfigurasi test sebelum dependency dibuat.
+func CreateTestApp(t *testing.T, opts ...func(*configs.Config)) *TestApp {
+	cfg := testConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	deps := newTestDeps(t, cfg)
 	app := fiber.New()
-	app.Use(middleware.ErrorHandler())
-	app.Post("/register", handlers.Register)
-	app.Post("/login", handlers.Login)
-
-	// Route user (untuk endpoint user)
-	userRoutes := app.Group("/users", middleware.UseToken)
-	userRoutes.Get("/", handlers.GetAllUsers)
-	userRoutes.Get("/:id", handlers.GetUser)
-	userRoutes.Put("/:id", handlers.UpdateUser)
-	userRoutes.Delete("/:id", handlers.DeleteUser)
-
-	// Route upload (jika diperlukan)
-	uploadRoutes := app.Group("/upload", middleware.UseToken)
-	uploadRoutes.Post("/profile_picture", handlers.UploadProfilePicture)
-
-	// Route task
-	taskRoutes := app.Group("/tasks", middleware.UseToken)
-	taskRoutes.Post("/", handlers.CreateTask)
-	taskRoutes.Get("/", handlers.ListTasks)
-	taskRoutes.Get("/:id", handlers.GetTask)
-	taskRoutes.Put("/:id", handlers.UpdateTask)
-	taskRoutes.Delete("/:id", handlers.DeleteTask)
-
-	return app
+	app.Use(middleware.ErrorHandler(deps))
+	v1.RegisterHealthRoutes(app, deps)
+	v1.RegisterWellKnownRoutes(app, deps)
+	v1.RegisterRoutes(app, deps)
+	return &TestApp{App: app, Deps: deps}
 }
 
 // createTestAdmin secara langsung menyisipkan user admin ke database dan login untuk mendapatkan token
-func CreateTestAdmin(app *fiber.App, t *testing.T) (string, int, string) {
+func CreateTestAdmin(app *TestApp, t *testing.T) (string, int, string) {
 	uniqueAdmin := fmt.Sprintf("admin_%d", time.Now().UnixNano())
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("adminpass"), bcrypt.DefaultCost)
+	hashedPassword, err := app.Deps.Passwords.Hash("adminpass")
 	if err != nil {
 		t.Fatalf("Error hashing admin password: %v", err)
 	}
-	var adminID int
 	// Masukkan admin ke database dengan role 'admin'
-	err = config.DB.QueryRow(
-		"INSERT INTO users (username, email, password, role) VALUES ($1, $2, $3, 'admin') RETURNING id",
-		uniqueAdmin, uniqueAdmin+"@example.com", string(hashedPassword),
-	).Scan(&adminID)
-	if err != nil {
+	admin := models.User{
+		Username: uniqueAdmin,
+		Email:    uniqueAdmin + "@example.com",
+		Password: hashedPassword,
+		Role:     "admin",
+	}
+	if err := app.Deps.Users.Create(context.Background(), &admin); err != nil {
 		t.Fatalf("Error inserting admin user: %v", err)
 	}
+	adminID := admin.ID
 
 	// Login admin
 	loginBody := map[string]string{
@@ -164,3 +242,88 @@ func CreateTestAdmin(app *fiber.App, t *testing.T) (string, int, string) {
 	// Kembalikan token, adminID, dan username
 	return token, adminID, uniqueAdmin
 }
+
+// CreateTestUser mendaftarkan user member baru lalu login,
+// mengembalikan isi field "data" dari response login
+func CreateTestUser(app *TestApp, t *testing.T, prefix string) map[string]interface{} {
+	uniqueUser := fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	regBody := map[string]string{
+		"username": uniqueUser,
+		"email":    uniqueUser + "@example.com",
+		"password": "password123",
+	}
+	regJSON, _ := json.Marshal(regBody)
+	regReq := httptest.NewRequest("POST", "/register", bytes.NewReader(regJSON))
+	regReq.Header.Set("Content-Type", "application/json")
+	regResp, err := app.Test(regReq)
+	if err != nil {
+		t.Fatalf("Error registering test user: %v", err)
+	}
+	regResp.Body.Close()
+
+	loginBody := map[string]string{
+		"username": uniqueUser,
+		"password": "password123",
+	}
+	loginJSON, _ := json.Marshal(loginBody)
+	req := httptest.NewRequest("POST", "/login", bytes.NewReader(loginJSON))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Error logging in test user: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var loginResult map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&loginResult); err != nil {
+		t.Fatalf("Error decoding test user login: %v", err)
+	}
+	data, ok := loginResult["data"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected data field in test user login response")
+	}
+	data["username"] = uniqueUser
+	return data
+}
+
+// doRequest mengirim request JSON (body boleh nil) dengan token opsional
+// lalu mengembalikan response beserta body yang sudah di-decode
+func doRequest(t *testing.T, app *TestApp, method, path, token string, body interface{}) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	return resp, result
+}
+
+// createTestTask membuat task lewat API dan mengembalikan ID-nya.
+// Field wajib yang tidak diisi memakai nilai default.
+func createTestTask(t *testing.T, app *TestApp, token string, task map[string]string) int {
+	t.Helper()
+	for field, value := range map[string]string{"title": "Test task", "description": "Test description", "status": "pending", "security_code": "secret"} {
+		if _, ok := task[field]; !ok {
+			task[field] = value
+		}
+	}
+	resp, result := doRequest(t, app, "POST", "/tasks", token, task)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating task, got %d: %v", resp.StatusCode, result)
+	}
+	return int(result["id"].(float64))
+}
@@ -0,0 +1,261 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"belajar-go/internal/service"
+	"belajar-go/pkg/totp"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// enableTOTP mendaftarkan dan mengaktifkan TOTP, mengembalikan secret,
+// nomor periode kode konfirmasi, dan recovery code
+func enableTOTP(t *testing.T, app *TestApp, token string) (string, int64, []string) {
+	t.Helper()
+	resp, result := doRequest(t, app, "POST", "/mfa/totp/enroll", token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for enroll but got %d: %v", resp.StatusCode, result)
+	}
+	data := result["data"].(map[string]interface{})
+	secret := data["secret"].(string)
+	uri := data["otpauth_uri"].(string)
+	if !strings.HasPrefix(uri, "otpauth://totp/") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("Unexpected otpauth URI %q", uri)
+	}
+
+	now := time.Now()
+	code, _ := totp.Code(secret, now)
+	resp, result = doRequest(t, app, "POST", "/mfa/totp/confirm", token, map[string]string{"code": code})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for confirm but got %d: %v", resp.StatusCode, result)
+	}
+	var codes []string
+	for _, c := range result["data"].(map[string]interface{})["recovery_codes"].([]interface{}) {
+		codes = append(codes, c.(string))
+	}
+	return secret, totp.Step(now), codes
+}
+
+// loginChallenge login dengan password dan mengembalikan mfa_token
+func loginChallenge(t *testing.T, app *TestApp, username string) string {
+	t.Helper()
+	resp, result := doRequest(t, app, "POST", "/login", "", map[string]string{"username": username, "password": "password123"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for login but got %d: %v", resp.StatusCode, result)
+	}
+	data := result["data"].(map[string]interface{})
+	if data["mfa_required"] != true || data["token"] != nil {
+		t.Fatalf("Expected mfa challenge without access token, got %v", data)
+	}
+	return data["mfa_token"].(string)
+}
+
+// TestTOTPLogin: login dengan TOTP aktif membutuhkan kode, dan setiap kode hanya bisa dipakai sekali
+func TestTOTPLogin(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "totp")
+	username := user["username"].(string)
+	token := user["token"].(string)
+
+	secret, step, recovery := enableTOTP(t, app, token)
+	if len(recovery) != 10 {
+		t.Fatalf("Expected 10 recovery codes, got %d", len(recovery))
+	}
+
+	// TOTP yang sudah aktif tidak bisa didaftarkan ulang
+	if resp, _ := doRequest(t, app, "POST", "/mfa/totp/enroll", token, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 for second enroll but got %d", resp.StatusCode)
+	}
+
+	// kode yang dipakai saat konfirmasi tidak bisa dipakai ulang
+	mfaToken := loginChallenge(t, app, username)
+	used, _ := totp.CodeAt(secret, step)
+	resp, _ := doRequest(t, app, "POST", "/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": used})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for replayed code but got %d", resp.StatusCode)
+	}
+
+	next, _ := totp.CodeAt(secret, step+1)
+	resp, result := doRequest(t, app, "POST", "/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": next})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for valid code but got %d: %v", resp.StatusCode, result)
+	}
+	if result["data"].(map[string]interface{})["token"] == nil {
+		t.Errorf("Expected access token after mfa login")
+	}
+
+	// challenge token hanya bisa ditukar sekali
+	resp, _ = doRequest(t, app, "POST", "/login/mfa", "", map[string]string{"mfa_token": mfaToken, "recovery_code": recovery[0]})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for reused mfa token but got %d", resp.StatusCode)
+	}
+
+	// recovery code berlaku sekali, tidak peka huruf besar dan tanda minus
+	mfaToken = loginChallenge(t, app, username)
+	resp, result = doRequest(t, app, "POST", "/login/mfa", "", map[string]string{
+		"mfa_token":     mfaToken,
+		"recovery_code": strings.ToUpper(strings.ReplaceAll(recovery[0], "-", "")),
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for recovery code but got %d: %v", resp.StatusCode, result)
+	}
+	if remaining := result["data"].(map[string]interface{})["recovery_codes_remaining"]; remaining != float64(9) {
+		t.Errorf("Expected 9 recovery codes remaining, got %v", remaining)
+	}
+	mfaToken = loginChallenge(t, app, username)
+	resp, _ = doRequest(t, app, "POST", "/login/mfa", "", map[string]string{"mfa_token": mfaToken, "recovery_code": recovery[0]})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for used recovery code but got %d", resp.StatusCode)
+	}
+}
+
+// TestDisableTOTP: menonaktifkan TOTP membutuhkan password dan kode
+func TestDisableTOTP(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "disabletotp")
+	username := user["username"].(string)
+	token := user["token"].(string)
+	_, _, recovery := enableTOTP(t, app, token)
+
+	resp, _ := doRequest(t, app, "POST", "/mfa/totp/disable", token, map[string]string{"password": "wrong", "recovery_code": recovery[0]})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for wrong password but got %d", resp.StatusCode)
+	}
+	resp, _ = doRequest(t, app, "POST", "/mfa/totp/disable", token, map[string]string{"password": "password123"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without code but got %d", resp.StatusCode)
+	}
+	resp, result := doRequest(t, app, "POST", "/mfa/totp/disable", token, map[string]string{"password": "password123", "recovery_code": recovery[1]})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for disable but got %d: %v", resp.StatusCode, result)
+	}
+
+	resp, result = doRequest(t, app, "GET", "/mfa/totp", token, nil)
+	if resp.StatusCode != http.StatusOK || result["data"].(map[string]interface{})["enabled"] != false {
+		t.Errorf("Expected two-factor to be disabled, got %d: %v", resp.StatusCode, result)
+	}
+	resp, result = doRequest(t, app, "POST", "/login", "", map[string]string{"username": username, "password": "password123"})
+	if resp.StatusCode != http.StatusOK || result["data"].(map[string]interface{})["token"] == nil {
+		t.Errorf("Expected access token without two-factor, got %d: %v", resp.StatusCode, result)
+	}
+}
+
+// TestTOTPChallengeAttempts: challenge dihapus setelah terlalu banyak kode salah
+func TestTOTPChallengeAttempts(t *testing.T) {
+	app := CreateTestApp(t, func(cfg *configs.Config) {
+		cfg.LoginBackoffBase = 0
+	})
+	user := CreateTestUser(app, t, "totpattempts")
+	secret, step, _ := enableTOTP(t, app, user["token"].(string))
+
+	mfaToken := loginChallenge(t, app, user["username"].(string))
+	for i := 0; i < 5; i++ {
+		resp, _ := doRequest(t, app, "POST", "/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": "000000"})
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status 401 but got %d", i+1, resp.StatusCode)
+		}
+	}
+
+	// kode yang benar pun ditolak karena challenge sudah dihapus
+	next, _ := totp.CodeAt(secret, step+1)
+	resp, result := doRequest(t, app, "POST", "/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": next})
+	if resp.StatusCode != http.StatusUnauthorized || !strings.Contains(result["message"].(string), "mfa token") {
+		t.Errorf("Expected invalid mfa token response, got %d: %v", resp.StatusCode, result)
+	}
+}
+
+// TestTOTPLockedAccount: challenge yang dibuat sebelum akun dikunci tidak
+// bisa dipakai selama lockout, walaupun kodenya benar
+func TestTOTPLockedAccount(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "totplocked")
+	secret, step, _ := enableTOTP(t, app, user["token"].(string))
+	mfaToken := loginChallenge(t, app, user["username"].(string))
+
+	lockedUntil := time.Now().Add(time.Hour)
+	if err := app.Deps.Users.SetLockedUntil(context.Background(), int(user["user_id"].(float64)), &lockedUntil); err != nil {
+		t.Fatalf("SetLockedUntil failed: %v", err)
+	}
+	next, _ := totp.CodeAt(secret, step+1)
+	resp, result := doRequest(t, app, "POST", "/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": next})
+	if resp.StatusCode != http.StatusLocked || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected status 423 with Retry-After for locked account, got %d: %v", resp.StatusCode, result)
+	}
+}
+
+// TestTOTPChallengeParallelAttempts: percobaan paralel dengan challenge
+// yang sama tetap dibatasi, dan challenge hanya bisa ditukar sekali
+func TestTOTPChallengeParallelAttempts(t *testing.T) {
+	app := CreateTestApp(t)
+	ctx := context.Background()
+	challenges := app.Deps.MFAChallenges
+
+	token, err := challenges.Issue(ctx, 1, service.Device{ID: "phone"})
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := challenges.Attempt(ctx, token); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			} else if !errors.Is(err, service.ErrMFAChallengeInvalid) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 5 {
+		t.Errorf("Expected 5 allowed attempts, got %d", allowed)
+	}
+
+	token, err = challenges.Issue(ctx, 1, service.Device{ID: "phone"})
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if err := challenges.Consume(ctx, token); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := challenges.Consume(ctx, token); !errors.Is(err, service.ErrMFAChallengeInvalid) {
+		t.Errorf("Expected second Consume to fail, got %v", err)
+	}
+}
+
+// TestTOTPCode: kode sesuai vektor uji RFC 6238 (SHA1)
+func TestTOTPCode(t *testing.T) {
+	// secret "12345678901234567890" dalam base32
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{20000000000, "353130"},
+	} {
+		got, err := totp.Code(secret, time.Unix(tc.unix, 0))
+		if err != nil || got != tc.want {
+			t.Errorf("Code at %d: expected %s but got %s (%v)", tc.unix, tc.want, got, err)
+		}
+	}
+	if _, ok := totp.Validate(secret, "287082", time.Unix(59+30, 0), 1); !ok {
+		t.Errorf("Expected code of previous period to be accepted with skew 1")
+	}
+	if _, ok := totp.Validate(secret, "287082", time.Unix(59+90, 0), 1); ok {
+		t.Errorf("Expected code outside skew to be rejected")
+	}
+}
@@ -0,0 +1,405 @@
+package test
+
+import (
+	"belajar-go/pkg/migrate"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeMigrateDB adalah database palsu untuk test migrator: menyimpan isi
+// schema_migrations, mencatat script dan lock yang dijalankan, dan
+// meniru advisory lock Postgres. Script yang berisi "FAIL" gagal dijalankan.
+type fakeMigrateDB struct {
+	mu      sync.Mutex
+	applied map[int64]fakeMigrationRow
+	log     []string
+	// lock berkapasitas 1, terisi selama advisory lock dipegang
+	lock chan struct{}
+}
+
+type fakeMigrationRow struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+func newFakeMigrateDB(t *testing.T) (*fakeMigrateDB, *sql.DB) {
+	fake := &fakeMigrateDB{applied: map[int64]fakeMigrationRow{}, lock: make(chan struct{}, 1)}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return fake, db
+}
+
+func (f *fakeMigrateDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeMigrateConn{db: f}, nil
+}
+
+func (f *fakeMigrateDB) Driver() driver.Driver { return fakeMigrateDriver{} }
+
+// record mencatat kejadian ke log
+func (f *fakeMigrateDB) record(entry string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, entry)
+}
+
+// takeLog mengembalikan log lalu mengosongkannya
+func (f *fakeMigrateDB) takeLog() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	log := f.log
+	f.log = nil
+	return log
+}
+
+func (f *fakeMigrateDB) versions() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var versions []int64
+	for v := range f.applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+type fakeMigrateDriver struct{}
+
+func (fakeMigrateDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use sql.OpenDB")
+}
+
+// fakeMigrateConn menampung perubahan schema_migrations selama transaksi
+// dan menerapkannya saat commit
+type fakeMigrateConn struct {
+	db      *fakeMigrateDB
+	pending []func()
+	inTx    bool
+}
+
+func (c *fakeMigrateConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *fakeMigrateConn) Close() error { return nil }
+
+func (c *fakeMigrateConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	c.pending = nil
+	return c, nil
+}
+
+func (c *fakeMigrateConn) Commit() error {
+	c.db.mu.Lock()
+	for _, apply := range c.pending {
+		apply()
+	}
+	c.db.mu.Unlock()
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *fakeMigrateConn) Rollback() error {
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *fakeMigrateConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case strings.Contains(query, "pg_advisory_lock"):
+		select {
+		case c.db.lock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.db.record("lock")
+	case strings.Contains(query, "pg_advisory_unlock"):
+		c.db.record("unlock")
+		<-c.db.lock
+	case strings.Contains(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		version, checksum := args[0].Value.(int64), args[2].Value.(string)
+		c.pending = append(c.pending, func() {
+			c.db.applied[version] = fakeMigrationRow{checksum: checksum, appliedAt: time.Now()}
+		})
+	case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+		version := args[0].Value.(int64)
+		c.pending = append(c.pending, func() { delete(c.db.applied, version) })
+	case strings.Contains(query, "FAIL"):
+		return nil, errors.New("syntax error")
+	default:
+		c.db.record(strings.TrimSpace(query))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeMigrateConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "SELECT version, checksum, applied_at FROM schema_migrations") {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	rows := &fakeMigrationRows{}
+	for version, row := range c.db.applied {
+		rows.values = append(rows.values, []driver.Value{version, row.checksum, row.appliedAt})
+	}
+	return rows, nil
+}
+
+type fakeMigrationRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeMigrationRows) Columns() []string { return []string{"version", "checksum", "applied_at"} }
+
+func (r *fakeMigrationRows) Close() error { return nil }
+
+func (r *fakeMigrationRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// migrationFS membuat migrasi fixture dengan script "up N" dan "down N"
+func migrationFS(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		version := strings.SplitN(name, "_", 2)[0]
+		fsys[name+".up.sql"] = &fstest.MapFile{Data: []byte("up " + version)}
+		fsys[name+".down.sql"] = &fstest.MapFile{Data: []byte("down " + version)}
+	}
+	return fsys
+}
+
+func newTestMigrator(t *testing.T, db *sql.DB, fsys fstest.MapFS) *migrate.Migrator {
+	t.Helper()
+	m, err := migrate.New(db, fsys)
+	if err != nil {
+		t.Fatalf("Error loading migrations: %v", err)
+	}
+	return m
+}
+
+// migrationVersions mengambil versi dari hasil Up/Down/Redo
+func migrationVersions(migrations []migrate.Migration) []int64 {
+	versions := []int64{}
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	return versions
+}
+
+func TestMigrateUpAndStatus(t *testing.T) {
+	fake, db := newFakeMigrateDB(t)
+	fsys := migrationFS("0010_c", "0002_b", "0001_a")
+	fsys["README.md"] = &fstest.MapFile{Data: []byte("not a migration")}
+	m := newTestMigrator(t, db, fsys)
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if got := fmt.Sprint(migrationVersions(applied)); got != "[1 2 10]" {
+		t.Errorf("Expected migrations applied in version order, got %s", got)
+	}
+	if got := fmt.Sprint(fake.takeLog()); got != "[lock up 0001 up 0002 up 0010 unlock]" {
+		t.Errorf("Unexpected statements: %s", got)
+	}
+
+	// migrasi yang sudah diterapkan tidak dijalankan lagi
+	applied, err = m.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Expected nothing to apply, got %v, %v", migrationVersions(applied), err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("Expected 3 statuses, got %v", statuses)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.Modified || s.AppliedAt.IsZero() {
+			t.Errorf("Expected %d_%s to be applied and unmodified, got %+v", s.Version, s.Name, s)
+		}
+	}
+}
+
+func TestMigrateChecksumMismatch(t *testing.T) {
+	_, db := newFakeMigrateDB(t)
+	ctx := context.Background()
+	if _, err := newTestMigrator(t, db, migrationFS("0001_a", "0002_b")).Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	fsys := migrationFS("0001_a", "0002_b", "0003_c")
+	fsys["0001_a.up.sql"].Data = []byte("up 0001 edited")
+	m := newTestMigrator(t, db, fsys)
+
+	applied, err := m.Up(ctx)
+	if !errors.Is(err, migrate.ErrChecksumMismatch) || len(applied) != 0 {
+		t.Errorf("Expected ErrChecksumMismatch before applying anything, got %v, %v", migrationVersions(applied), err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !statuses[0].Modified || statuses[1].Modified || statuses[2].Applied {
+		t.Errorf("Expected only 0001 to be modified and 0003 pending, got %+v", statuses)
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	fake, db := newFakeMigrateDB(t)
+	m := newTestMigrator(t, db, migrationFS("0001_a", "0002_b", "0003_c"))
+	ctx := context.Background()
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	fake.takeLog()
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil || fmt.Sprint(migrationVersions(reverted)) != "[3]" {
+		t.Fatalf("Expected Down(1) to revert 3, got %v, %v", migrationVersions(reverted), err)
+	}
+	reverted, err = m.Down(ctx, 0)
+	if err != nil || fmt.Sprint(migrationVersions(reverted)) != "[2 1]" {
+		t.Fatalf("Expected Down(0) to revert 2 and 1, got %v, %v", migrationVersions(reverted), err)
+	}
+	if got := fmt.Sprint(fake.takeLog()); got != "[lock down 0003 unlock lock down 0002 down 0001 unlock]" {
+		t.Errorf("Unexpected statements: %s", got)
+	}
+	if versions := fake.versions(); len(versions) != 0 {
+		t.Errorf("Expected no applied migrations, got %v", versions)
+	}
+
+	// migrasi tanpa script down tidak bisa dibatalkan
+	fsys := migrationFS("0001_a")
+	delete(fsys, "0001_a.down.sql")
+	m = newTestMigrator(t, db, fsys)
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if _, err := m.Down(ctx, 1); err == nil || !strings.Contains(err.Error(), "no down script") {
+		t.Errorf("Expected a missing down script error, got %v", err)
+	}
+}
+
+func TestMigrateRedo(t *testing.T) {
+	fake, db := newFakeMigrateDB(t)
+	ctx := context.Background()
+	if _, err := newTestMigrator(t, db, migrationFS("0001_a", "0002_b")).Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	fake.takeLog()
+
+	// 0003 belum diterapkan dan tidak boleh ikut dijalankan oleh redo
+	m := newTestMigrator(t, db, migrationFS("0001_a", "0002_b", "0003_c"))
+	redone, err := m.Redo(ctx)
+	if err != nil || fmt.Sprint(migrationVersions(redone)) != "[2]" {
+		t.Fatalf("Expected Redo to re-apply only 2, got %v, %v", migrationVersions(redone), err)
+	}
+	if got := fmt.Sprint(fake.takeLog()); got != "[lock down 0002 up 0002 unlock]" {
+		t.Errorf("Expected down and up inside one lock, got %s", got)
+	}
+	if got := fmt.Sprint(fake.versions()); got != "[1 2]" {
+		t.Errorf("Expected 1 and 2 applied, got %s", got)
+	}
+
+	// tanpa migrasi yang diterapkan, redo tidak melakukan apa-apa
+	_, empty := newFakeMigrateDB(t)
+	redone, err = newTestMigrator(t, empty, migrationFS("0001_a")).Redo(ctx)
+	if err != nil || len(redone) != 0 {
+		t.Errorf("Expected Redo on an empty database to do nothing, got %v, %v", migrationVersions(redone), err)
+	}
+}
+
+func TestMigrateFailureRollsBack(t *testing.T) {
+	fake, db := newFakeMigrateDB(t)
+	fsys := migrationFS("0001_a", "0002_b", "0003_c")
+	fsys["0002_b.up.sql"].Data = []byte("FAIL")
+	m := newTestMigrator(t, db, fsys)
+
+	applied, err := m.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "apply 2_b") {
+		t.Errorf("Expected apply error for 2_b, got %v", err)
+	}
+	if fmt.Sprint(migrationVersions(applied)) != "[1]" || fmt.Sprint(fake.versions()) != "[1]" {
+		t.Errorf("Expected only 1 to be applied, got %v and %v", migrationVersions(applied), fake.versions())
+	}
+	if log := fake.takeLog(); log[len(log)-1] != "unlock" {
+		t.Errorf("Expected the lock to be released after a failure, got %v", log)
+	}
+}
+
+func TestMigrateLock(t *testing.T) {
+	fake, db := newFakeMigrateDB(t)
+	fsys := migrationFS("0001_a", "0002_b", "0003_c")
+	ctx := context.Background()
+
+	// dua instance bermigrasi bersamaan: setiap migrasi hanya diterapkan sekali
+	results := make(chan []migrate.Migration, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied, err := newTestMigrator(t, db, fsys).Up(ctx)
+			if err != nil {
+				t.Errorf("Up failed: %v", err)
+			}
+			results <- applied
+		}()
+	}
+	wg.Wait()
+	close(results)
+	total := 0
+	for applied := range results {
+		total += len(applied)
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 migrations applied in total, got %d", total)
+	}
+	if got := fmt.Sprint(fake.takeLog()); got != "[lock up 0001 up 0002 up 0003 unlock lock unlock]" {
+		t.Errorf("Expected the second instance to wait for the lock, got %s", got)
+	}
+
+	// migrator menunggu lock yang dipegang instance lain sampai context habis
+	fake.lock <- struct{}{}
+	defer func() { <-fake.lock }()
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := newTestMigrator(t, db, fsys).Status(waitCtx); err == nil {
+		t.Errorf("Expected Status to fail while the lock is held")
+	}
+}
+
+func TestMigrateNewErrors(t *testing.T) {
+	_, db := newFakeMigrateDB(t)
+
+	fsys := migrationFS("0001_a")
+	delete(fsys, "0001_a.up.sql")
+	if _, err := migrate.New(db, fsys); err == nil || !strings.Contains(err.Error(), "no up script") {
+		t.Errorf("Expected a missing up script error, got %v", err)
+	}
+
+	fsys = migrationFS("0001_a")
+	fsys["0001_b.down.sql"] = &fstest.MapFile{Data: []byte("down 0001")}
+	if _, err := migrate.New(db, fsys); err == nil || !strings.Contains(err.Error(), "conflicting names") {
+		t.Errorf("Expected a conflicting names error, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"belajar-go/internal/auth"
+	"belajar-go/pkg/jwk"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockOIDCAuthorization adalah login yang sudah disetujui di mock provider
+type mockOIDCAuthorization struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+	claims      jwt.MapClaims
+}
+
+// mockOIDCProvider adalah identity provider OpenID Connect tiruan dengan
+// discovery, JWKS, dan token endpoint yang memeriksa client secret,
+// redirect_uri, dan PKCE seperti provider asli
+type mockOIDCProvider struct {
+	server       *httptest.Server
+	key          *rsa.PrivateKey
+	clientID     string
+	clientSecret string
+
+	mu    sync.Mutex
+	codes map[string]mockOIDCAuthorization
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Cannot generate RSA key: %v", err)
+	}
+	idp := &mockOIDCProvider{
+		key:          key,
+		clientID:     "belajar-go",
+		clientSecret: "s3cret/+:&",
+		codes:        map[string]mockOIDCAuthorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/jwks",
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := &jwk.Key{ID: "idp", Algorithm: jwk.AlgRS256, Public: &key.PublicKey}
+		json.NewEncoder(w).Encode(jwk.Set{Keys: []jwk.JWK{pub.JWK()}})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// token menukar kode dengan ID token
+func (idp *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	reject := func(status int, code string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	id, secret, ok := r.BasicAuth()
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+	if !ok || id != idp.clientID || secret != idp.clientSecret {
+		reject(http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		reject(http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	idp.mu.Lock()
+	authz, ok := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.mu.Unlock()
+	if !ok || authz.redirectURI != r.PostFormValue("redirect_uri") ||
+		auth.PKCEChallenge(r.PostFormValue("code_verifier")) != authz.challenge {
+		reject(http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   idp.clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"nonce": authz.nonce,
+	}
+	for k, v := range authz.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp"
+	signed, _ := token.SignedString(idp.key)
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "unused", "token_type": "Bearer", "id_token": signed})
+}
+
+// authorize menyetujui login dari authorization_url seolah user sudah
+// login di provider, lalu mengembalikan code dan state untuk callback.
+// change boleh mengubah login yang disimpan, misalnya untuk merusak PKCE.
+func (idp *mockOIDCProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims, change func(*mockOIDCAuthorization)) (string, string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Invalid authorization_url %q: %v", authURL, err)
+	}
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("response_type") != "code" || q.Get("client_id") != idp.clientID ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("nonce") == "" || q.Get("state") == "" {
+		t.Fatalf("Unexpected authorization_url %q", authURL)
+	}
+
+	authz := mockOIDCAuthorization{
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		redirectURI: q.Get("redirect_uri"),
+		claims:      claims,
+	}
+	if change != nil {
+		change(&authz)
+	}
+	code := "code-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	idp.mu.Lock()
+	idp.codes[code] = authz
+	idp.mu.Unlock()
+	return code, q.Get("state")
+}
+
+// withOIDCProvider mendaftarkan idp sebagai provider "mock"
+func withOIDCProvider(t *testing.T, idp *mockOIDCProvider) func(*configs.Config) {
+	path := filepath.Join(t.TempDir(), "oidc.yaml")
+	content := fmt.Sprintf("providers:\n  - name: mock\n    issuer: %s\n    client_id: %s\n    client_secret: %q\n",
+		idp.server.URL, idp.clientID, idp.clientSecret)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Cannot write OIDC providers file: %v", err)
+	}
+	return func(cfg *configs.Config) {
+		cfg.OIDCProvidersFile = path
+	}
+}
+
+// oidcLogin menjalankan login lewat mock provider dan mengembalikan
+// respons callback
+func oidcLogin(t *testing.T, app *TestApp, idp *mockOIDCProvider, claims jwt.MapClaims, change func(*mockOIDCAuthorization)) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	resp, result := doRequest(t, app, "GET", "/oidc/mock/login?device_id=browser", "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 starting OIDC login but got %d: %v", resp.StatusCode, result)
+	}
+	authURL := result["data"].(map[string]interface{})["authorization_url"].(string)
+	code, state := idp.authorize(t, authURL, claims, change)
+	return doRequest(t, app, "GET", "/oidc/mock/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state), "", nil)
+}
+
+// TestOIDCLoginProvisionsUser: identity baru mendapat user member baru,
+// login berikutnya memakai user yang sama
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	idp := newMockOIDCProvider(t)
+	app := CreateTestApp(t, withOIDCProvider(t, idp))
+
+	_, result := doRequest(t, app, "GET", "/oidc/providers", "", nil)
+	if providers, _ := result["data"].([]interface{}); len(providers) != 1 || providers[0] != "mock" {
+		t.Errorf("Expected provider list [mock], got %v", result)
+	}
+
+	unique := time.Now().UnixNano()
+	email := fmt.Sprintf("oidc_%d@example.com", unique)
+	claims := jwt.MapClaims{"sub": fmt.Sprintf("subject-%d", unique), "email": email, "email_verified": true, "preferred_username": "Alice Smith!"}
+	resp, result := oidcLogin(t, app, idp, claims, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for OIDC login but got %d: %v", resp.StatusCode, result)
+	}
+	data := result["data"].(map[string]interface{})
+	if data["role"] != "member" || data["token"] == nil || data["refresh_token"] == nil || data["device_id"] != "browser" {
+		t.Errorf("Unexpected login data: %v", data)
+	}
+	userID := int(data["user_id"].(float64))
+
+	user, err := app.Deps.Users.GetByID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Cannot fetch provisioned user: %v", err)
+	}
+	if !strings.HasPrefix(user.Username, "alicesmith") || user.Email != email || user.EmailVerifiedAt == nil {
+		t.Errorf("Unexpected provisioned user: %+v", user)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", data["token"].(string), nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 with OIDC token but got %d", resp.StatusCode)
+	}
+
+	// email di provider boleh berubah, identity tetap dikenali dari sub
+	claims["email"] = "changed_" + email
+	resp, result = oidcLogin(t, app, idp, claims, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for repeat OIDC login but got %d: %v", resp.StatusCode, result)
+	}
+	if got := int(result["data"].(map[string]interface{})["user_id"].(float64)); got != userID {
+		t.Errorf("Expected repeat login as user %d, got %d", userID, got)
+	}
+
+	// username yang sudah dipakai mendapat akhiran
+	other := jwt.MapClaims{"sub": fmt.Sprintf("other-%d", unique), "email": "other_" + email, "email_verified": true, "preferred_username": "alicesmith"}
+	resp, result = oidcLogin(t, app, idp, other, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for second identity but got %d: %v", resp.StatusCode, result)
+	}
+	if got := int(result["data"].(map[string]interface{})["user_id"].(float64)); got == userID {
+		t.Errorf("Expected a new user for a different subject")
+	}
+}
+
+// TestOIDCLoginLinksExistingUser: identity hanya ditautkan ke user lama
+// jika email terverifikasi di kedua pihak
+func TestOIDCLoginLinksExistingUser(t *testing.T) {
+	idp := newMockOIDCProvider(t)
+	app := CreateTestApp(t, withOIDCProvider(t, idp))
+	existing := CreateTestUser(app, t, "oidclink")
+	userID := int(existing["user_id"].(float64))
+	email := existing["username"].(string) + "@example.com"
+
+	subject := "link-" + existing["username"].(string)
+	claims := jwt.MapClaims{"sub": subject, "email": email, "email_verified": true}
+	if resp, result := oidcLogin(t, app, idp, claims, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 while local email is unverified but got %d: %v", resp.StatusCode, result)
+	}
+
+	if err := app.Deps.Users.MarkEmailVerified(context.Background(), userID, email, time.Now()); err != nil {
+		t.Fatalf("Cannot verify email: %v", err)
+	}
+	unverified := jwt.MapClaims{"sub": subject, "email": email, "email_verified": false}
+	if resp, result := oidcLogin(t, app, idp, unverified, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 while provider email is unverified but got %d: %v", resp.StatusCode, result)
+	}
+
+	resp, result := oidcLogin(t, app, idp, claims, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 linking verified user but got %d: %v", resp.StatusCode, result)
+	}
+	if got := int(result["data"].(map[string]interface{})["user_id"].(float64)); got != userID {
+		t.Errorf("Expected login as existing user %d, got %d", userID, got)
+	}
+
+	noEmail := jwt.MapClaims{"sub": "no-email"}
+	if resp, _ := oidcLogin(t, app, idp, noEmail, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 without email but got %d", resp.StatusCode)
+	}
+}
+
+// TestOIDCLoginRejected: state, PKCE, nonce, dan ID token yang salah ditolak
+func TestOIDCLoginRejected(t *testing.T) {
+	idp := newMockOIDCProvider(t)
+	app := CreateTestApp(t, withOIDCProvider(t, idp))
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "rejected", "email": "rejected@example.com", "email_verified": true}
+	}
+
+	for name, tc := range map[string]struct {
+		claims jwt.MapClaims
+		change func(*mockOIDCAuthorization)
+	}{
+		"PKCE mismatch":  {claims(), func(a *mockOIDCAuthorization) { a.challenge = auth.PKCEChallenge("other-verifier") }},
+		"wrong nonce":    {claims(), func(a *mockOIDCAuthorization) { a.nonce = "other-nonce" }},
+		"wrong audience": {jwt.MapClaims{"sub": "rejected", "aud": "other-client"}, nil},
+		"wrong issuer":   {jwt.MapClaims{"sub": "rejected", "iss": "https://evil.example.com"}, nil},
+		"expired":        {jwt.MapClaims{"sub": "rejected", "exp": time.Now().Add(-time.Hour).Unix()}, nil},
+		"missing sub":    {jwt.MapClaims{"sub": ""}, nil},
+	} {
+		if resp, result := oidcLogin(t, app, idp, tc.claims, tc.change); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401 but got %d: %v", name, resp.StatusCode, result)
+		}
+	}
+
+	// state hanya bisa dipakai sekali
+	_, result := doRequest(t, app, "GET", "/oidc/mock/login", "", nil)
+	code, state := idp.authorize(t, result["data"].(map[string]interface{})["authorization_url"].(string), claims(), nil)
+	callback := "/oidc/mock/callback?code=" + url.QueryEscape(code) + "&state=" + url.QueryEscape(state)
+	if resp, _ := doRequest(t, app, "GET", callback, "", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for callback but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "GET", callback, "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for reused state but got %d", resp.StatusCode)
+	}
+
+	if resp, _ := doRequest(t, app, "GET", "/oidc/mock/callback?code=x&state=unknown", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown state but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/oidc/mock/callback?error=access_denied&state=x", "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for provider error but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/oidc/unknown/login", "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown provider but got %d", resp.StatusCode)
+	}
+}
+
+// TestOIDCProvidersConfig: file provider yang salah ditolak saat konfigurasi dimuat
+func TestOIDCProvidersConfig(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", validEmailLinkSecret)
+	t.Setenv("ENCRYPTION_KEYS", validEncryptionKey)
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+
+	t.Setenv("OIDC_PROVIDERS_FILE", writeConfigFile(t, "oidc.toml", `
+[[providers]]
+name = "corp"
+issuer = "https://login.example.com"
+client_id = "app"
+scopes = ["email"]
+`))
+	cfg, err := configs.Load(nil)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	providers, _ := cfg.OIDCProviders()
+	if len(providers) != 1 || providers[0].RedirectURL != cfg.AppBaseURL+"/api/v1/oidc/corp/callback" ||
+		len(providers[0].Scopes) != 2 || providers[0].Scopes[0] != "openid" {
+		t.Errorf("Unexpected providers: %+v", providers)
+	}
+
+	t.Setenv("OIDC_PROVIDERS_FILE", writeConfigFile(t, "oidc.yaml", `
+providers:
+  - name: corp
+    issuer: http://login.example.com
+    client_id: app
+  - name: corp
+    issuer: https://login.example.com
+`))
+	_, err = configs.Load(nil)
+	expectProblems(t, err,
+		`OIDC_PROVIDERS_FILE: provider "corp": issuer "http://login.example.com" must use https`,
+		`provider "corp" is defined twice`,
+		`provider "corp": client_id is required`)
+}
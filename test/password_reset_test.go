@@ -0,0 +1,198 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"belajar-go/pkg/mail"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var resetTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// withFileMailer menyimpan email test di folder sementara
+func withFileMailer(t *testing.T, dir *string) func(*configs.Config) {
+	*dir = t.TempDir()
+	return func(cfg *configs.Config) {
+		cfg.MailDriver = "file"
+		cfg.MailDir = *dir
+	}
+}
+
+// readMails membaca semua email yang ditulis FileMailer, urut waktu kirim
+func readMails(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatalf("Glob error: %v", err)
+	}
+	mails := make([]string, 0, len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("ReadFile error: %v", err)
+		}
+		mails = append(mails, string(data))
+	}
+	return mails
+}
+
+// requestPasswordReset meminta reset dan mengembalikan token dari email terakhir
+func requestPasswordReset(t *testing.T, app *TestApp, dir, email string) string {
+	t.Helper()
+	resp, result := doRequest(t, app, "POST", "/password/forgot", "", map[string]string{"email": email})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for forgot password but got %d: %v", resp.StatusCode, result)
+	}
+	mails := readMails(t, dir)
+	if len(mails) == 0 {
+		t.Fatalf("Expected a password reset email")
+	}
+	last := mails[len(mails)-1]
+	if !strings.Contains(strings.ToLower(last), "to: "+strings.ToLower(email)) {
+		t.Errorf("Expected email to be sent to %s, got:\n%s", email, last)
+	}
+	match := resetTokenPattern.FindStringSubmatch(last)
+	if match == nil {
+		t.Fatalf("Expected reset token in email, got:\n%s", last)
+	}
+	return match[1]
+}
+
+// TestPasswordReset: reset password dengan token dari email dan mencabut semua sesi
+func TestPasswordReset(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir))
+	user := CreateTestUser(app, t, "reset")
+	username := user["username"].(string)
+	userID := int(user["user_id"].(float64))
+	oldToken := user["token"].(string)
+
+	token := requestPasswordReset(t, app, mailDir, strings.ToUpper(username)+"@example.com")
+
+	resp, result := doRequest(t, app, "POST", "/password/reset", "", map[string]string{"token": token, "password": "newpassword"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for reset but got %d: %v", resp.StatusCode, result)
+	}
+
+	// token hanya bisa dipakai sekali
+	resp, _ = doRequest(t, app, "POST", "/password/reset", "", map[string]string{"token": token, "password": "another"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for reused token but got %d", resp.StatusCode)
+	}
+
+	// access token dan refresh token lama sudah dicabut
+	resp, _ = doRequest(t, app, "GET", fmt.Sprintf("/users/%d", userID), oldToken, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for old access token but got %d", resp.StatusCode)
+	}
+	resp, _ = doRequest(t, app, "POST", "/token/refresh", "", map[string]string{
+		"refresh_token": user["refresh_token"].(string),
+		"device_id":     user["device_id"].(string),
+	})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for old refresh token but got %d", resp.StatusCode)
+	}
+
+	if resp := login(t, app, username, "password123"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for old password but got %d", resp.StatusCode)
+	}
+	resp, result = doRequest(t, app, "POST", "/login", "", map[string]string{"username": username, "password": "newpassword"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for new password but got %d", resp.StatusCode)
+	}
+	newToken := result["data"].(map[string]interface{})["token"].(string)
+	resp, _ = doRequest(t, app, "GET", fmt.Sprintf("/users/%d", userID), newToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for new access token but got %d", resp.StatusCode)
+	}
+}
+
+// TestPasswordResetOnlyLatestToken: meminta reset lagi membatalkan token sebelumnya
+func TestPasswordResetOnlyLatestToken(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir))
+	user := CreateTestUser(app, t, "latest")
+	email := user["username"].(string) + "@example.com"
+
+	first := requestPasswordReset(t, app, mailDir, email)
+	second := requestPasswordReset(t, app, mailDir, email)
+	if first == second {
+		t.Fatalf("Expected a new token for every request")
+	}
+
+	resp, _ := doRequest(t, app, "POST", "/password/reset", "", map[string]string{"token": first, "password": "newpassword"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for replaced token but got %d", resp.StatusCode)
+	}
+	resp, _ = doRequest(t, app, "POST", "/password/reset", "", map[string]string{"token": second, "password": "newpassword"})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for latest token but got %d", resp.StatusCode)
+	}
+}
+
+// TestPasswordResetExpiredToken: token kadaluarsa ditolak
+func TestPasswordResetExpiredToken(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir), func(cfg *configs.Config) {
+		cfg.PasswordResetTTL = time.Millisecond
+	})
+	user := CreateTestUser(app, t, "expiredreset")
+
+	token := requestPasswordReset(t, app, mailDir, user["username"].(string)+"@example.com")
+	time.Sleep(5 * time.Millisecond)
+
+	resp, _ := doRequest(t, app, "POST", "/password/reset", "", map[string]string{"token": token, "password": "newpassword"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for expired token but got %d", resp.StatusCode)
+	}
+}
+
+// TestForgotPasswordUnknownEmail: email yang tidak terdaftar mendapat respons yang sama tanpa email
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	var mailDir string
+	app := CreateTestApp(t, withFileMailer(t, &mailDir))
+
+	resp, result := doRequest(t, app, "POST", "/password/forgot", "", map[string]string{"email": "nobody@example.com"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for unknown email but got %d: %v", resp.StatusCode, result)
+	}
+	if mails := readMails(t, mailDir); len(mails) != 0 {
+		t.Errorf("Expected no email for unknown address, got %d", len(mails))
+	}
+
+	resp, _ = doRequest(t, app, "POST", "/password/forgot", "", map[string]string{"email": "not-an-email"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid email but got %d", resp.StatusCode)
+	}
+}
+
+// TestMessageHeaders: baris baru di header dihapus agar tidak bisa menyisipkan header lain
+func TestMessageHeaders(t *testing.T) {
+	dir := t.TempDir()
+	mailer := mail.NewFileMailer(dir, "app@example.com")
+	err := mailer.Send(context.Background(), mail.Message{
+		To:      "user@example.com\r\nBcc: attacker@example.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	mails := readMails(t, dir)
+	if len(mails) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(mails))
+	}
+	if strings.Contains(mails[0], "\r\nBcc:") {
+		t.Errorf("Expected header injection to be removed, got:\n%s", mails[0])
+	}
+	if !strings.Contains(mails[0], "From: app@example.com\r\n") || !strings.Contains(mails[0], "\r\n\r\nline one\r\nline two") {
+		t.Errorf("Unexpected email format:\n%s", mails[0])
+	}
+}
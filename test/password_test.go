@@ -0,0 +1,105 @@
+package test
+
+import (
+	"belajar-go/internal/models"
+	"belajar-go/pkg/password"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// parameter kecil agar test cepat
+var testParams = password.Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := password.New(testParams)
+
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Expected PHC encoded argon2id hash, got %q", hash)
+	}
+	if ok, err := hasher.Verify("correct horse", hash); !ok || err != nil {
+		t.Errorf("Expected password to verify, got %v (%v)", ok, err)
+	}
+	if ok, _ := hasher.Verify("wrong horse", hash); ok {
+		t.Errorf("Expected wrong password to fail")
+	}
+	if hasher.NeedsRehash(hash) {
+		t.Errorf("Expected hash with current params not to need rehash")
+	}
+
+	// parameter berubah -> hash lama perlu dibuat ulang, tapi tetap bisa diverifikasi
+	stronger := testParams
+	stronger.Iterations = 2
+	if !password.New(stronger).NeedsRehash(hash) {
+		t.Errorf("Expected hash with old params to need rehash")
+	}
+	if ok, _ := password.New(stronger).Verify("correct horse", hash); !ok {
+		t.Errorf("Expected hash with old params to still verify")
+	}
+
+	if _, err := hasher.Verify("x", "plaintext"); !errors.Is(err, password.ErrUnknownHash) {
+		t.Errorf("Expected ErrUnknownHash, got %v", err)
+	}
+	if _, err := hasher.Verify("x", "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$aGFzaA"); !errors.Is(err, password.ErrInvalidHash) {
+		t.Errorf("Expected ErrInvalidHash, got %v", err)
+	}
+}
+
+func TestArgon2idVerifiesBcrypt(t *testing.T) {
+	hasher := password.New(testParams)
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+
+	if ok, err := hasher.Verify("secret123", string(legacy)); !ok || err != nil {
+		t.Errorf("Expected bcrypt hash to verify, got %v (%v)", ok, err)
+	}
+	if ok, _ := hasher.Verify("nope", string(legacy)); ok {
+		t.Errorf("Expected wrong password to fail against bcrypt hash")
+	}
+	if !hasher.NeedsRehash(string(legacy)) {
+		t.Errorf("Expected bcrypt hash to need rehash")
+	}
+}
+
+// TestLoginUpgradesLegacyHash: login dengan hash bcrypt lama menyimpan hash Argon2id baru
+func TestLoginUpgradesLegacyHash(t *testing.T) {
+	app := CreateTestApp(t)
+	ctx := context.Background()
+
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("legacypass"), bcrypt.MinCost)
+	user := models.User{Username: "legacyuser", Email: "legacy@example.com", Password: string(legacy), Role: "member"}
+	if err := app.Deps.Users.Create(ctx, &user); err != nil {
+		t.Fatalf("Create user error: %v", err)
+	}
+
+	resp, _ := doRequest(t, app, "POST", "/login", "", map[string]string{"username": "legacyuser", "password": "wrong"})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for wrong password but got %d", resp.StatusCode)
+	}
+	stored, _ := app.Deps.Users.GetByUsername(ctx, "legacyuser")
+	if stored.Password != string(legacy) {
+		t.Errorf("Expected hash to stay unchanged after failed login")
+	}
+
+	resp, _ = doRequest(t, app, "POST", "/login", "", map[string]string{"username": "legacyuser", "password": "legacypass"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for legacy login but got %d", resp.StatusCode)
+	}
+	stored, _ = app.Deps.Users.GetByUsername(ctx, "legacyuser")
+	if !strings.HasPrefix(stored.Password, "$argon2id$") {
+		t.Errorf("Expected hash to be upgraded to argon2id, got %q", stored.Password)
+	}
+
+	// login berikutnya memakai hash baru
+	resp, _ = doRequest(t, app, "POST", "/login", "", map[string]string{"username": "legacyuser", "password": "legacypass"})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 with upgraded hash but got %d", resp.StatusCode)
+	}
+}
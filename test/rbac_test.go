@@ -0,0 +1,132 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// loginToken login dengan password default CreateTestUser dan mengembalikan access token
+func loginToken(t *testing.T, app *TestApp, username string) string {
+	t.Helper()
+	resp, result := doRequest(t, app, "POST", "/login", "", map[string]string{"username": username, "password": "password123"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for login but got %d: %v", resp.StatusCode, result)
+	}
+	return result["data"].(map[string]interface{})["token"].(string)
+}
+
+// TestCustomRole: role baru dengan permission ":any" memberi akses ke task user lain
+func TestCustomRole(t *testing.T) {
+	app := CreateTestApp(t)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+	owner := CreateTestUser(app, t, "owner")
+	moderator := CreateTestUser(app, t, "moderator")
+	moderatorID := int(moderator["user_id"].(float64))
+	taskID := createTestTask(t, app, owner["token"].(string), map[string]string{"title": "Owned", "description": "d", "status": "pending"})
+	taskPath := fmt.Sprintf("/tasks/%d", taskID)
+
+	// member tidak bisa mengelola role
+	if resp, _ := doRequest(t, app, "GET", "/roles", moderator["token"].(string), nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for member listing roles but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", taskPath, moderator["token"].(string), nil); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403 before role change but got %d", resp.StatusCode)
+	}
+
+	roleName := fmt.Sprintf("moderator_%d", time.Now().UnixNano())
+	resp, result := doRequest(t, app, "POST", "/roles", adminToken, map[string]interface{}{
+		"name":        roleName,
+		"description": "Reads and deletes every task",
+		"permissions": []string{"tasks:read:own", "tasks:read:any", "tasks:delete:any"},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 for create role but got %d: %v", resp.StatusCode, result)
+	}
+
+	resp, _ = doRequest(t, app, "PUT", fmt.Sprintf("/users/%d/role", moderatorID), adminToken, map[string]string{"role": roleName})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for assign role but got %d", resp.StatusCode)
+	}
+
+	// access token lama membawa role lama sehingga dicabut
+	if resp, _ := doRequest(t, app, "GET", taskPath, moderator["token"].(string), nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for token issued before role change but got %d", resp.StatusCode)
+	}
+	token := loginToken(t, app, moderator["username"].(string))
+
+	if resp, _ := doRequest(t, app, "GET", taskPath, token, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 reading other user's task but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "PUT", taskPath, token, map[string]string{"title": "Changed"}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 updating without tasks:update permission but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "POST", "/tasks", token, map[string]string{"title": "x", "description": "d", "status": "pending"}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 creating without tasks:create but got %d", resp.StatusCode)
+	}
+
+	// perubahan permission langsung berlaku untuk token yang sudah ada
+	resp, _ = doRequest(t, app, "PUT", "/roles/"+roleName, adminToken, map[string]interface{}{"permissions": []string{"tasks:read:own", "tasks:read:any"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for update role but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", taskPath, token, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 after tasks:delete:any was removed but got %d", resp.StatusCode)
+	}
+
+	// role yang masih dipakai tidak bisa dihapus
+	if resp, _ := doRequest(t, app, "DELETE", "/roles/"+roleName, adminToken, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 deleting role in use but got %d", resp.StatusCode)
+	}
+	doRequest(t, app, "PUT", fmt.Sprintf("/users/%d/role", moderatorID), adminToken, map[string]string{"role": "member"})
+	if resp, _ := doRequest(t, app, "DELETE", "/roles/"+roleName, adminToken, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 deleting unused role but got %d", resp.StatusCode)
+	}
+}
+
+// TestRoleValidation: role bawaan dilindungi dan permission harus dikenal
+func TestRoleValidation(t *testing.T) {
+	app := CreateTestApp(t)
+	adminToken, adminID, _ := CreateTestAdmin(app, t)
+	member := CreateTestUser(app, t, "rolemember")
+
+	resp, result := doRequest(t, app, "GET", "/roles", adminToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for list roles but got %d", resp.StatusCode)
+	}
+	builtIn := map[string]bool{}
+	for _, r := range result["data"].([]interface{}) {
+		role := r.(map[string]interface{})
+		if role["built_in"] == true {
+			builtIn[role["name"].(string)] = true
+		}
+	}
+	if !builtIn["admin"] || !builtIn["member"] {
+		t.Errorf("Expected built-in admin and member roles, got %v", builtIn)
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		body         interface{}
+		want         int
+	}{
+		{"DELETE", "/roles/member", nil, http.StatusConflict},
+		{"PUT", "/roles/admin", map[string]interface{}{"permissions": []string{}}, http.StatusConflict},
+		{"PUT", "/roles/member", map[string]interface{}{"permissions": []string{"tasks:fly"}}, http.StatusBadRequest},
+		{"POST", "/roles", map[string]interface{}{"name": "Bad Name", "permissions": []string{}}, http.StatusBadRequest},
+		{"POST", "/roles", map[string]interface{}{"name": "member", "permissions": []string{}}, http.StatusConflict},
+		{"PUT", "/roles/nope", map[string]interface{}{"description": "x"}, http.StatusNotFound},
+		{"PUT", fmt.Sprintf("/users/%d/role", adminID), map[string]string{"role": "member"}, http.StatusBadRequest},
+		{"PUT", fmt.Sprintf("/users/%d/role", int(member["user_id"].(float64))), map[string]string{"role": "nope"}, http.StatusBadRequest},
+	} {
+		if resp, result := doRequest(t, app, tc.method, tc.path, adminToken, tc.body); resp.StatusCode != tc.want {
+			t.Errorf("%s %s: expected status %d but got %d: %v", tc.method, tc.path, tc.want, resp.StatusCode, result)
+		}
+	}
+
+	resp, result = doRequest(t, app, "GET", "/permissions", adminToken, nil)
+	if resp.StatusCode != http.StatusOK || len(result["data"].([]interface{})) == 0 {
+		t.Errorf("Expected permission list, got %d: %v", resp.StatusCode, result)
+	}
+}
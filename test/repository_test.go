@@ -0,0 +1,88 @@
+package test
+
+import (
+	"belajar-go/internal/models"
+	"belajar-go/internal/repository"
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestMemoryUserRepository: repository in-memory meniru unique constraint dan not found
+func TestMemoryUserRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryUserRepository()
+
+	user := models.User{Username: "alice", Email: "alice@example.com", Password: "hash", Role: "member"}
+	if err := repo.Create(ctx, &user); err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	if user.ID == 0 {
+		t.Fatalf("Expected ID to be assigned")
+	}
+
+	dup := models.User{Username: "alice", Email: "other@example.com", Password: "hash", Role: "member"}
+	if err := repo.Create(ctx, &dup); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, got %v", err)
+	}
+
+	found, err := repo.GetByUsername(ctx, "alice")
+	if err != nil || found.Password != "hash" {
+		t.Errorf("Expected GetByUsername to return password hash, got %v (%v)", found, err)
+	}
+	byID, err := repo.GetByID(ctx, user.ID)
+	if err != nil || byID.Password != "" {
+		t.Errorf("Expected GetByID to hide password hash, got %v (%v)", byID, err)
+	}
+
+	newName := "alice2"
+	updated, err := repo.Update(ctx, user.ID, repository.UserUpdate{Username: &newName})
+	if err != nil || updated.Username != newName || updated.Email != user.Email {
+		t.Errorf("Unexpected update result %v (%v)", updated, err)
+	}
+
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+// TestMemoryTaskRepository: filter user dan update parsial pada repository in-memory
+func TestMemoryTaskRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository()
+
+	for _, owner := range []int{1, 1, 2} {
+		task := models.Task{UserID: owner, Title: "t", Description: "d", Status: "pending", SecurityCode: "x"}
+		if err := repo.Create(ctx, &task); err != nil {
+			t.Fatalf("Create error: %v", err)
+		}
+	}
+
+	owner := 1
+	page, err := repo.List(ctx, repository.TaskQuery{Filter: repository.TaskFilter{UserID: &owner}})
+	if err != nil || len(page.Tasks) != 2 {
+		t.Fatalf("Expected 2 tasks for user 1, got %v (%v)", page, err)
+	}
+	tasks := page.Tasks
+	all, _ := repo.List(ctx, repository.TaskQuery{})
+	if len(all.Tasks) != 3 || all.Total != 3 {
+		t.Errorf("Expected 3 tasks in total, got %d", len(all.Tasks))
+	}
+
+	status := "completed"
+	empty := ""
+	updated, err := repo.Update(ctx, tasks[0].ID, repository.TaskUpdate{Status: &status, SecurityCode: &empty})
+	if err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if updated.Status != "completed" || updated.Title != "t" || updated.SecurityCode != "x" {
+		t.Errorf("Expected partial update, got %+v", updated)
+	}
+
+	if _, err := repo.Update(ctx, 999, repository.TaskUpdate{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// loginDevice login sebagai username dari device tertentu dan
+// mengembalikan isi field "data" dari response login
+func loginDevice(t *testing.T, app *TestApp, username, deviceName string) map[string]interface{} {
+	t.Helper()
+	resp, result := doRequest(t, app, "POST", "/login", "", map[string]string{
+		"username":    username,
+		"password":    "password123",
+		"device_name": deviceName,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for login but got %d: %v", resp.StatusCode, result)
+	}
+	return result["data"].(map[string]interface{})
+}
+
+// TestSessions: setiap login menjadi sesi yang bisa dilihat dan dicabut,
+// dan token dari sesi yang dicabut langsung ditolak
+func TestSessions(t *testing.T) {
+	app := CreateTestApp(t)
+	user := CreateTestUser(app, t, "sessions")
+	token := user["token"].(string)
+	sessionsPath := fmt.Sprintf("/users/%d/sessions", int(user["user_id"].(float64)))
+
+	laptop := loginDevice(t, app, user["username"].(string), "Laptop")
+	resp, result := doRequest(t, app, "GET", sessionsPath, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 listing sessions but got %d: %v", resp.StatusCode, result)
+	}
+	sessions, _ := result["data"].([]interface{})
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %v", result)
+	}
+	found := map[string]map[string]interface{}{}
+	for _, s := range sessions {
+		session := s.(map[string]interface{})
+		found[session["id"].(string)] = session
+	}
+	current, other := found[user["session_id"].(string)], found[laptop["session_id"].(string)]
+	if current == nil || current["current"] != true {
+		t.Errorf("Expected the caller's session to be marked current: %v", sessions)
+	}
+	if other == nil || other["current"] != false || other["device_name"] != "Laptop" || other["device_id"] != laptop["device_id"] ||
+		other["ip_address"] == "" || other["created_at"] == nil || other["last_seen_at"] == nil {
+		t.Errorf("Unexpected session data: %v", other)
+	}
+
+	// sesi lain tidak bisa dilihat atau dicabut oleh member lain
+	stranger := CreateTestUser(app, t, "stranger")
+	if resp, _ := doRequest(t, app, "GET", sessionsPath, stranger["token"].(string), nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 listing sessions of another user but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", sessionsPath+"/"+laptop["session_id"].(string), stranger["token"].(string), nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 revoking session of another user but got %d", resp.StatusCode)
+	}
+	strangerPath := fmt.Sprintf("/users/%d/sessions/%s", int(stranger["user_id"].(float64)), laptop["session_id"])
+	if resp, _ := doRequest(t, app, "DELETE", strangerPath, stranger["token"].(string), nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 revoking a session through another user ID but got %d", resp.StatusCode)
+	}
+
+	// cabut sesi laptop: access token dan refresh token-nya ditolak
+	if resp, result := doRequest(t, app, "DELETE", sessionsPath+"/"+laptop["session_id"].(string), token, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 revoking session but got %d: %v", resp.StatusCode, result)
+	}
+	if resp, result := doRequest(t, app, "GET", "/tasks", laptop["token"].(string), nil); resp.StatusCode != http.StatusUnauthorized || result["message"] != "Session revoked" {
+		t.Errorf("Expected status 401 for revoked session but got %d: %v", resp.StatusCode, result)
+	}
+	refresh := map[string]interface{}{"refresh_token": laptop["refresh_token"], "device_id": laptop["device_id"]}
+	if resp, _ := doRequest(t, app, "POST", "/token/refresh", "", refresh); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 refreshing revoked session but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", sessionsPath+"/"+laptop["session_id"].(string), token, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 revoking a revoked session but got %d", resp.StatusCode)
+	}
+
+	// cabut semua sesi lain, sesi sendiri tetap berlaku
+	phone := loginDevice(t, app, user["username"].(string), "Phone")
+	tablet := loginDevice(t, app, user["username"].(string), "Tablet")
+	resp, result = doRequest(t, app, "DELETE", sessionsPath, token, nil)
+	if resp.StatusCode != http.StatusOK || result["data"].(map[string]interface{})["revoked"] != float64(2) {
+		t.Fatalf("Expected 2 sessions revoked but got %d: %v", resp.StatusCode, result)
+	}
+	for _, other := range []map[string]interface{}{phone, tablet} {
+		if resp, _ := doRequest(t, app, "GET", "/tasks", other["token"].(string), nil); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for %v session but got %d", other["device_id"], resp.StatusCode)
+		}
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", token, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected current session to stay valid but got %d", resp.StatusCode)
+	}
+	_, result = doRequest(t, app, "GET", sessionsPath, token, nil)
+	if sessions, _ := result["data"].([]interface{}); len(sessions) != 1 {
+		t.Errorf("Expected only the current session left, got %v", result)
+	}
+}
+
+// TestForceLogout: admin bisa mengakhiri semua sesi user, member tidak
+func TestForceLogout(t *testing.T) {
+	app := CreateTestApp(t)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+	user := CreateTestUser(app, t, "forced")
+	other := loginDevice(t, app, user["username"].(string), "Phone")
+	path := fmt.Sprintf("/users/%d/logout", int(user["user_id"].(float64)))
+
+	if resp, _ := doRequest(t, app, "POST", path, other["token"].(string), nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for member force logout but got %d", resp.StatusCode)
+	}
+	if resp, result := doRequest(t, app, "POST", path, adminToken, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for force logout but got %d: %v", resp.StatusCode, result)
+	}
+	for _, session := range []map[string]interface{}{user, other} {
+		if resp, _ := doRequest(t, app, "GET", "/tasks", session["token"].(string), nil); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 after force logout but got %d", resp.StatusCode)
+		}
+		refresh := map[string]interface{}{"refresh_token": session["refresh_token"], "device_id": session["device_id"]}
+		if resp, _ := doRequest(t, app, "POST", "/token/refresh", "", refresh); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 refreshing after force logout but got %d", resp.StatusCode)
+		}
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks", adminToken, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected admin session to stay valid but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "POST", "/users/999999/logout", adminToken, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown user but got %d", resp.StatusCode)
+	}
+}
+
+// TestSessionsOutliveRefreshTTL: sesi yang terus dirotasi lebih lama dari
+// TTL tetap terdaftar dan bisa dicabut lewat "cabut semua sesi lain"
+func TestSessionsOutliveRefreshTTL(t *testing.T) {
+	ctx := context.Background()
+	tokens, _, sessionID := newRotatingSession(t, 1)
+
+	sessions, err := tokens.Sessions(ctx, 1)
+	if err != nil {
+		t.Fatalf("Sessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != sessionID {
+		t.Fatalf("Expected the rotated session to be listed, got %v", sessions)
+	}
+	revoked, err := tokens.RevokeOtherSessions(ctx, 1, "current")
+	if err != nil || revoked != 1 {
+		t.Fatalf("Expected 1 session revoked, got %d: %v", revoked, err)
+	}
+	if active, err := tokens.TouchSession(ctx, 1, sessionID); err != nil || active {
+		t.Errorf("Expected the rotated session to be revoked, got active=%v: %v", active, err)
+	}
+}
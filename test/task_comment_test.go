@@ -0,0 +1,91 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTaskComments(t *testing.T) {
+	app := CreateTestApp(t)
+	owner := CreateTestUser(app, t, "commenter")
+	token := owner["token"].(string)
+	mentioned := CreateTestUser(app, t, "mentioned")
+	mentionedID := mentioned["user_id"].(float64)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+	path := fmt.Sprintf("/tasks/%d/comments", createTestTask(t, app, token, map[string]string{}))
+
+	body := fmt.Sprintf("Please check @%s. Mail me at %s or ping @nobody_here", mentioned["username"], owner["username"].(string)+"@example.com")
+	resp, result := doRequest(t, app, "POST", path, token, map[string]string{"body": body})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating comment but got %d: %v", resp.StatusCode, result)
+	}
+	comment := result["data"].(map[string]interface{})
+	commentPath := fmt.Sprintf("%s/%d", path, int(comment["id"].(float64)))
+	if fmt.Sprint(comment["mentions"]) != fmt.Sprint([]interface{}{mentionedID}) || comment["edited_at"] != nil || comment["user_id"] != owner["user_id"] {
+		t.Errorf("Unexpected comment: %v", comment)
+	}
+	if resp, _ := doRequest(t, app, "POST", path, token, map[string]string{"body": "   "}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty comment but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "POST", path, token, map[string]string{"body": strings.Repeat("a", 5001)}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a too long comment but got %d", resp.StatusCode)
+	}
+
+	// admin boleh berkomentar di task user lain, user lain tidak
+	resp, result = doRequest(t, app, "POST", path, adminToken, map[string]string{"body": "Looks good"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 for admin comment but got %d: %v", resp.StatusCode, result)
+	}
+	adminCommentID := int(result["data"].(map[string]interface{})["id"].(float64))
+	stranger := CreateTestUser(app, t, "commentstranger")["token"].(string)
+	for _, req := range []struct{ method, path string }{{"GET", path}, {"POST", path}, {"PUT", commentPath}, {"DELETE", commentPath}} {
+		if resp, _ := doRequest(t, app, req.method, req.path, stranger, map[string]string{"body": "x"}); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected status 403 for %s %s by another user but got %d", req.method, req.path, resp.StatusCode)
+		}
+	}
+
+	// hanya penulis yang boleh mengubah; mention dihitung ulang
+	if resp, _ := doRequest(t, app, "PUT", commentPath, adminToken, map[string]string{"body": "hijacked"}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 editing another user's comment but got %d", resp.StatusCode)
+	}
+	resp, result = doRequest(t, app, "PUT", commentPath, token, map[string]string{"body": "Never mind"})
+	edited, _ := result["data"].(map[string]interface{})
+	if resp.StatusCode != http.StatusOK || edited["body"] != "Never mind" || edited["edited_at"] == nil || len(edited["mentions"].([]interface{})) != 0 {
+		t.Errorf("Unexpected edit result %d: %v", resp.StatusCode, result)
+	}
+
+	resp, result = doRequest(t, app, "GET", path, token, nil)
+	if comments, _ := result["data"].([]interface{}); resp.StatusCode != http.StatusOK || len(comments) != 2 ||
+		comments[0].(map[string]interface{})["body"] != "Never mind" {
+		t.Fatalf("Expected 2 comments, oldest first, got %d: %v", resp.StatusCode, result)
+	}
+
+	// soft delete: komentar hilang dari daftar dan tidak bisa diubah lagi
+	if resp, result := doRequest(t, app, "DELETE", commentPath, token, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 deleting comment but got %d: %v", resp.StatusCode, result)
+	}
+	if resp, _ := doRequest(t, app, "PUT", commentPath, token, map[string]string{"body": "again"}); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 editing a deleted comment but got %d", resp.StatusCode)
+	}
+	_, result = doRequest(t, app, "GET", path, token, nil)
+	if comments, _ := result["data"].([]interface{}); len(comments) != 1 {
+		t.Errorf("Expected 1 comment after delete, got %v", result)
+	}
+
+	// komentar user lain hanya bisa dihapus dengan tasks:delete:any
+	adminCommentPath := fmt.Sprintf("%s/%d", path, adminCommentID)
+	if resp, _ := doRequest(t, app, "DELETE", adminCommentPath, token, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 deleting another user's comment but got %d", resp.StatusCode)
+	}
+
+	// komentar tidak bisa diakses lewat task lain
+	otherPath := fmt.Sprintf("/tasks/%d/comments/%d", createTestTask(t, app, token, map[string]string{}), adminCommentID)
+	if resp, _ := doRequest(t, app, "DELETE", otherPath, token, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a comment of another task but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", adminCommentPath, adminToken, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for admin deleting a comment but got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// getTaskData mengambil satu task lewat GET /tasks/:id
+func getTaskData(t *testing.T, app *TestApp, token string, id int) map[string]interface{} {
+	t.Helper()
+	resp, result := doRequest(t, app, "GET", fmt.Sprintf("/tasks/%d", id), token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 fetching task but got %d: %v", resp.StatusCode, result)
+	}
+	return result["data"].(map[string]interface{})
+}
+
+func TestTaskDueAtAndPriority(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "dueuser")["token"].(string)
+
+	id := createTestTask(t, app, token, map[string]string{"title": "Pay invoice", "priority": "high", "due_at": "2030-01-15T10:00:00Z"})
+	task := getTaskData(t, app, token, id)
+	if task["priority"] != "high" || task["due_at"] != "2030-01-15T10:00:00Z" || task["completed_at"] != nil {
+		t.Errorf("Unexpected task data: %v", task)
+	}
+
+	// tanpa priority dan due_at: medium dan tanpa tenggat; tanggal saja berarti akhir hari
+	plain := getTaskData(t, app, token, createTestTask(t, app, token, map[string]string{"title": "Plain"}))
+	if plain["priority"] != "medium" || plain["due_at"] != nil {
+		t.Errorf("Expected default priority and no due date, got %v", plain)
+	}
+	dated := getTaskData(t, app, token, createTestTask(t, app, token, map[string]string{"due_at": "2030-02-01"}))
+	if due, _ := time.Parse(time.RFC3339, dated["due_at"].(string)); !due.Equal(time.Date(2030, 2, 1, 23, 59, 59, 999999999, time.UTC)) {
+		t.Errorf("Expected date-only due_at at the end of the day, got %v", dated["due_at"])
+	}
+
+	for _, body := range []map[string]string{
+		{"title": "x", "description": "x", "status": "pending", "priority": "critical"},
+		{"title": "x", "description": "x", "status": "pending", "due_at": "next week"},
+	} {
+		if resp, _ := doRequest(t, app, "POST", "/tasks", token, body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 creating task with %v but got %d", body, resp.StatusCode)
+		}
+	}
+	path := fmt.Sprintf("/tasks/%d", id)
+	for _, body := range []map[string]string{{"priority": "critical"}, {"due_at": "tomorrow"}} {
+		if resp, _ := doRequest(t, app, "PUT", path, token, body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 updating task with %v but got %d", body, resp.StatusCode)
+		}
+	}
+
+	// completed_at diisi saat completed dan dikosongkan saat dibuka kembali
+	if resp, result := doRequest(t, app, "PUT", path, token, map[string]string{"status": "completed", "priority": "urgent"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 updating task but got %d: %v", resp.StatusCode, result)
+	}
+	task = getTaskData(t, app, token, id)
+	if task["completed_at"] == nil || task["priority"] != "urgent" || task["due_at"] != "2030-01-15T10:00:00Z" {
+		t.Errorf("Expected completed_at to be set and due_at kept, got %v", task)
+	}
+	completedAt := task["completed_at"]
+	doRequest(t, app, "PUT", path, token, map[string]string{"status": "completed"})
+	if task = getTaskData(t, app, token, id); task["completed_at"] != completedAt {
+		t.Errorf("Expected completed_at to stay %v, got %v", completedAt, task["completed_at"])
+	}
+	doRequest(t, app, "PUT", path, token, map[string]string{"status": "in_progress", "due_at": ""})
+	if task = getTaskData(t, app, token, id); task["completed_at"] != nil || task["due_at"] != nil {
+		t.Errorf("Expected completed_at and due_at to be cleared, got %v", task)
+	}
+
+	// filter priority dan rentang tenggat
+	_, data, _ := listTasks(t, app, token, url.Values{"priority": {"urgent"}})
+	if len(data) != 1 || int(data[0].(map[string]interface{})["id"].(float64)) != id {
+		t.Errorf("Expected only the urgent task, got %v", data)
+	}
+	_, data, _ = listTasks(t, app, token, url.Values{"due_from": {"2030-02-01"}, "due_to": {"2030-02-28"}})
+	if len(data) != 1 || data[0].(map[string]interface{})["due_at"] == nil {
+		t.Errorf("Expected one task due in February 2030, got %v", data)
+	}
+	for _, query := range []url.Values{{"priority": {"critical"}}, {"due_from": {"soon"}}} {
+		if status, _, _ := listTasks(t, app, token, query); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %v but got %d", query, status)
+		}
+	}
+}
+
+func TestListOverdueTasks(t *testing.T) {
+	app := CreateTestApp(t)
+	owner := CreateTestUser(app, t, "overdue")
+	token := owner["token"].(string)
+	yesterday := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	tomorrow := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+
+	late := createTestTask(t, app, token, map[string]string{"title": "late", "due_at": yesterday})
+	later := createTestTask(t, app, token, map[string]string{"title": "later", "due_at": lastWeek, "priority": "urgent"})
+	createTestTask(t, app, token, map[string]string{"title": "done", "due_at": lastWeek, "status": "completed"})
+	createTestTask(t, app, token, map[string]string{"title": "future", "due_at": tomorrow})
+	createTestTask(t, app, token, map[string]string{"title": "no due date"})
+	other := CreateTestUser(app, t, "overdueother")
+	createTestTask(t, app, other["token"].(string), map[string]string{"title": "not mine", "due_at": lastWeek})
+
+	resp, result := doRequest(t, app, "GET", "/tasks/overdue", token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 but got %d: %v", resp.StatusCode, result)
+	}
+	var ids []int
+	for _, item := range result["data"].([]interface{}) {
+		ids = append(ids, int(item.(map[string]interface{})["id"].(float64)))
+	}
+	if fmt.Sprint(ids) != fmt.Sprint([]int{later, late}) {
+		t.Errorf("Expected overdue tasks %v (oldest due date first), got %v", []int{later, late}, ids)
+	}
+
+	// filter ListTasks tetap berlaku, dan cursor mengikuti sort due_at
+	resp, result = doRequest(t, app, "GET", "/tasks/overdue?priority=urgent", token, nil)
+	if data, _ := result["data"].([]interface{}); resp.StatusCode != http.StatusOK || len(data) != 1 {
+		t.Errorf("Expected one urgent overdue task, got %v", result)
+	}
+	resp, result = doRequest(t, app, "GET", "/tasks/overdue?limit=1", token, nil)
+	cursor, _ := result["meta"].(map[string]interface{})["next_cursor"].(string)
+	if resp.StatusCode != http.StatusOK || cursor == "" {
+		t.Fatalf("Expected a next cursor, got %v", result)
+	}
+	resp, result = doRequest(t, app, "GET", "/tasks/overdue?limit=1&cursor="+url.QueryEscape(cursor), token, nil)
+	if data, _ := result["data"].([]interface{}); resp.StatusCode != http.StatusOK || len(data) != 1 || int(data[0].(map[string]interface{})["id"].(float64)) != late {
+		t.Errorf("Expected the second page to contain task %d, got %v", late, result)
+	}
+}
@@ -0,0 +1,164 @@
+package test
+
+import (
+	"belajar-go/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// taskHistory mengambil riwayat task lewat GET /tasks/:id/history
+func taskHistory(t *testing.T, app *TestApp, token string, id int) []map[string]interface{} {
+	t.Helper()
+	resp, result := doRequest(t, app, "GET", fmt.Sprintf("/tasks/%d/history", id), token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 fetching history but got %d: %v", resp.StatusCode, result)
+	}
+	if strings.Contains(fmt.Sprint(result), "top-secret") {
+		t.Errorf("Security code leaked into history: %v", result)
+	}
+	var history []map[string]interface{}
+	for _, item := range result["data"].([]interface{}) {
+		history = append(history, item.(map[string]interface{}))
+	}
+	return history
+}
+
+// change mengambil nilai from dan to satu field dari baris riwayat
+func change(entry map[string]interface{}, field string) (interface{}, interface{}, bool) {
+	c, ok := entry["changes"].(map[string]interface{})[field].(map[string]interface{})
+	if !ok {
+		return nil, nil, false
+	}
+	return c["from"], c["to"], true
+}
+
+func TestTaskHistory(t *testing.T) {
+	app := CreateTestApp(t)
+	owner := CreateTestUser(app, t, "history")
+	token := owner["token"].(string)
+	ownerID := owner["user_id"].(float64)
+	adminToken, adminID, _ := CreateTestAdmin(app, t)
+
+	id := createTestTask(t, app, token, map[string]string{"title": "Draft", "security_code": "top-secret"})
+	path := fmt.Sprintf("/tasks/%d", id)
+	doRequest(t, app, "PUT", path, token, map[string]string{"title": "Final", "status": "in_progress", "security_code": "top-secret-2"})
+	doRequest(t, app, "PUT", path, token, map[string]string{"title": "Final"}) // tidak ada perubahan
+	doRequest(t, app, "PUT", path, adminToken, map[string]string{"priority": "urgent"})
+
+	history := taskHistory(t, app, token, id)
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 history rows, got %v", history)
+	}
+	created, updated, byAdmin := history[0], history[1], history[2]
+	if created["version"] != float64(1) || created["action"] != "create" || created["actor_id"] != ownerID {
+		t.Errorf("Unexpected create row: %v", created)
+	}
+	if from, to, _ := change(created, "title"); from != nil || to != "Draft" {
+		t.Errorf("Expected title nil -> Draft on create, got %v -> %v", from, to)
+	}
+	if from, to, _ := change(updated, "title"); updated["action"] != "update" || from != "Draft" || to != "Final" {
+		t.Errorf("Expected title Draft -> Final, got %v", updated)
+	}
+	if from, to, _ := change(updated, "security_code"); from != "[REDACTED]" || to != "[REDACTED]" {
+		t.Errorf("Expected redacted security code change, got %v -> %v", from, to)
+	}
+	if _, _, ok := change(byAdmin, "title"); ok || byAdmin["actor_id"] != float64(adminID) {
+		t.Errorf("Expected only the admin's priority change, got %v", byAdmin)
+	}
+
+	// revert ke versi 1: isi dan status kembali, dicatat sebagai revert
+	resp, result := doRequest(t, app, "POST", path+"/revert", token, map[string]int{"version": 1})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 reverting task but got %d: %v", resp.StatusCode, result)
+	}
+	task := getTaskData(t, app, token, id)
+	if task["title"] != "Draft" || task["status"] != "pending" || task["priority"] != "medium" || task["security_code"] != "top-secret-2" {
+		t.Errorf("Unexpected task after revert: %v", task)
+	}
+	history = taskHistory(t, app, token, id)
+	if last := history[len(history)-1]; last["action"] != "revert" || last["reverted_to"] != float64(1) || last["version"] != float64(4) {
+		t.Errorf("Unexpected revert row: %v", last)
+	}
+	if resp, _ := doRequest(t, app, "POST", path+"/revert", token, map[string]int{"version": 99}); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown version but got %d", resp.StatusCode)
+	}
+
+	// user lain tidak bisa membaca atau memakai riwayat
+	stranger := CreateTestUser(app, t, "historystranger")["token"].(string)
+	if resp, _ := doRequest(t, app, "GET", path+"/history", stranger, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 reading history of another user but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "POST", path+"/revert", stranger, map[string]int{"version": 1}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 reverting task of another user but got %d", resp.StatusCode)
+	}
+
+	// riwayat tetap ada setelah task dihapus
+	doRequest(t, app, "DELETE", path, token, nil)
+	history = taskHistory(t, app, token, id)
+	last := history[len(history)-1]
+	if from, to, _ := change(last, "title"); last["action"] != "delete" || from != "Draft" || to != nil {
+		t.Errorf("Unexpected delete row: %v", last)
+	}
+	if resp, _ := doRequest(t, app, "GET", path+"/history", stranger, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 reading history of a deleted task of another user but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "GET", "/tasks/999999/history", token, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a task without history but got %d", resp.StatusCode)
+	}
+}
+
+// TestRevertTaskWorkflow: revert tidak bisa melewati workflow status
+func TestRevertTaskWorkflow(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "revertflow")["token"].(string)
+	id := createTestTask(t, app, token, map[string]string{"status": "pending"})
+	updateStatus(t, app, token, id, "completed")
+
+	resp, result := doRequest(t, app, "POST", fmt.Sprintf("/tasks/%d/revert", id), token, map[string]int{"version": 1})
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 reverting completed task to pending but got %d: %v", resp.StatusCode, result)
+	}
+	if task := getTaskData(t, app, token, id); task["status"] != "completed" {
+		t.Errorf("Expected task to stay completed, got %v", task)
+	}
+}
+
+// TestRevertTaskEmptyDescription: revert ke versi dengan deskripsi kosong
+// benar-benar mengosongkan deskripsi
+func TestRevertTaskEmptyDescription(t *testing.T) {
+	app := CreateTestApp(t)
+	owner := CreateTestUser(app, t, "revertdesc")
+	token := owner["token"].(string)
+
+	// API mewajibkan deskripsi, jadi task tanpa deskripsi dibuat lewat repository
+	securityCode, err := app.Deps.Keyring.Encrypt("code")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	task := models.Task{
+		UserID:       int(owner["user_id"].(float64)),
+		Title:        "No description",
+		Status:       "pending",
+		Priority:     models.TaskPriorityMedium,
+		SecurityCode: securityCode,
+	}
+	if err := app.Deps.Tasks.Create(context.Background(), &task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+	doRequest(t, app, "PUT", path, token, map[string]string{"description": "Filled in"})
+
+	resp, result := doRequest(t, app, "POST", path+"/revert", token, map[string]int{"version": 1})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 reverting task but got %d: %v", resp.StatusCode, result)
+	}
+	if data := result["data"].(map[string]interface{}); data["description"] != "" {
+		t.Errorf("Expected empty description after revert, got %v", data)
+	}
+	if got := getTaskData(t, app, token, task.ID); got["description"] != "" {
+		t.Errorf("Expected empty description to be stored, got %v", got)
+	}
+}
@@ -0,0 +1,180 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// createTestLabel membuat label dan mengembalikan ID-nya
+func createTestLabel(t *testing.T, app *TestApp, token string, label map[string]interface{}) int {
+	t.Helper()
+	resp, result := doRequest(t, app, "POST", "/labels", token, label)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating label %v but got %d: %v", label, resp.StatusCode, result)
+	}
+	return int(result["data"].(map[string]interface{})["id"].(float64))
+}
+
+// labelNames mengambil nama label dari field labels sebuah task
+func labelNames(task map[string]interface{}) []string {
+	names := []string{}
+	labels, _ := task["labels"].([]interface{})
+	for _, l := range labels {
+		names = append(names, l.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestLabels(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "labeler")["token"].(string)
+	other := CreateTestUser(app, t, "otherlabeler")["token"].(string)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+
+	resp, result := doRequest(t, app, "POST", "/labels", token, map[string]interface{}{"name": " Bug ", "color": "#FF0000"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201 creating label but got %d: %v", resp.StatusCode, result)
+	}
+	bug := result["data"].(map[string]interface{})
+	if bug["name"] != "Bug" || bug["color"] != "#ff0000" || bug["user_id"] == nil {
+		t.Errorf("Unexpected label: %v", bug)
+	}
+	bugID := int(bug["id"].(float64))
+
+	for _, label := range []map[string]interface{}{
+		{"color": "#ff0000"},
+		{"name": "x", "color": "red"},
+		{"name": "x", "color": "#fff"},
+	} {
+		if resp, _ := doRequest(t, app, "POST", "/labels", token, label); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %v but got %d", label, resp.StatusCode)
+		}
+	}
+	// nama unik per user tanpa membedakan huruf besar/kecil
+	if resp, _ := doRequest(t, app, "POST", "/labels", token, map[string]interface{}{"name": "bug"}); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 for a duplicate name but got %d", resp.StatusCode)
+	}
+	otherBug := createTestLabel(t, app, other, map[string]interface{}{"name": "bug"})
+
+	// label bersama hanya bisa dikelola dengan labels:manage
+	if resp, _ := doRequest(t, app, "POST", "/labels", token, map[string]interface{}{"name": "Urgent", "shared": true}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 creating a shared label as member but got %d", resp.StatusCode)
+	}
+	shared := createTestLabel(t, app, adminToken, map[string]interface{}{"name": "Urgent", "shared": true})
+	sharedPath := fmt.Sprintf("/labels/%d", shared)
+	if resp, _ := doRequest(t, app, "PUT", sharedPath, token, map[string]interface{}{"color": "#000000"}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 updating a shared label as member but got %d", resp.StatusCode)
+	}
+
+	resp, result = doRequest(t, app, "GET", "/labels", token, nil)
+	labels, _ := result["data"].([]interface{})
+	if resp.StatusCode != http.StatusOK || len(labels) != 2 ||
+		labels[0].(map[string]interface{})["name"] != "Bug" || labels[1].(map[string]interface{})["user_id"] != nil {
+		t.Errorf("Expected own label and shared label, got %d: %v", resp.StatusCode, result)
+	}
+
+	// label pribadi user lain dianggap tidak ada
+	if resp, _ := doRequest(t, app, "PUT", fmt.Sprintf("/labels/%d", otherBug), token, map[string]interface{}{"name": "mine"}); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 updating another user's label but got %d", resp.StatusCode)
+	}
+	resp, result = doRequest(t, app, "PUT", fmt.Sprintf("/labels/%d", bugID), token, map[string]interface{}{"name": "Defect"})
+	if updated, _ := result["data"].(map[string]interface{}); resp.StatusCode != http.StatusOK || updated["name"] != "Defect" || updated["color"] != "#ff0000" {
+		t.Errorf("Unexpected update result %d: %v", resp.StatusCode, result)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", sharedPath, adminToken, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 deleting shared label but got %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", sharedPath, adminToken, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 deleting a deleted label but got %d", resp.StatusCode)
+	}
+}
+
+func TestTaskLabels(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "tasklabeler")["token"].(string)
+	other := CreateTestUser(app, t, "tasklabelother")["token"].(string)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+
+	bug := createTestLabel(t, app, token, map[string]interface{}{"name": "bug"})
+	ui := createTestLabel(t, app, token, map[string]interface{}{"name": "ui"})
+	shared := createTestLabel(t, app, adminToken, map[string]interface{}{"name": "release", "shared": true})
+	otherLabel := createTestLabel(t, app, other, map[string]interface{}{"name": "private"})
+	adminLabel := createTestLabel(t, app, adminToken, map[string]interface{}{"name": "admin only"})
+
+	both := createTestTask(t, app, token, map[string]string{"title": "Both"})
+	onlyBug := createTestTask(t, app, token, map[string]string{"title": "Only bug"})
+	createTestTask(t, app, token, map[string]string{"title": "None"})
+	attach := func(token string, taskID, labelID int) (int, map[string]interface{}) {
+		resp, result := doRequest(t, app, "POST", fmt.Sprintf("/tasks/%d/labels/%d", taskID, labelID), token, nil)
+		return resp.StatusCode, result
+	}
+
+	for _, labelID := range []int{bug, ui, shared, bug} {
+		if status, result := attach(token, both, labelID); status != http.StatusOK {
+			t.Fatalf("Expected status 200 attaching label %d but got %d: %v", labelID, status, result)
+		}
+	}
+	if status, _ := attach(token, onlyBug, bug); status != http.StatusOK {
+		t.Fatalf("Expected status 200 attaching label but got %d", status)
+	}
+	if status, _ := attach(token, both, otherLabel); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 attaching another user's label but got %d", status)
+	}
+	if status, _ := attach(other, both, otherLabel); status != http.StatusForbidden {
+		t.Errorf("Expected status 403 labeling another user's task but got %d", status)
+	}
+	// admin boleh memasang label bersama, tetapi tidak label pribadinya sendiri
+	if status, _ := attach(adminToken, onlyBug, adminLabel); status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 attaching a personal label to another user's task but got %d", status)
+	}
+
+	task := getTaskData(t, app, token, both)
+	if names := fmt.Sprint(labelNames(task)); names != "[bug release ui]" {
+		t.Errorf("Expected labels [bug release ui] but got %s", names)
+	}
+
+	titles := func(query url.Values) []string {
+		t.Helper()
+		status, data, _ := listTasks(t, app, token, query)
+		if status != http.StatusOK {
+			t.Fatalf("Expected status 200 for %v but got %d", query, status)
+		}
+		var result []string
+		for _, item := range data {
+			result = append(result, item.(map[string]interface{})["title"].(string))
+		}
+		return result
+	}
+	if got := fmt.Sprint(titles(url.Values{"label": {fmt.Sprintf("%d,%d", ui, bug)}})); got != "[Both Only bug]" {
+		t.Errorf("Expected any-match [Both Only bug] but got %s", got)
+	}
+	if got := fmt.Sprint(titles(url.Values{"label": {fmt.Sprintf("%d,%d", ui, bug)}, "label_match": {"all"}})); got != "[Both]" {
+		t.Errorf("Expected all-match [Both] but got %s", got)
+	}
+	for _, query := range []url.Values{{"label": {"abc"}}, {"label_match": {"some"}}} {
+		if status, _, _ := listTasks(t, app, token, query); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %v but got %d", query, status)
+		}
+	}
+
+	detachPath := fmt.Sprintf("/tasks/%d/labels/%d", both, ui)
+	if resp, result := doRequest(t, app, "DELETE", detachPath, token, nil); resp.StatusCode != http.StatusOK || len(result["data"].([]interface{})) != 2 {
+		t.Errorf("Expected 2 labels left after detach, got %d: %v", resp.StatusCode, result)
+	}
+	if resp, _ := doRequest(t, app, "DELETE", detachPath, token, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 detaching a label that is not attached but got %d", resp.StatusCode)
+	}
+
+	// menghapus label melepasnya dari semua task
+	if resp, _ := doRequest(t, app, "DELETE", fmt.Sprintf("/labels/%d", bug), token, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 deleting label but got %d", resp.StatusCode)
+	}
+	if names := fmt.Sprint(labelNames(getTaskData(t, app, token, onlyBug))); names != "[]" {
+		t.Errorf("Expected no labels after deleting the label but got %s", names)
+	}
+	if got := titles(url.Values{"label": {fmt.Sprint(bug)}}); len(got) != 0 {
+		t.Errorf("Expected no tasks for a deleted label but got %v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// listTasks memanggil GET /tasks dengan query string dan mengembalikan data dan meta
+func listTasks(t *testing.T, app *TestApp, token string, query url.Values) (int, []interface{}, map[string]interface{}) {
+	t.Helper()
+	resp, result := doRequest(t, app, "GET", "/tasks?"+query.Encode(), token, nil)
+	data, _ := result["data"].([]interface{})
+	meta, _ := result["meta"].(map[string]interface{})
+	return resp.StatusCode, data, meta
+}
+
+func TestListTasksPagination(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "pageuser")["token"].(string)
+
+	var created []int
+	for i := 0; i < 5; i++ {
+		created = append(created, createTestTask(t, app, token, map[string]string{
+			"title": fmt.Sprintf("Task %d", i), "status": "pending", "security_code": "secret",
+		}))
+	}
+
+	var seen []int
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("Too many pages, cursor does not advance")
+		}
+		query := url.Values{"limit": {"2"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		status, data, meta := listTasks(t, app, token, query)
+		if status != http.StatusOK {
+			t.Fatalf("Expected status 200 but got %d", status)
+		}
+		if meta["total"] != float64(5) {
+			t.Errorf("Expected total 5 but got %v", meta["total"])
+		}
+		for _, item := range data {
+			task := item.(map[string]interface{})
+			if _, ok := task["security_code"]; ok {
+				t.Errorf("Expected security_code to be omitted from list")
+			}
+			seen = append(seen, int(task["id"].(float64)))
+		}
+		next, _ := meta["next_cursor"].(string)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if fmt.Sprint(seen) != fmt.Sprint(created) {
+		t.Errorf("Expected tasks %v across pages but got %v", created, seen)
+	}
+}
+
+func TestListTasksFilterAndSort(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "filteruser")["token"].(string)
+
+	createTestTask(t, app, token, map[string]string{"title": "Write report", "status": "pending", "security_code": "a"})
+	createTestTask(t, app, token, map[string]string{"title": "Review REPORT", "status": "completed", "security_code": "b"})
+	createTestTask(t, app, token, map[string]string{"title": "Buy milk", "status": "pending", "security_code": "c"})
+
+	status, data, meta := listTasks(t, app, token, url.Values{"title": {"report"}})
+	if status != http.StatusOK || len(data) != 2 || meta["total"] != float64(2) {
+		t.Errorf("Expected 2 tasks matching title, got %d (status %d)", len(data), status)
+	}
+
+	_, data, _ = listTasks(t, app, token, url.Values{"title": {"report"}, "status": {"pending"}})
+	if len(data) != 1 || data[0].(map[string]interface{})["title"] != "Write report" {
+		t.Errorf("Expected only 'Write report', got %v", data)
+	}
+
+	_, data, _ = listTasks(t, app, token, url.Values{"sort": {"title"}, "order": {"desc"}})
+	var titles []string
+	for _, item := range data {
+		titles = append(titles, item.(map[string]interface{})["title"].(string))
+	}
+	if fmt.Sprint(titles) != "[Write report Review REPORT Buy milk]" {
+		t.Errorf("Unexpected order for sort=title desc: %v", titles)
+	}
+
+	_, data, _ = listTasks(t, app, token, url.Values{"created_from": {"2000-01-01"}, "created_to": {"2000-12-31"}})
+	if len(data) != 0 {
+		t.Errorf("Expected no tasks created in 2000, got %d", len(data))
+	}
+}
+
+func TestListTasksInvalidQuery(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "badquery")["token"].(string)
+	for i := 0; i < 3; i++ {
+		createTestTask(t, app, token, map[string]string{"title": "t", "status": "pending", "security_code": "x"})
+	}
+
+	for _, query := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"1000"}},
+		{"sort": {"security_code"}},
+		{"order": {"sideways"}},
+		{"status": {"unknown"}},
+		{"created_from": {"yesterday"}},
+		{"cursor": {"not-a-cursor"}},
+	} {
+		if status, _, _ := listTasks(t, app, token, query); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %v but got %d", query, status)
+		}
+	}
+
+	// cursor dari urutan lain tidak boleh dipakai
+	_, _, meta := listTasks(t, app, token, url.Values{"limit": {"1"}})
+	cursor := meta["next_cursor"].(string)
+	if status, _, _ := listTasks(t, app, token, url.Values{"cursor": {cursor}, "sort": {"title"}}); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for cursor with different sort but got %d", status)
+	}
+}
+
+func TestListTasksUserFilter(t *testing.T) {
+	app := CreateTestApp(t)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+	owner := CreateTestUser(app, t, "owner")
+	other := CreateTestUser(app, t, "other")
+	ownerID := int(owner["user_id"].(float64))
+
+	createTestTask(t, app, owner["token"].(string), map[string]string{"title": "mine", "status": "pending", "security_code": "x"})
+	createTestTask(t, app, other["token"].(string), map[string]string{"title": "theirs", "status": "pending", "security_code": "x"})
+
+	// member tidak boleh melihat task user lain
+	status, _, _ := listTasks(t, app, other["token"].(string), url.Values{"user_id": {fmt.Sprint(ownerID)}})
+	if status != http.StatusForbidden {
+		t.Errorf("Expected status 403 for member filtering by user_id but got %d", status)
+	}
+
+	// member hanya melihat task miliknya
+	_, data, _ := listTasks(t, app, other["token"].(string), nil)
+	if len(data) != 1 || data[0].(map[string]interface{})["title"] != "theirs" {
+		t.Errorf("Expected member to see only own task, got %v", data)
+	}
+
+	// admin bisa memfilter berdasarkan user_id
+	_, data, meta := listTasks(t, app, adminToken, url.Values{"user_id": {fmt.Sprint(ownerID)}})
+	if len(data) != 1 || meta["total"] != float64(1) || data[0].(map[string]interface{})["title"] != "mine" {
+		t.Errorf("Expected admin to see only owner's task, got %v", data)
+	}
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// searchTasks memanggil GET /tasks/search dan mengembalikan status dan data
+func searchTasks(t *testing.T, app *TestApp, token string, query url.Values) (int, []interface{}) {
+	t.Helper()
+	resp, result := doRequest(t, app, "GET", "/tasks/search?"+query.Encode(), token, nil)
+	data, _ := result["data"].([]interface{})
+	return resp.StatusCode, data
+}
+
+func TestSearchTasks(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "searchuser")["token"].(string)
+
+	reportID := createTestTask(t, app, token, map[string]string{"title": "Quarterly report", "description": "Collect <numbers> for finance"})
+	reviewID := createTestTask(t, app, token, map[string]string{"title": "Review budget", "description": "Compare with the quarterly report", "status": "completed"})
+	createTestTask(t, app, token, map[string]string{"title": "Buy milk", "description": "From the store"})
+
+	// prefix matching, hasil di judul mendapat ranking lebih tinggi
+	status, data := searchTasks(t, app, token, url.Values{"q": {"quart rep"}})
+	if status != http.StatusOK || len(data) != 2 {
+		t.Fatalf("Expected 2 results but got %d (status %d)", len(data), status)
+	}
+	first := data[0].(map[string]interface{})
+	if int(first["id"].(float64)) != reportID {
+		t.Errorf("Expected task %d ranked first but got %v", reportID, first["id"])
+	}
+	if first["title_highlight"] != "<mark>Quarterly</mark> <mark>report</mark>" {
+		t.Errorf("Unexpected title highlight: %v", first["title_highlight"])
+	}
+	if _, ok := first["security_code"]; ok {
+		t.Errorf("Expected security_code to be omitted from search results")
+	}
+
+	// teks di luar highlight di-escape
+	_, data = searchTasks(t, app, token, url.Values{"q": {"numbers"}})
+	if len(data) != 1 || data[0].(map[string]interface{})["snippet"] != "Collect &lt;<mark>numbers</mark>&gt; for finance" {
+		t.Errorf("Unexpected snippet: %v", data)
+	}
+
+	// filter status
+	_, data = searchTasks(t, app, token, url.Values{"q": {"report"}, "status": {"completed"}})
+	if len(data) != 1 || int(data[0].(map[string]interface{})["id"].(float64)) != reviewID {
+		t.Errorf("Expected only completed task %d but got %v", reviewID, data)
+	}
+
+	if status, _ := searchTasks(t, app, token, url.Values{"q": {"  !! "}}); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for empty query but got %d", status)
+	}
+}
+
+func TestSearchTasksOwnership(t *testing.T) {
+	app := CreateTestApp(t)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+	owner := CreateTestUser(app, t, "searchowner")["token"].(string)
+	other := CreateTestUser(app, t, "searchother")["token"].(string)
+
+	createTestTask(t, app, owner, map[string]string{"title": "Private roadmap"})
+
+	if _, data := searchTasks(t, app, other, url.Values{"q": {"roadmap"}}); len(data) != 0 {
+		t.Errorf("Expected other member not to find the task, got %v", data)
+	}
+	if _, data := searchTasks(t, app, owner, url.Values{"q": {"roadmap"}}); len(data) != 1 {
+		t.Errorf("Expected owner to find the task, got %v", data)
+	}
+	if _, data := searchTasks(t, app, adminToken, url.Values{"q": {"roadmap"}}); len(data) != 1 {
+		t.Errorf("Expected admin to find the task, got %v", data)
+	}
+}
@@ -12,7 +12,7 @@ import (
 
 // TestCreateTask: Uji pembuatan task baru
 func TestCreateTask(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	// Register dan login user untuk task
 	uniqueUser := fmt.Sprintf("taskuser_%d", time.Now().UnixNano())
@@ -81,7 +81,7 @@ func TestCreateTask(t *testing.T) {
 
 // TestListTasks: Uji endpoint list tasks
 func TestListTasks(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	uniqueUser := fmt.Sprintf("listuser_%d", time.Now().UnixNano())
 	// Register & login
@@ -148,7 +148,7 @@ func TestListTasks(t *testing.T) {
 
 // TestGetTask: Uji endpoint ambil task berdasarkan ID
 func TestGetTask(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	uniqueUser := fmt.Sprintf("gettaskuser_%d", time.Now().UnixNano())
 	regBody := map[string]string{
@@ -218,7 +218,7 @@ func TestGetTask(t *testing.T) {
 
 // TestUpdateTask: Uji endpoint update task
 func TestUpdateTask(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	uniqueUser := fmt.Sprintf("updatetaskuser_%d", time.Now().UnixNano())
 	regBody := map[string]string{
@@ -307,7 +307,7 @@ func TestUpdateTask(t *testing.T) {
 
 // TestDeleteTask: Uji endpoint hapus task
 func TestDeleteTask(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	uniqueUser := fmt.Sprintf("deletetaskuser_%d", time.Now().UnixNano())
 	regBody := map[string]string{
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"belajar-go/configs"
+	"belajar-go/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const reviewWorkflow = `
+statuses: [todo, doing, review, completed]
+initial: [todo]
+transitions:
+  - {from: todo, to: doing}
+  - {from: doing, to: review}
+  - {from: review, to: doing}
+  - {from: review, to: completed, roles: [admin]}
+  - {from: completed, to: doing, roles: [admin]}
+`
+
+// updateStatus mengubah status task dan mengembalikan status HTTP dan body
+func updateStatus(t *testing.T, app *TestApp, token string, id int, status string) (int, map[string]interface{}) {
+	t.Helper()
+	resp, result := doRequest(t, app, "PUT", fmt.Sprintf("/tasks/%d", id), token, map[string]string{"status": status})
+	return resp.StatusCode, result
+}
+
+func TestTaskWorkflow(t *testing.T) {
+	file := writeConfigFile(t, "workflow.yaml", reviewWorkflow)
+	app := CreateTestApp(t, func(cfg *configs.Config) { cfg.TaskWorkflowFile = file })
+	token := CreateTestUser(app, t, "workflow")["token"].(string)
+	adminToken, _, _ := CreateTestAdmin(app, t)
+
+	resp, result := doRequest(t, app, "GET", "/tasks/workflow", token, nil)
+	data, _ := result["data"].(map[string]interface{})
+	if resp.StatusCode != http.StatusOK || fmt.Sprint(data["statuses"]) != "[todo doing review completed]" || len(data["transitions"].([]interface{})) != 5 {
+		t.Fatalf("Unexpected workflow response %d: %v", resp.StatusCode, result)
+	}
+
+	// hanya status awal yang boleh dipakai saat membuat task
+	for status, want := range map[string]int{"doing": 422, "pending": 422} {
+		resp, _ := doRequest(t, app, "POST", "/tasks", token, map[string]string{"title": "x", "description": "x", "status": status})
+		if resp.StatusCode != want {
+			t.Errorf("Expected status %d creating task as %s but got %d", want, status, resp.StatusCode)
+		}
+	}
+	id := createTestTask(t, app, token, map[string]string{"status": "todo"})
+
+	code, result := updateStatus(t, app, token, id, "completed")
+	if code != http.StatusConflict || !strings.Contains(fmt.Sprint(result["errors"]), "allowed next statuses: doing") {
+		t.Errorf("Expected 409 listing the allowed statuses but got %d: %v", code, result)
+	}
+	if code, _ := updateStatus(t, app, token, id, "archived"); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for unknown status but got %d", code)
+	}
+	for _, status := range []string{"doing", "review"} {
+		if code, result := updateStatus(t, app, token, id, status); code != http.StatusOK {
+			t.Fatalf("Expected 200 moving task to %s but got %d: %v", status, code, result)
+		}
+	}
+
+	// review -> completed dan membuka kembali task hanya untuk admin
+	if code, _ := updateStatus(t, app, token, id, "completed"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for member completing task but got %d", code)
+	}
+	if code, result := updateStatus(t, app, adminToken, id, "completed"); code != http.StatusOK {
+		t.Fatalf("Expected 200 for admin completing task but got %d: %v", code, result)
+	}
+	if task := getTaskData(t, app, token, id); task["status"] != "completed" || task["completed_at"] == nil {
+		t.Errorf("Expected completed task with completed_at, got %v", task)
+	}
+	if code, _ := updateStatus(t, app, token, id, "doing"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for member reopening task but got %d", code)
+	}
+	if code, _ := updateStatus(t, app, token, id, "completed"); code != http.StatusOK {
+		t.Errorf("Expected 200 when the status does not change but got %d", code)
+	}
+}
+
+func TestDefaultTaskWorkflow(t *testing.T) {
+	app := CreateTestApp(t)
+	token := CreateTestUser(app, t, "defaultflow")["token"].(string)
+	id := createTestTask(t, app, token, map[string]string{"status": "completed"})
+
+	// task completed hanya bisa dibuka kembali ke in_progress
+	if code, _ := updateStatus(t, app, token, id, "pending"); code != http.StatusConflict {
+		t.Errorf("Expected 409 moving completed task to pending but got %d", code)
+	}
+	if code, result := updateStatus(t, app, token, id, "in_progress"); code != http.StatusOK {
+		t.Errorf("Expected 200 reopening task but got %d: %v", code, result)
+	}
+}
+
+func TestTaskWorkflowConfig(t *testing.T) {
+	t.Setenv("EMAIL_LINK_SECRET", validEmailLinkSecret)
+	t.Setenv("ENCRYPTION_KEYS", validEncryptionKey)
+	t.Setenv("JWT_KEY_FILES", testJWTKeyFiles)
+
+	t.Setenv("TASK_WORKFLOW_FILE", writeConfigFile(t, "workflow.toml", `
+statuses = ["open", "completed"]
+
+[[transitions]]
+from = "open"
+to = "completed"
+`))
+	cfg, err := configs.Load(nil)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if workflow, _ := cfg.TaskWorkflow(); fmt.Sprint(workflow.Initial) != "[open]" {
+		t.Errorf("Expected the first status to be the initial status, got %+v", workflow)
+	}
+
+	t.Setenv("TASK_WORKFLOW_FILE", writeConfigFile(t, "workflow.yaml", `
+statuses: [open, Done, open]
+initial: [closed]
+transitions:
+  - {from: open, to: open}
+  - {from: open, to: closed}
+`))
+	_, err = configs.Load(nil)
+	expectProblems(t, err,
+		`TASK_WORKFLOW_FILE: status "Done" must be 1-50 lower-case letters`,
+		`status "open" is defined twice`,
+		fmt.Sprintf("statuses must include %q", models.TaskStatusCompleted),
+		`initial status "closed" is not in statuses`,
+		`transition "open" -> "open" does not change the status`,
+		`transition "open" -> "closed" uses a status that is not in statuses`)
+}
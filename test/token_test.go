@@ -0,0 +1,186 @@
+package test
+
+import (
+	"belajar-go/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// refreshRequest memanggil endpoint refresh token dan mengembalikan response-nya
+func refreshRequest(t *testing.T, app *TestApp, refreshToken, deviceID string) (*http.Response, map[string]interface{}) {
+	body, _ := json.Marshal(map[string]string{
+		"refresh_token": refreshToken,
+		"device_id":     deviceID,
+	})
+	req := httptest.NewRequest("POST", "/token/refresh", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Refresh request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	return resp, result
+}
+
+// TestRefreshToken: refresh token ditukar dengan token baru dan token baru bisa dipakai
+func TestRefreshToken(t *testing.T) {
+	app := CreateTestApp(t)
+	login := CreateTestUser(app, t, "refreshuser")
+
+	resp, result := refreshRequest(t, app, login["refresh_token"].(string), login["device_id"].(string))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for refresh, got %d", resp.StatusCode)
+	}
+	data := result["data"].(map[string]interface{})
+	if data["refresh_token"] == login["refresh_token"] {
+		t.Errorf("Expected rotated refresh token")
+	}
+
+	req := httptest.NewRequest("GET", "/tasks", nil)
+	req.Header.Set("Authorization", "Bearer "+data["token"].(string))
+	listResp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("ListTasks error: %v", err)
+	}
+	defer listResp.Body.Close()
+	if listResp.StatusCode != http.StatusOK {
+		t.Errorf("Expected refreshed access token to be accepted, got %d", listResp.StatusCode)
+	}
+}
+
+// TestRefreshTokenReuse: refresh token lama yang dipakai ulang mencabut seluruh family
+func TestRefreshTokenReuse(t *testing.T) {
+	app := CreateTestApp(t)
+	login := CreateTestUser(app, t, "reuseuser")
+	deviceID := login["device_id"].(string)
+	oldToken := login["refresh_token"].(string)
+
+	resp, result := refreshRequest(t, app, oldToken, deviceID)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for first refresh, got %d", resp.StatusCode)
+	}
+	newToken := result["data"].(map[string]interface{})["refresh_token"].(string)
+
+	// pakai ulang token lama -> reuse terdeteksi
+	resp, _ = refreshRequest(t, app, oldToken, deviceID)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for reused refresh token, got %d", resp.StatusCode)
+	}
+
+	// token terbaru di family yang sama ikut dicabut
+	resp, _ = refreshRequest(t, app, newToken, deviceID)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for token in revoked family, got %d", resp.StatusCode)
+	}
+}
+
+// TestRefreshTokenWrongDevice: refresh token tidak bisa dipakai dari device lain
+func TestRefreshTokenWrongDevice(t *testing.T) {
+	app := CreateTestApp(t)
+	login := CreateTestUser(app, t, "deviceuser")
+
+	resp, _ := refreshRequest(t, app, login["refresh_token"].(string), "another-device")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for wrong device, got %d", resp.StatusCode)
+	}
+}
+
+// TestLogout: access token dan refresh token tidak berlaku setelah logout
+func TestLogout(t *testing.T) {
+	app := CreateTestApp(t)
+	login := CreateTestUser(app, t, "logoutuser")
+	token := login["token"].(string)
+
+	body, _ := json.Marshal(map[string]string{"refresh_token": login["refresh_token"].(string)})
+	req := httptest.NewRequest("POST", "/logout", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Logout request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for logout, got %d", resp.StatusCode)
+	}
+
+	listReq := httptest.NewRequest("GET", "/tasks", nil)
+	listReq.Header.Set("Authorization", "Bearer "+token)
+	listResp, err := app.Test(listReq)
+	if err != nil {
+		t.Fatalf("ListTasks error: %v", err)
+	}
+	defer listResp.Body.Close()
+	if listResp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for revoked access token, got %d", listResp.StatusCode)
+	}
+
+	refreshResp, _ := refreshRequest(t, app, login["refresh_token"].(string), login["device_id"].(string))
+	if refreshResp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for refresh after logout, got %d", refreshResp.StatusCode)
+	}
+}
+
+// newRotatingSession membuat RefreshTokenService dengan TTL 1 jam di atas
+// miniredis sendiri, lalu merotasi satu sesi tiga kali dengan jarak 40 menit
+// sehingga sesi itu hidup lebih lama dari TTL. Mengembalikan service,
+// refresh token terakhir, dan ID sesinya.
+func newRotatingSession(t *testing.T, userID int) (*service.RefreshTokenService, string, string) {
+	t.Helper()
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	tokens := service.NewRefreshTokenService(rdb, time.Hour)
+
+	token, rec, err := tokens.Issue(ctx, userID, service.Device{ID: "phone"}, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		mr.FastForward(40 * time.Minute)
+		if token, _, err = tokens.Rotate(ctx, token, "phone"); err != nil {
+			t.Fatalf("Rotate %d failed: %v", i+1, err)
+		}
+	}
+	return tokens, token, rec.FamilyID
+}
+
+// TestRefreshTokenRotationOutlivesTTL: sesi yang terus dirotasi lebih lama
+// dari TTL tetap ikut dicabut saat semua sesi user dicabut
+func TestRefreshTokenRotationOutlivesTTL(t *testing.T) {
+	ctx := context.Background()
+	tokens, token, _ := newRotatingSession(t, 1)
+
+	if err := tokens.RevokeAllForUser(ctx, 1); err != nil {
+		t.Fatalf("RevokeAllForUser failed: %v", err)
+	}
+	if _, _, err := tokens.Rotate(ctx, token, "phone"); !errors.Is(err, service.ErrRefreshTokenInvalid) {
+		t.Errorf("Expected ErrRefreshTokenInvalid after revoking all sessions, got %v", err)
+	}
+}
+
+// TestLogoutWithoutRefreshToken: logout tanpa body tetap mengakhiri sesi
+// access token yang dipakai, sehingga refresh token-nya ikut ditolak
+func TestLogoutWithoutRefreshToken(t *testing.T) {
+	app := CreateTestApp(t)
+	login := CreateTestUser(app, t, "logoutnobody")
+
+	if resp, result := doRequest(t, app, "POST", "/logout", login["token"].(string), nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for logout, got %d: %v", resp.StatusCode, result)
+	}
+	if resp, _ := refreshRequest(t, app, login["refresh_token"].(string), login["device_id"].(string)); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for refresh after logout, got %d", resp.StatusCode)
+	}
+}
@@ -11,7 +11,7 @@ import (
 )
 
 func TestGetAllUsers(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	// Buat admin user dan login untuk mendapatkan token
 	adminToken, _, _ := CreateTestAdmin(app, t)
@@ -40,7 +40,7 @@ func TestGetAllUsers(t *testing.T) {
 }
 
 func TestGetUser(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	// Buat admin user dan login
 	adminToken, adminID, adminUsername := CreateTestAdmin(app, t)
@@ -72,7 +72,7 @@ func TestGetUser(t *testing.T) {
 }
 
 func TestUpdateUser(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	// Buat user reguler melalui register
 	uniqueUser := fmt.Sprintf("user_%d", time.Now().UnixNano())
@@ -172,7 +172,7 @@ func TestUpdateUser(t *testing.T) {
 }
 
 func TestDeleteUser(t *testing.T) {
-	app := CreateTestApp()
+	app := CreateTestApp(t)
 
 	// Buat user reguler melalui register
 	uniqueUser := fmt.Sprintf("user_%d", time.Now().UnixNano())